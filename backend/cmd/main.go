@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"time"
@@ -44,53 +45,102 @@ func main() {
 	adminInitService := services.NewAdminInitService(database.DB)
 	adminInitHandler := handlers.NewAdminInitHandler(adminInitService)
 
-	// 初始化区块链监听器
-	rpcUrl := os.Getenv("ETHEREUM_RPC_URL")
-	wsUrl := os.Getenv("BLOCKCHAIN_WS_URL")
-
-	// 检查是否配置了区块链节点URL
-	if rpcUrl == "" || wsUrl == "" {
-		log.Println("⚠️ 区块链节点URL未配置，跳过区块链监听器初始化")
-		log.Println("💡 请在.env文件中配置以下环境变量:")
-		log.Println("   ETHEREUM_RPC_URL=https://sepolia.infura.io/v3/YOUR_PROJECT_ID")
-		log.Println("   BLOCKCHAIN_WS_URL=wss://sepolia.infura.io/ws/v3/YOUR_PROJECT_ID")
-		log.Println("💡 系统将在没有区块链监听的情况下启动，Safe创建功能仍可正常使用")
+	// 加载链配置注册表（支持多链）
+	chainRegistry := blockchain.LoadChainRegistryFromEnv()
+	blockchain.SetDefaultChainRegistry(chainRegistry)
+
+	// 加载执行账户签名器（加密keystore或远程签名服务）
+	executorSigner, err := blockchain.LoadTxSignerFromEnv(context.Background())
+	if err != nil {
+		log.Printf("⚠️ 执行账户签名器未就绪，提案将无法上链执行: %v", err)
 	} else {
-		// 创建区块链监听器配置
-		monitorConfig := blockchain.MonitorConfig{
-			ChainID:              11155111, // Sepolia测试网链ID
-			SafeFactoryAddress:   "0xa6B71E26C5e0845f74c812102Ca7114b6a896AB2",
-			SafeSingletonAddress: "0xd9Db270c1B5E3Bd161E8c8503c55cEABeE709552",
-			PollInterval:         30 * time.Second, // 30秒轮询间隔
-			ConfirmationBlocks:   3,                // 3个区块确认
-			MaxRetries:           5,                // 最大重试次数
-			BatchSize:            10,               // 批处理大小
+		workflow.SetExecutorSigner(executorSigner)
+	}
+
+	// 为每条配置了节点URL的链初始化区块链监听器
+	for _, chain := range chainRegistry.All() {
+		// 检查是否配置了区块链节点URL
+		if chain.RPCUrl == "" || chain.WSUrl == "" {
+			log.Printf("⚠️ 链 %s (%d) 节点URL未配置，跳过区块链监听器初始化", chain.Name, chain.ChainID)
+			log.Println("💡 请在.env文件中配置以下环境变量:")
+			log.Println("   ETHEREUM_RPC_URL=https://sepolia.infura.io/v3/YOUR_PROJECT_ID")
+			log.Println("   BLOCKCHAIN_WS_URL=wss://sepolia.infura.io/ws/v3/YOUR_PROJECT_ID")
+			log.Println("   或使用 CHAINS=<链ID列表> 与 CHAIN_<链ID>_RPC_URL / CHAIN_<链ID>_WS_URL 配置多链")
+			log.Println("💡 系统将在没有区块链监听的情况下启动，Safe创建功能仍可正常使用")
+			continue
 		}
 
+		// 创建区块链监听器配置
+		monitorConfig := chain.MonitorConfig()
+
 		monitor, err := blockchain.NewSafeCreationMonitor(
-			rpcUrl, wsUrl, database.DB, safeTransactionService, wsHub, monitorConfig,
+			chain.RPCUrl, chain.WSUrl, database.DB, safeTransactionService, wsHub, monitorConfig,
 		)
 		if err != nil {
-			log.Printf("⚠️ 区块链监听器初始化失败: %v", err)
+			log.Printf("⚠️ 链 %s (%d) 区块链监听器初始化失败: %v", chain.Name, chain.ChainID, err)
 			log.Println("💡 请检查区块链节点URL和API密钥是否正确")
-			log.Println("💡 系统将在没有区块链监听的情况下启动")
+			log.Println("💡 系统将在没有该链区块链监听的情况下启动")
+			continue
+		}
+
+		// 🔥 关键修复：设置监控器到workflow引擎，启用提案执行监控
+		workflow.SetSafeMonitor(monitor)
+
+		// 启动区块链监听器
+		go func(chainName string) {
+			log.Printf("🔗 [Safe监控] 启动 %s Safe创建监听器...", chainName)
+			log.Printf("📋 [提案监控] 启动 %s 提案执行监控器...", chainName)
+			if err := monitor.Start(); err != nil {
+				log.Printf("❌ %s 区块链监听器启动失败: %v", chainName, err)
+			}
+		}(chain.Name)
+		log.Printf("✅ %s Safe创建监听器初始化成功", chain.Name)
+		log.Printf("✅ %s 提案执行监控器初始化成功", chain.Name)
+
+		// 启动Safe状态对账器（执行确认后及定时同步owners/threshold/nonce）
+		reconciler, err := blockchain.NewSafeReconciler(chain, database.DB, wsHub)
+		if err != nil {
+			log.Printf("⚠️ 链 %s (%d) Safe状态对账器初始化失败: %v", chain.Name, chain.ChainID, err)
 		} else {
-			// 🔥 关键修复：设置监控器到workflow引擎，启用提案执行监控
-			workflow.SetSafeMonitor(monitor)
-			
-			// 启动区块链监听器
-			go func() {
-				log.Println("🔗 [Safe监控] 启动Safe创建监听器...")
-				log.Println("📋 [提案监控] 启动提案执行监控器...")
-				if err := monitor.Start(); err != nil {
-					log.Printf("❌ 区块链监听器启动失败: %v", err)
-				}
-			}()
-			log.Println("✅ Safe创建监听器初始化成功")
-			log.Println("✅ 提案执行监控器初始化成功")
+			monitor.SetSafeReconciler(reconciler)
+			reconciler.Start()
+		}
+
+		// 启动执行账户nonce维护（恢复重启前的分配记录，填补被丢弃交易留下的空洞）
+		if executorSigner != nil {
+			nonceKeeper, err := blockchain.NewExecutorNonceKeeper(chain, executorSigner, database.DB)
+			if err != nil {
+				log.Printf("⚠️ 链 %s (%d) 执行账户nonce维护初始化失败: %v", chain.Name, chain.ChainID, err)
+			} else {
+				nonceKeeper.Start()
+			}
 		}
+
+		// 启动交易服务同步器（从外部Safe Transaction Service导入待执行交易和确认）
+		if chain.SafeServiceURL != "" && os.Getenv("SAFE_SERVICE_SYNC_ENABLED") == "true" {
+			txServiceSyncer, err := workflow.NewTxServiceSyncer(chain)
+			if err != nil {
+				log.Printf("⚠️ 链 %s (%d) 交易服务同步器初始化失败: %v", chain.Name, chain.ChainID, err)
+			} else {
+				txServiceSyncer.Start()
+			}
+		}
+
+		// 启动Safe交易历史索引器（与监听器并行，按检查点增量索引）
+		historyIndexer, err := blockchain.NewSafeHistoryIndexer(chain, database.DB)
+		if err != nil {
+			log.Printf("⚠️ 链 %s (%d) 历史索引器初始化失败: %v", chain.Name, chain.ChainID, err)
+			continue
+		}
+		historyIndexer.Start()
 	}
 
+	// 启动提案执行队列（在监控器注册之后，恢复的任务执行后才能加入监控）
+	workflow.StartExecutionQueue()
+
+	// 启动SIWE过期nonce清理
+	services.NewSiweNonceCleaner(database.DB).Start()
+
 	// 设置 Gin 模式
 	if os.Getenv("GIN_MODE") == "release" {
 		gin.SetMode(gin.ReleaseMode)
@@ -156,6 +206,8 @@ func setupRouter(wsHub *websocket.Hub, safeTransactionHandler *handlers.SafeTran
 	{
 		auth.POST("/register", handlers.Register)
 		auth.POST("/login", handlers.Login)
+		// 挑战接口无需认证且每次请求都写入nonce，按客户端IP限流
+		auth.POST("/siwe/challenge", middleware.RateLimit(10, time.Minute), handlers.GetSiweChallenge)
 		auth.POST("/wallet-register", handlers.WalletRegister)
 		auth.POST("/wallet-login", handlers.WalletLogin)
 		auth.POST("/refresh", handlers.RefreshToken)
@@ -184,6 +236,9 @@ func setupRouter(wsHub *websocket.Hub, safeTransactionHandler *handlers.SafeTran
 		protected.GET("/safes/:safeId", middleware.RequireSafeAccess("safe.info.view"), handlers.GetSafe)
 		protected.PUT("/safes/:safeId", middleware.RequireSafeAccess("safe.info.manage"), handlers.UpdateSafe)
 		protected.GET("/safes/:safeId/nonce", middleware.RequireSafeAccess("safe.info.view"), handlers.GetSafeNonce)
+		protected.GET("/safes/:safeId/queue", middleware.RequireSafeAccess("safe.proposal.view"), handlers.GetSafeNonceQueue)
+		protected.GET("/safes/:safeId/balances", middleware.RequireSafeAccess("safe.info.view"), handlers.GetSafeBalances)
+		protected.GET("/safes/:safeId/history", middleware.RequireSafeAccess("safe.info.view"), handlers.GetSafeHistory)
 		// 临时开发路由 - Safe相关不需要认证的端点
 		api.GET("/safes/:safeId/available-users", handlers.GetAvailableUsersForSafe)
 		
@@ -210,12 +265,36 @@ func setupRouter(wsHub *websocket.Hub, safeTransactionHandler *handlers.SafeTran
 		}), handlers.SignProposal)
 		protected.DELETE("/proposals/:id/signatures/:signatureId", middleware.RequireAnyPermission("proposal.manage", "signature.revoke"), handlers.RemoveSignature)
 		protected.GET("/proposals/:id/signatures", middleware.OptionalPermissionCheck("proposal.view"), handlers.GetSignatures)
+		protected.POST("/proposals/offline-signing/export", middleware.OptionalPermissionCheck("proposal.view"), handlers.ExportOfflineSigningBundle)
+		protected.POST("/proposals/offline-signing/import", middleware.RequirePermission(middleware.PermissionConfig{
+			PermissionCode: "proposal.sign",
+		}), handlers.ImportOfflineSignatures)
+		protected.POST("/proposals/:id/sync-approvals", middleware.OptionalPermissionCheck("proposal.view"), handlers.SyncOnChainApprovals)
 
 		// 提案执行和拒绝
 		protected.POST("/proposals/:id/execute", middleware.RequirePermission(middleware.PermissionConfig{
 			PermissionCode: "proposal.execute",
 		}), handlers.ExecuteProposalByID)
 		protected.POST("/proposals/:id/reject", middleware.RequireAnyPermission("proposal.manage", "proposal.reject"), handlers.RejectProposal)
+		protected.POST("/proposals/:id/speed-up", middleware.RequirePermission(middleware.PermissionConfig{
+			PermissionCode: "proposal.execute",
+		}), handlers.SpeedUpProposalExecution)
+		protected.POST("/proposals/:id/cancel-execution", middleware.RequirePermission(middleware.PermissionConfig{
+			PermissionCode: "proposal.execute",
+		}), handlers.CancelProposalExecution)
+		protected.GET("/proposals/:id/execution-txs", middleware.OptionalPermissionCheck("proposal.view"), handlers.GetProposalExecutionTxs)
+		protected.GET("/proposals/:id/simulate", middleware.OptionalPermissionCheck("proposal.view"), handlers.SimulateProposal)
+		protected.GET("/proposals/:id/typed-data", middleware.OptionalPermissionCheck("proposal.view"), handlers.GetProposalTypedData)
+		protected.GET("/proposals/:id/execution-jobs", middleware.OptionalPermissionCheck("proposal.view"), handlers.GetProposalExecutionJobs)
+		protected.GET("/execution-jobs/:jobId", middleware.OptionalPermissionCheck("proposal.view"), handlers.GetExecutionJob)
+
+		// Safe Transaction Service兼容路由（:safeId为Safe地址，路径保留官方服务的结尾斜杠）
+		protected.GET("/safes/:safeId/", handlers.GetTxServiceSafe)
+		protected.GET("/safes/:safeId/multisig-transactions/", handlers.GetTxServiceMultisigTransactions)
+		protected.POST("/safes/:safeId/multisig-transactions/", handlers.ProposeTxServiceTransaction)
+		protected.GET("/multisig-transactions/:safeTxHash/", handlers.GetTxServiceMultisigTransaction)
+		protected.GET("/multisig-transactions/:safeTxHash/confirmations/", handlers.GetTxServiceConfirmations)
+		protected.POST("/multisig-transactions/:safeTxHash/confirmations/", handlers.ConfirmTxServiceTransaction)
 
 		// 工作流路由
 		protected.GET("/workflow/status/:proposalId", handlers.GetWorkflowStatus)
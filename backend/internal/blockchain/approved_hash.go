@@ -0,0 +1,107 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+// ApprovedHashOwners 返回已在链上对提案当前SafeTxHash调用approveHash的Safe所有者
+// SafeTxHash按链上当前nonce构建，与执行时使用的哈希一致
+func (se *SafeExecutor) ApprovedHashOwners(ctx context.Context, proposal *models.Proposal) (common.Hash, []common.Address, error) {
+	if int64(proposal.Safe.ChainID) != se.chainID.Int64() {
+		return common.Hash{}, nil, fmt.Errorf("safe %s is on chain %d, executor is connected to chain %s",
+			proposal.Safe.Address, proposal.Safe.ChainID, se.chainID.String())
+	}
+
+	safeAddress := common.HexToAddress(proposal.Safe.Address)
+	safeTx, err := se.buildSafeTransaction(proposal, safeAddress, se.determineProposalType(proposal))
+	if err != nil {
+		return common.Hash{}, nil, fmt.Errorf("failed to build Safe transaction: %w", err)
+	}
+	safeTxHash := ComputeSafeTxHash(se.chainID, safeAddress, safeTx)
+
+	approvers, err := se.approvedHashOwners(ctx, safeAddress, safeTxHash)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	return safeTxHash, approvers, nil
+}
+
+// approvedHashOwners 逐个查询当前所有者的approvedHashes(owner, safeTxHash)
+// 已移除的所有者即使调用过approveHash也不会被Safe合约计入，因此只查询当前所有者
+func (se *SafeExecutor) approvedHashOwners(ctx context.Context, safeAddress common.Address, safeTxHash common.Hash) ([]common.Address, error) {
+	owners, err := se.getSafeOwners(safeAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Safe owners: %w", err)
+	}
+
+	var approvers []common.Address
+	for _, owner := range owners {
+		approved, err := isHashApproved(ctx, se.client, safeAddress, owner, safeTxHash)
+		if err != nil {
+			return nil, err
+		}
+		if approved {
+			approvers = append(approvers, owner)
+		}
+	}
+	return approvers, nil
+}
+
+// isHashApproved 查询owner是否已对safeTxHash调用approveHash
+func isHashApproved(ctx context.Context, caller ethereum.ContractCaller, safeAddress, owner common.Address, safeTxHash common.Hash) (bool, error) {
+	safeABI := getSafeABI()
+	data, err := safeABI.Pack("approvedHashes", owner, safeTxHash)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &safeAddress, Data: data}, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to call approvedHashes on %s: %w", safeAddress.Hex(), err)
+	}
+	return new(big.Int).SetBytes(result).Sign() != 0, nil
+}
+
+// approvedHashSignature 构造预验证签名：r=所有者地址, s=0, v=1
+// Safe合约校验v=1时只检查approvedHashes[owner][safeTxHash]（或owner即交易发送者）
+func approvedHashSignature(owner common.Address) []byte {
+	signature := make([]byte, 0, 65)
+	signature = append(signature, common.LeftPadBytes(owner.Bytes(), 32)...)
+	signature = append(signature, make([]byte, 32)...)
+	return append(signature, 1)
+}
+
+// appendApprovedHashParts 为尚未提供链下签名、但已在链上approveHash的所有者追加v=1签名
+func (se *SafeExecutor) appendApprovedHashParts(ctx context.Context, safeAddress common.Address, safeTxHash common.Hash, parts []safeSignaturePart) []safeSignaturePart {
+	approvers, err := se.approvedHashOwners(ctx, safeAddress, safeTxHash)
+	if err != nil {
+		log.Printf("⚠️ 查询链上approveHash审批失败，仅使用链下签名: %v", err)
+		return parts
+	}
+
+	signed := make(map[common.Address]bool, len(parts))
+	for _, part := range parts {
+		signed[part.Signer] = true
+	}
+
+	for _, owner := range approvers {
+		if signed[owner] {
+			continue
+		}
+		log.Printf("  ✅ 所有者 %s 已在链上approveHash", owner.Hex())
+		parts = append(parts, safeSignaturePart{
+			Signer:    owner,
+			Type:      SafeSignatureApprovedHash,
+			Signature: approvedHashSignature(owner),
+		})
+	}
+	return parts
+}
@@ -0,0 +1,297 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+// defaultBalanceRefreshInterval 余额快照默认刷新间隔
+const defaultBalanceRefreshInterval = 5 * time.Minute
+
+// BalanceBackend 读取余额所需的最小RPC能力，*ethclient.Client 实现该接口，测试可注入内存后端
+type BalanceBackend interface {
+	ethereum.ContractCaller
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// BalanceBackendDialer 为指定链创建余额读取后端，返回的关闭函数在使用完毕后调用
+type BalanceBackendDialer func(ctx context.Context, chain *ChainConfig) (BalanceBackend, func(), error)
+
+// AssetBalance 单个资产余额
+type AssetBalance struct {
+	TokenAddress string `json:"token_address"`
+	Symbol       string `json:"symbol"`
+	Decimals     int    `json:"decimals"`
+	Balance      string `json:"balance"` // 最小单位
+}
+
+// AssetTotal 按链和资产汇总的余额
+type AssetTotal struct {
+	ChainID      int64  `json:"chain_id"`
+	TokenAddress string `json:"token_address"`
+	Symbol       string `json:"symbol"`
+	Decimals     int    `json:"decimals"`
+	Balance      string `json:"balance"`   // 最小单位
+	Formatted    string `json:"formatted"` // 按decimals格式化
+	SafeCount    int    `json:"safe_count"`
+}
+
+// BalanceService Safe资产余额服务
+// 跟踪原生币和链配置中的ERC-20代币余额，快照缓存在safe_balance_snapshots表中
+type BalanceService struct {
+	db              *gorm.DB
+	registry        *ChainRegistry
+	refreshInterval time.Duration
+	dial            BalanceBackendDialer
+}
+
+// NewBalanceService 创建余额服务
+// 刷新间隔读取环境变量 BALANCE_REFRESH_INTERVAL（如 "5m"），默认5分钟
+func NewBalanceService(db *gorm.DB, registry *ChainRegistry) *BalanceService {
+	refreshInterval := defaultBalanceRefreshInterval
+	if v := os.Getenv("BALANCE_REFRESH_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			refreshInterval = parsed
+		} else {
+			log.Printf("⚠️ 无效的BALANCE_REFRESH_INTERVAL: %s，使用默认值 %s", v, defaultBalanceRefreshInterval)
+		}
+	}
+
+	return &BalanceService{
+		db:              db,
+		registry:        registry,
+		refreshInterval: refreshInterval,
+		dial:            registryBalanceBackend(registry),
+	}
+}
+
+// SetBackendDialer 替换RPC后端（测试时注入内存后端）
+func (s *BalanceService) SetBackendDialer(dial BalanceBackendDialer) {
+	s.dial = dial
+}
+
+// SetRefreshInterval 设置快照刷新间隔
+func (s *BalanceService) SetRefreshInterval(interval time.Duration) {
+	s.refreshInterval = interval
+}
+
+// GetSafeBalances 获取Safe的资产余额快照
+// 快照缺失或超过刷新间隔时从链上刷新；刷新失败但存在旧快照时返回旧快照
+func (s *BalanceService) GetSafeBalances(ctx context.Context, safe *models.Safe, forceRefresh bool) ([]models.SafeBalanceSnapshot, error) {
+	var snapshots []models.SafeBalanceSnapshot
+	if err := s.db.WithContext(ctx).
+		Where("safe_id = ?", safe.ID).
+		Order("token_address ASC").
+		Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("查询余额快照失败: %w", err)
+	}
+
+	if !forceRefresh && !s.isStale(snapshots) {
+		return snapshots, nil
+	}
+
+	refreshed, err := s.RefreshSafeBalances(ctx, safe)
+	if err != nil {
+		if len(snapshots) > 0 {
+			log.Printf("⚠️ 刷新Safe %s 余额失败，返回缓存快照: %v", safe.Address, err)
+			return snapshots, nil
+		}
+		return nil, err
+	}
+	return refreshed, nil
+}
+
+// RefreshSafeBalances 从链上读取Safe余额并写入快照
+func (s *BalanceService) RefreshSafeBalances(ctx context.Context, safe *models.Safe) ([]models.SafeBalanceSnapshot, error) {
+	chain, err := s.registry.Get(int64(safe.ChainID))
+	if err != nil {
+		return nil, err
+	}
+
+	backend, closeBackend, err := s.dial(ctx, chain)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBackend()
+
+	tokens := make([]common.Address, 0, len(chain.Tokens))
+	for _, token := range chain.Tokens {
+		tokens = append(tokens, common.HexToAddress(token))
+	}
+
+	balances, err := FetchBalances(ctx, backend, common.HexToAddress(safe.Address), chain.NativeSymbol, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	snapshots := make([]models.SafeBalanceSnapshot, 0, len(balances))
+	for _, balance := range balances {
+		snapshots = append(snapshots, models.SafeBalanceSnapshot{
+			SafeID:       safe.ID,
+			ChainID:      chain.ChainID,
+			TokenAddress: balance.TokenAddress,
+			Symbol:       balance.Symbol,
+			Decimals:     balance.Decimals,
+			Balance:      balance.Balance,
+			FetchedAt:    now,
+		})
+	}
+
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "safe_id"}, {Name: "token_address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"chain_id", "symbol", "decimals", "balance", "fetched_at", "updated_at"}),
+	}).Create(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("保存余额快照失败: %w", err)
+	}
+
+	// 移除已不再跟踪的代币快照
+	tracked := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		tracked = append(tracked, snapshot.TokenAddress)
+	}
+	if err := s.db.WithContext(ctx).
+		Where("safe_id = ? AND token_address NOT IN ?", safe.ID, tracked).
+		Delete(&models.SafeBalanceSnapshot{}).Error; err != nil {
+		log.Printf("⚠️ 清理Safe %s 过期代币快照失败: %v", safe.Address, err)
+	}
+
+	log.Printf("💰 已刷新Safe %s 余额快照: %d 种资产", safe.Address, len(snapshots))
+	return snapshots, nil
+}
+
+// GetAssetTotals 汇总多个Safe的资产余额（按链和资产分组）
+func (s *BalanceService) GetAssetTotals(ctx context.Context, safes []models.Safe) ([]AssetTotal, error) {
+	type assetKey struct {
+		chainID int64
+		token   string
+	}
+
+	totals := make(map[assetKey]*AssetTotal)
+	sums := make(map[assetKey]*big.Int)
+
+	for i := range safes {
+		snapshots, err := s.GetSafeBalances(ctx, &safes[i], false)
+		if err != nil {
+			log.Printf("⚠️ 获取Safe %s 余额失败，跳过: %v", safes[i].Address, err)
+			continue
+		}
+
+		for _, snapshot := range snapshots {
+			key := assetKey{chainID: snapshot.ChainID, token: snapshot.TokenAddress}
+			if _, ok := totals[key]; !ok {
+				totals[key] = &AssetTotal{
+					ChainID:      snapshot.ChainID,
+					TokenAddress: snapshot.TokenAddress,
+					Symbol:       snapshot.Symbol,
+					Decimals:     snapshot.Decimals,
+				}
+				sums[key] = big.NewInt(0)
+			}
+			if balance, ok := new(big.Int).SetString(snapshot.Balance, 10); ok {
+				sums[key].Add(sums[key], balance)
+			}
+			totals[key].SafeCount++
+		}
+	}
+
+	result := make([]AssetTotal, 0, len(totals))
+	for key, total := range totals {
+		total.Balance = sums[key].String()
+		total.Formatted = FormatTokenAmount(sums[key], uint8(total.Decimals))
+		result = append(result, *total)
+	}
+
+	// 原生币在前，其余按链和symbol排序
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ChainID != result[j].ChainID {
+			return result[i].ChainID < result[j].ChainID
+		}
+		iNative := result[i].TokenAddress == models.NativeTokenAddress
+		jNative := result[j].TokenAddress == models.NativeTokenAddress
+		if iNative != jNative {
+			return iNative
+		}
+		return result[i].Symbol < result[j].Symbol
+	})
+
+	return result, nil
+}
+
+// isStale 快照为空或任一资产超过刷新间隔即视为过期
+func (s *BalanceService) isStale(snapshots []models.SafeBalanceSnapshot) bool {
+	if len(snapshots) == 0 {
+		return true
+	}
+	for _, snapshot := range snapshots {
+		if time.Since(snapshot.FetchedAt) > s.refreshInterval {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchBalances 读取账户的原生币余额和指定ERC-20代币余额
+// 单个代币读取失败时记录日志并跳过，不影响其他资产
+func FetchBalances(ctx context.Context, backend BalanceBackend, account common.Address, nativeSymbol string, tokens []common.Address) ([]AssetBalance, error) {
+	nativeBalance, err := backend.BalanceAt(ctx, account, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read native balance of %s: %w", account.Hex(), err)
+	}
+	if nativeSymbol == "" {
+		nativeSymbol = "ETH"
+	}
+
+	balances := []AssetBalance{{
+		TokenAddress: models.NativeTokenAddress,
+		Symbol:       nativeSymbol,
+		Decimals:     18,
+		Balance:      nativeBalance.String(),
+	}}
+
+	for _, token := range tokens {
+		info, err := ReadTokenInfo(ctx, backend, token)
+		if err != nil {
+			log.Printf("⚠️ 读取代币 %s 信息失败，跳过: %v", token.Hex(), err)
+			continue
+		}
+		balance, err := ReadTokenBalance(ctx, backend, token, account)
+		if err != nil {
+			log.Printf("⚠️ 读取代币 %s 余额失败，跳过: %v", token.Hex(), err)
+			continue
+		}
+
+		balances = append(balances, AssetBalance{
+			TokenAddress: strings.ToLower(token.Hex()),
+			Symbol:       info.Symbol,
+			Decimals:     int(info.Decimals),
+			Balance:      balance.String(),
+		})
+	}
+
+	return balances, nil
+}
+
+// registryBalanceBackend 默认后端：使用注册表中该链共享的RPC客户端，用完不关闭
+func registryBalanceBackend(registry *ChainRegistry) BalanceBackendDialer {
+	return func(ctx context.Context, chain *ChainConfig) (BalanceBackend, func(), error) {
+		client, err := registry.Client(ctx, chain.ChainID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() {}, nil
+	}
+}
@@ -0,0 +1,403 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Safe v1.3.0 官方部署地址（各链通过CREATE2部署，地址一致）
+const (
+	defaultSafeFactoryAddress   = "0xa6B71E26C5e0845f74c812102Ca7114b6a896AB2"
+	defaultSafeSingletonAddress = "0xd9Db270c1B5E3Bd161E8c8503c55cEABeE709552"
+	defaultMultiSendCallOnly    = "0x40A2aCCbd92BCA938b02010E17A5b8929b49130D"
+	defaultSimulateTxAccessor   = "0x59AD6735bCd8152B84860Cb256dD9e96b85F69Da"
+	defaultChainID              = int64(11155111) // Sepolia
+)
+
+// ChainConfig 单条链的网络配置
+// 包含节点地址、Safe合约地址、确认深度和区块浏览器地址
+type ChainConfig struct {
+	ChainID              int64    `json:"chain_id"`
+	Name                 string   `json:"name"`
+	RPCUrl               string   `json:"-"`
+	WSUrl                string   `json:"-"`
+	SafeFactoryAddress   string   `json:"safe_factory_address"`
+	SafeSingletonAddress string   `json:"safe_singleton_address"`
+	MultiSendCallOnly    string   `json:"multi_send_call_only"`
+	SimulateTxAccessor   string   `json:"simulate_tx_accessor"`
+	NativeSymbol         string   `json:"native_symbol"`
+	Tokens               []string `json:"tokens"` // 需要跟踪余额的ERC-20代币地址
+	ConfirmationBlocks   int64    `json:"confirmation_blocks"`
+	ExplorerURL          string   `json:"explorer_url"`
+	SafeServiceURL       string   `json:"-"` // 同步待执行交易的Safe Transaction Service地址
+
+	DelegateCallAllowlist []string `json:"delegate_call_allowlist"` // 除MultiSendCallOnly外允许DELEGATECALL的合约地址
+
+	Fees ExecutionFeeConfig `json:"-"` // 执行交易的gas缓冲和费用上限
+}
+
+// knownChains 内置的链默认配置，环境变量可覆盖任意字段
+var knownChains = map[int64]ChainConfig{
+	1: {
+		ChainID:            1,
+		Name:               "Ethereum",
+		NativeSymbol:       "ETH",
+		ConfirmationBlocks: 12,
+		ExplorerURL:        "https://etherscan.io",
+	},
+	11155111: {
+		ChainID:            11155111,
+		Name:               "Sepolia",
+		NativeSymbol:       "ETH",
+		ConfirmationBlocks: 3,
+		ExplorerURL:        "https://sepolia.etherscan.io",
+	},
+	137: {
+		ChainID:            137,
+		Name:               "Polygon",
+		NativeSymbol:       "POL",
+		ConfirmationBlocks: 64,
+		ExplorerURL:        "https://polygonscan.com",
+	},
+	42161: {
+		ChainID:            42161,
+		Name:               "Arbitrum One",
+		NativeSymbol:       "ETH",
+		ConfirmationBlocks: 3,
+		ExplorerURL:        "https://arbiscan.io",
+	},
+	10: {
+		ChainID:            10,
+		Name:               "Optimism",
+		NativeSymbol:       "ETH",
+		ConfirmationBlocks: 3,
+		ExplorerURL:        "https://optimistic.etherscan.io",
+	},
+	8453: {
+		ChainID:            8453,
+		Name:               "Base",
+		NativeSymbol:       "ETH",
+		ConfirmationBlocks: 3,
+		ExplorerURL:        "https://basescan.org",
+	},
+	31337: {
+		ChainID:            31337,
+		Name:               "Localhost",
+		NativeSymbol:       "ETH",
+		ConfirmationBlocks: 1,
+	},
+}
+
+// TxURL 返回交易在区块浏览器中的链接
+func (c *ChainConfig) TxURL(txHash string) string {
+	if c.ExplorerURL == "" {
+		return txHash
+	}
+	return fmt.Sprintf("%s/tx/%s", strings.TrimRight(c.ExplorerURL, "/"), txHash)
+}
+
+// AddressURL 返回地址在区块浏览器中的链接
+func (c *ChainConfig) AddressURL(address string) string {
+	if c.ExplorerURL == "" {
+		return address
+	}
+	return fmt.Sprintf("%s/address/%s", strings.TrimRight(c.ExplorerURL, "/"), address)
+}
+
+// AllowsDelegateCall 判断SafeTx能否DELEGATECALL到目标地址
+// 被调用合约以Safe自身的存储执行，只允许MultiSendCallOnly和 CHAIN_<ID>_DELEGATECALL_ALLOWLIST 中的合约
+func (c *ChainConfig) AllowsDelegateCall(to string) bool {
+	if !common.IsHexAddress(to) {
+		return false
+	}
+	target := common.HexToAddress(to)
+	if common.IsHexAddress(c.MultiSendCallOnly) && common.HexToAddress(c.MultiSendCallOnly) == target {
+		return true
+	}
+	for _, allowed := range c.DelegateCallAllowlist {
+		if common.HexToAddress(allowed) == target {
+			return true
+		}
+	}
+	return false
+}
+
+// MonitorConfig 根据链配置生成监听器配置
+func (c *ChainConfig) MonitorConfig() MonitorConfig {
+	return MonitorConfig{
+		ChainID:              c.ChainID,
+		RPCUrl:               c.RPCUrl,
+		WSUrl:                c.WSUrl,
+		SafeFactoryAddress:   c.SafeFactoryAddress,
+		SafeSingletonAddress: c.SafeSingletonAddress,
+		PollInterval:         30 * time.Second, // 30秒轮询间隔
+		ConfirmationBlocks:   c.ConfirmationBlocks,
+		MaxRetries:           5,  // 最大重试次数
+		BatchSize:            10, // 批处理大小
+	}
+}
+
+// Dial 连接该链的RPC节点并校验链ID
+func (c *ChainConfig) Dial(ctx context.Context) (*ethclient.Client, error) {
+	if c.RPCUrl == "" {
+		return nil, fmt.Errorf("链 %d 未配置RPC节点", c.ChainID)
+	}
+
+	client, err := ethclient.DialContext(ctx, c.RPCUrl)
+	if err != nil {
+		return nil, fmt.Errorf("连接链 %d 的RPC节点失败: %w", c.ChainID, err)
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("获取链ID失败: %w", err)
+	}
+	if chainID.Int64() != c.ChainID {
+		client.Close()
+		return nil, fmt.Errorf("链ID不匹配: 期望 %d, 实际 %d", c.ChainID, chainID.Int64())
+	}
+
+	return client, nil
+}
+
+// ChainRegistry 链配置注册表
+// 一个后端实例可同时服务多条链，按Safe的ChainID路由到对应网络
+type ChainRegistry struct {
+	mu     sync.RWMutex
+	chains map[int64]*ChainConfig
+
+	// 每条链共享的RPC客户端，按需连接，避免每个请求重新建立连接
+	clientsMu sync.Mutex
+	clients   map[int64]*ethclient.Client
+}
+
+// NewChainRegistry 创建空的链注册表
+func NewChainRegistry() *ChainRegistry {
+	return &ChainRegistry{
+		chains:  make(map[int64]*ChainConfig),
+		clients: make(map[int64]*ethclient.Client),
+	}
+}
+
+// Register 注册（或覆盖）一条链的配置，覆盖时关闭旧配置的共享客户端
+func (r *ChainRegistry) Register(cfg ChainConfig) {
+	r.mu.Lock()
+	c := cfg
+	r.chains[cfg.ChainID] = &c
+	r.mu.Unlock()
+
+	r.clientsMu.Lock()
+	defer r.clientsMu.Unlock()
+	if client, ok := r.clients[cfg.ChainID]; ok {
+		client.Close()
+		delete(r.clients, cfg.ChainID)
+	}
+}
+
+// Client 返回指定链共享的RPC客户端，首次调用时连接并校验链ID，调用方不应关闭返回的客户端
+func (r *ChainRegistry) Client(ctx context.Context, chainID int64) (*ethclient.Client, error) {
+	chain, err := r.Get(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.clientsMu.Lock()
+	defer r.clientsMu.Unlock()
+	if client, ok := r.clients[chainID]; ok {
+		return client, nil
+	}
+	client, err := chain.Dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.clients[chainID] = client
+	return client, nil
+}
+
+// Get 获取指定链的配置
+func (r *ChainRegistry) Get(chainID int64) (*ChainConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cfg, ok := r.chains[chainID]
+	if !ok {
+		return nil, fmt.Errorf("链 %d 未配置", chainID)
+	}
+	return cfg, nil
+}
+
+// All 返回所有已注册的链配置（按链ID排序）
+func (r *ChainRegistry) All() []*ChainConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chains := make([]*ChainConfig, 0, len(r.chains))
+	for _, cfg := range r.chains {
+		chains = append(chains, cfg)
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].ChainID < chains[j].ChainID
+	})
+	return chains
+}
+
+// LoadChainRegistryFromEnv 从环境变量加载链注册表
+//
+// CHAINS=11155111,1 指定启用的链，每条链通过 CHAIN_<ID>_* 配置：
+//
+//	CHAIN_<ID>_RPC_URL、CHAIN_<ID>_WS_URL、CHAIN_<ID>_NAME、
+//	CHAIN_<ID>_SAFE_FACTORY、CHAIN_<ID>_SAFE_SINGLETON、CHAIN_<ID>_MULTISEND_CALL_ONLY、
+//	CHAIN_<ID>_SIMULATE_TX_ACCESSOR、CHAIN_<ID>_DELEGATECALL_ALLOWLIST（逗号分隔的合约地址）、
+//	CHAIN_<ID>_CONFIRMATIONS、CHAIN_<ID>_EXPLORER_URL、CHAIN_<ID>_SAFE_SERVICE_URL、
+//	CHAIN_<ID>_NATIVE_SYMBOL、CHAIN_<ID>_TOKENS（逗号分隔的ERC-20地址）、
+//	CHAIN_<ID>_GAS_LIMIT_BUFFER_PERCENT、CHAIN_<ID>_MAX_FEE_GWEI、CHAIN_<ID>_MAX_PRIORITY_FEE_GWEI
+//
+// 未配置 CHAINS 时兼容旧配置：使用 CHAIN_ID、ETHEREUM_RPC_URL、BLOCKCHAIN_WS_URL 和 SAFE_SERVICE_URL
+func LoadChainRegistryFromEnv() *ChainRegistry {
+	registry := NewChainRegistry()
+
+	chainList := os.Getenv("CHAINS")
+	if chainList == "" {
+		chainID := defaultChainID
+		if v := os.Getenv("CHAIN_ID"); v != "" {
+			if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+				chainID = parsed
+			}
+		}
+		cfg := loadChainConfig(chainID)
+		if cfg.RPCUrl == "" {
+			cfg.RPCUrl = os.Getenv("ETHEREUM_RPC_URL")
+		}
+		if cfg.WSUrl == "" {
+			cfg.WSUrl = os.Getenv("BLOCKCHAIN_WS_URL")
+		}
+		if cfg.SafeServiceURL == "" {
+			cfg.SafeServiceURL = os.Getenv("SAFE_SERVICE_URL")
+		}
+		registry.Register(cfg)
+		return registry
+	}
+
+	for _, item := range strings.Split(chainList, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		chainID, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			log.Printf("⚠️ 忽略无效的链ID配置: %s", item)
+			continue
+		}
+		registry.Register(loadChainConfig(chainID))
+	}
+
+	return registry
+}
+
+// loadChainConfig 合并内置默认值与 CHAIN_<ID>_* 环境变量
+func loadChainConfig(chainID int64) ChainConfig {
+	cfg, ok := knownChains[chainID]
+	if !ok {
+		cfg = ChainConfig{
+			ChainID:            chainID,
+			Name:               fmt.Sprintf("Chain %d", chainID),
+			NativeSymbol:       "ETH",
+			ConfirmationBlocks: 3,
+		}
+	}
+	cfg.SafeFactoryAddress = defaultSafeFactoryAddress
+	cfg.SafeSingletonAddress = defaultSafeSingletonAddress
+	cfg.MultiSendCallOnly = defaultMultiSendCallOnly
+	cfg.SimulateTxAccessor = defaultSimulateTxAccessor
+
+	prefix := fmt.Sprintf("CHAIN_%d_", chainID)
+	if v := os.Getenv(prefix + "NAME"); v != "" {
+		cfg.Name = v
+	}
+	cfg.RPCUrl = os.Getenv(prefix + "RPC_URL")
+	cfg.WSUrl = os.Getenv(prefix + "WS_URL")
+	if v := os.Getenv(prefix + "SAFE_FACTORY"); v != "" {
+		cfg.SafeFactoryAddress = v
+	}
+	if v := os.Getenv(prefix + "SAFE_SINGLETON"); v != "" {
+		cfg.SafeSingletonAddress = v
+	}
+	if v := os.Getenv(prefix + "MULTISEND_CALL_ONLY"); v != "" {
+		cfg.MultiSendCallOnly = v
+	}
+	if v := os.Getenv(prefix + "SIMULATE_TX_ACCESSOR"); v != "" {
+		cfg.SimulateTxAccessor = v
+	}
+	if v := os.Getenv(prefix + "CONFIRMATIONS"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			cfg.ConfirmationBlocks = parsed
+		}
+	}
+	if v := os.Getenv(prefix + "EXPLORER_URL"); v != "" {
+		cfg.ExplorerURL = v
+	}
+	cfg.SafeServiceURL = os.Getenv(prefix + "SAFE_SERVICE_URL")
+	if v := os.Getenv(prefix + "NATIVE_SYMBOL"); v != "" {
+		cfg.NativeSymbol = v
+	}
+	cfg.Tokens = nil
+	for _, token := range strings.Split(os.Getenv(prefix+"TOKENS"), ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if !common.IsHexAddress(token) {
+			log.Printf("⚠️ 忽略链 %d 的无效代币地址: %s", chainID, token)
+			continue
+		}
+		cfg.Tokens = append(cfg.Tokens, common.HexToAddress(token).Hex())
+	}
+	cfg.DelegateCallAllowlist = nil
+	for _, target := range strings.Split(os.Getenv(prefix+"DELEGATECALL_ALLOWLIST"), ",") {
+		target = strings.TrimSpace(target)
+		if target == "" {
+			continue
+		}
+		if !common.IsHexAddress(target) {
+			log.Printf("⚠️ 忽略链 %d 的无效DELEGATECALL白名单地址: %s", chainID, target)
+			continue
+		}
+		cfg.DelegateCallAllowlist = append(cfg.DelegateCallAllowlist, common.HexToAddress(target).Hex())
+	}
+	cfg.Fees = loadExecutionFeeConfig(chainID)
+
+	return cfg
+}
+
+// 全局链注册表
+var (
+	defaultChainRegistry     *ChainRegistry
+	defaultChainRegistryOnce sync.Once
+)
+
+// SetDefaultChainRegistry 设置全局链注册表（由main.go在启动时调用）
+func SetDefaultChainRegistry(registry *ChainRegistry) {
+	defaultChainRegistryOnce.Do(func() {})
+	defaultChainRegistry = registry
+}
+
+// DefaultChainRegistry 获取全局链注册表，未设置时从环境变量加载
+func DefaultChainRegistry() *ChainRegistry {
+	defaultChainRegistryOnce.Do(func() {
+		if defaultChainRegistry == nil {
+			defaultChainRegistry = LoadChainRegistryFromEnv()
+		}
+	})
+	return defaultChainRegistry
+}
@@ -0,0 +1,197 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ERC-20 ABI，只包含本系统用到的方法
+const erc20ABIJSON = `[
+	{
+		"inputs": [
+			{"internalType": "address", "name": "to", "type": "address"},
+			{"internalType": "uint256", "name": "amount", "type": "uint256"}
+		],
+		"name": "transfer",
+		"outputs": [{"internalType": "bool", "name": "", "type": "bool"}],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [{"internalType": "address", "name": "account", "type": "address"}],
+		"name": "balanceOf",
+		"outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "decimals",
+		"outputs": [{"internalType": "uint8", "name": "", "type": "uint8"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "symbol",
+		"outputs": [{"internalType": "string", "name": "", "type": "string"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+var erc20ABI, _ = abi.JSON(strings.NewReader(erc20ABIJSON))
+
+// TokenInfo ERC-20代币元数据
+type TokenInfo struct {
+	Address  common.Address `json:"address"`
+	Symbol   string         `json:"symbol"`
+	Decimals uint8          `json:"decimals"`
+}
+
+// ReadTokenInfo 通过RPC读取代币的decimals()和symbol()
+// 部分老代币（如MKR）的symbol返回bytes32，这里一并兼容
+func ReadTokenInfo(ctx context.Context, caller ethereum.ContractCaller, token common.Address) (*TokenInfo, error) {
+	decimalsResult, err := callERC20(ctx, caller, token, "decimals")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read decimals of %s: %w", token.Hex(), err)
+	}
+	decimalsOut, err := erc20ABI.Unpack("decimals", decimalsResult)
+	if err != nil || len(decimalsOut) == 0 {
+		return nil, fmt.Errorf("token %s returned invalid decimals", token.Hex())
+	}
+	decimals, ok := decimalsOut[0].(uint8)
+	if !ok {
+		return nil, fmt.Errorf("token %s returned invalid decimals", token.Hex())
+	}
+
+	symbolResult, err := callERC20(ctx, caller, token, "symbol")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbol of %s: %w", token.Hex(), err)
+	}
+
+	return &TokenInfo{
+		Address:  token,
+		Symbol:   decodeTokenSymbol(symbolResult),
+		Decimals: decimals,
+	}, nil
+}
+
+// ReadTokenBalance 读取账户的代币余额（最小单位）
+func ReadTokenBalance(ctx context.Context, caller ethereum.ContractCaller, token, account common.Address) (*big.Int, error) {
+	result, err := callERC20(ctx, caller, token, "balanceOf", account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read balance of %s: %w", token.Hex(), err)
+	}
+	out, err := erc20ABI.Unpack("balanceOf", result)
+	if err != nil || len(out) == 0 {
+		return nil, fmt.Errorf("token %s returned invalid balance", token.Hex())
+	}
+	balance, ok := out[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("token %s returned invalid balance", token.Hex())
+	}
+	return balance, nil
+}
+
+// EncodeERC20Transfer 编码transfer(to, amount)调用数据
+func EncodeERC20Transfer(to common.Address, amount *big.Int) ([]byte, error) {
+	data, err := erc20ABI.Pack("transfer", to, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack transfer: %w", err)
+	}
+	return data, nil
+}
+
+// ParseTokenAmount 将人类可读金额（如 "12.5"）按decimals换算为最小单位
+// 小数位数超过decimals时返回错误，避免静默截断
+func ParseTokenAmount(amount string, decimals uint8) (*big.Int, error) {
+	amount = strings.TrimSpace(amount)
+	if amount == "" {
+		return nil, fmt.Errorf("amount is empty")
+	}
+
+	intPart, fracPart := amount, ""
+	if idx := strings.Index(amount, "."); idx >= 0 {
+		intPart, fracPart = amount[:idx], amount[idx+1:]
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	if len(fracPart) > int(decimals) {
+		return nil, fmt.Errorf("amount %s has more than %d decimal places", amount, decimals)
+	}
+	if !isDecimalDigits(intPart) || (fracPart != "" && !isDecimalDigits(fracPart)) {
+		return nil, fmt.Errorf("invalid amount: %s", amount)
+	}
+
+	raw, ok := new(big.Int).SetString(intPart+fracPart+strings.Repeat("0", int(decimals)-len(fracPart)), 10)
+	if !ok || raw.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid amount: %s", amount)
+	}
+	return raw, nil
+}
+
+// FormatTokenAmount 将最小单位金额按decimals格式化为人类可读字符串（去除末尾0）
+func FormatTokenAmount(raw *big.Int, decimals uint8) string {
+	if raw == nil {
+		return "0"
+	}
+	if decimals == 0 {
+		return raw.String()
+	}
+
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	intPart, fracPart := new(big.Int).QuoRem(raw, divisor, new(big.Int))
+
+	frac := strings.TrimRight(fmt.Sprintf("%0*s", int(decimals), fracPart.String()), "0")
+	if frac == "" {
+		return intPart.String()
+	}
+	return intPart.String() + "." + frac
+}
+
+// callERC20 调用代币合约的只读方法
+func callERC20(ctx context.Context, caller ethereum.ContractCaller, token common.Address, method string, args ...interface{}) ([]byte, error) {
+	data, err := erc20ABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("empty result, %s may not be an ERC-20 contract", token.Hex())
+	}
+	return result, nil
+}
+
+// decodeTokenSymbol 解析symbol()返回值，兼容string和bytes32两种实现
+func decodeTokenSymbol(result []byte) string {
+	if out, err := erc20ABI.Unpack("symbol", result); err == nil && len(out) > 0 {
+		if symbol, ok := out[0].(string); ok {
+			return symbol
+		}
+	}
+	if len(result) == 32 {
+		return strings.TrimRight(string(result), "\x00")
+	}
+	return ""
+}
+
+// isDecimalDigits 检查字符串是否只包含十进制数字
+func isDecimalDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,193 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// defaultGasLimitBufferPercent eth_estimateGas结果的默认缓冲比例
+const defaultGasLimitBufferPercent = 20
+
+// ExecutionFeeConfig 执行账户提交交易时的gas和费用设置
+// MaxFeePerGas/MaxPriorityFeePerGas为nil表示不设上限
+type ExecutionFeeConfig struct {
+	GasLimitBufferPercent uint64
+	MaxFeePerGas          *big.Int
+	MaxPriorityFeePerGas  *big.Int
+}
+
+// ExecutionTxParams 实际使用的交易参数，执行后记录到提案
+type ExecutionTxParams struct {
+	TxType               uint8
+	GasEstimate          uint64
+	GasLimit             uint64
+	MaxFeePerGas         *big.Int // EIP-1559
+	MaxPriorityFeePerGas *big.Int // EIP-1559
+	GasPrice             *big.Int // legacy
+	BaseFee              *big.Int // 不支持EIP-1559的链为nil
+}
+
+// loadExecutionFeeConfig 读取 CHAIN_<ID>_* 费用配置，未设置时回退到 EXECUTOR_* 全局配置
+//
+//	CHAIN_<ID>_GAS_LIMIT_BUFFER_PERCENT / EXECUTOR_GAS_LIMIT_BUFFER_PERCENT（默认20）
+//	CHAIN_<ID>_MAX_FEE_GWEI             / EXECUTOR_MAX_FEE_GWEI
+//	CHAIN_<ID>_MAX_PRIORITY_FEE_GWEI    / EXECUTOR_MAX_PRIORITY_FEE_GWEI
+func loadExecutionFeeConfig(chainID int64) ExecutionFeeConfig {
+	prefix := fmt.Sprintf("CHAIN_%d_", chainID)
+	lookup := func(name string) (string, string) {
+		if v := os.Getenv(prefix + name); v != "" {
+			return prefix + name, v
+		}
+		return "EXECUTOR_" + name, os.Getenv("EXECUTOR_" + name)
+	}
+
+	cfg := ExecutionFeeConfig{GasLimitBufferPercent: defaultGasLimitBufferPercent}
+
+	if key, v := lookup("GAS_LIMIT_BUFFER_PERCENT"); v != "" {
+		if parsed, err := strconv.ParseUint(v, 10, 64); err == nil {
+			cfg.GasLimitBufferPercent = parsed
+		} else {
+			log.Printf("⚠️ 无效的%s: %s，使用默认值 %d", key, v, defaultGasLimitBufferPercent)
+		}
+	}
+
+	parseGwei := func(name string) *big.Int {
+		key, v := lookup(name)
+		if v == "" {
+			return nil
+		}
+		wei, err := ParseTokenAmount(v, 9)
+		if err != nil || wei.Sign() <= 0 {
+			log.Printf("⚠️ 忽略无效的%s: %s", key, v)
+			return nil
+		}
+		return wei
+	}
+	cfg.MaxFeePerGas = parseGwei("MAX_FEE_GWEI")
+	cfg.MaxPriorityFeePerGas = parseGwei("MAX_PRIORITY_FEE_GWEI")
+
+	return cfg
+}
+
+// estimateGasLimit 通过eth_estimateGas估算gas并加上缓冲
+// 估算失败说明交易在当前状态下会revert，不应发送
+func estimateGasLimit(ctx context.Context, client *ethclient.Client, msg ethereum.CallMsg, bufferPercent uint64) (estimate uint64, limit uint64, err error) {
+	estimate, err = client.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, 0, fmt.Errorf("gas estimation failed, transaction would revert: %w", err)
+	}
+
+	limit = new(big.Int).Div(
+		new(big.Int).Mul(new(big.Int).SetUint64(estimate), new(big.Int).SetUint64(100+bufferPercent)),
+		big.NewInt(100),
+	).Uint64()
+	return estimate, limit, nil
+}
+
+// suggestFees 计算交易费用
+// 支持EIP-1559的链使用 maxFee = 2*baseFee + tip，tip和maxFee受配置上限约束；
+// 最新区块没有baseFee时使用legacy gasPrice
+func suggestFees(ctx context.Context, client *ethclient.Client, cfg ExecutionFeeConfig) (*ExecutionTxParams, error) {
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest header: %w", err)
+	}
+
+	if header.BaseFee == nil {
+		gasPrice, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gas price: %w", err)
+		}
+		if cfg.MaxFeePerGas != nil && gasPrice.Cmp(cfg.MaxFeePerGas) > 0 {
+			gasPrice = new(big.Int).Set(cfg.MaxFeePerGas)
+		}
+		return &ExecutionTxParams{TxType: types.LegacyTxType, GasPrice: gasPrice}, nil
+	}
+
+	baseFee := header.BaseFee
+	if cfg.MaxFeePerGas != nil && cfg.MaxFeePerGas.Cmp(baseFee) < 0 {
+		return nil, fmt.Errorf("current base fee %s wei exceeds configured max fee %s wei", baseFee, cfg.MaxFeePerGas)
+	}
+
+	tip, err := client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get priority fee: %w", err)
+	}
+	if cfg.MaxPriorityFeePerGas != nil && tip.Cmp(cfg.MaxPriorityFeePerGas) > 0 {
+		tip = new(big.Int).Set(cfg.MaxPriorityFeePerGas)
+	}
+
+	maxFee := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+	if cfg.MaxFeePerGas != nil && maxFee.Cmp(cfg.MaxFeePerGas) > 0 {
+		maxFee = new(big.Int).Set(cfg.MaxFeePerGas)
+	}
+	if tip.Cmp(maxFee) > 0 {
+		tip = new(big.Int).Set(maxFee)
+	}
+
+	return &ExecutionTxParams{
+		TxType:               types.DynamicFeeTxType,
+		MaxFeePerGas:         maxFee,
+		MaxPriorityFeePerGas: tip,
+		BaseFee:              baseFee,
+	}, nil
+}
+
+// buildTransaction 按费用参数构建legacy或EIP-1559交易
+func (p *ExecutionTxParams) buildTransaction(chainID *big.Int, nonce uint64, to common.Address, data []byte) *types.Transaction {
+	if p.TxType == types.DynamicFeeTxType {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: p.MaxPriorityFeePerGas,
+			GasFeeCap: p.MaxFeePerGas,
+			Gas:       p.GasLimit,
+			To:        &to,
+			Value:     big.NewInt(0),
+			Data:      data,
+		})
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: p.GasPrice,
+		Gas:      p.GasLimit,
+		To:       &to,
+		Value:    big.NewInt(0),
+		Data:     data,
+	})
+}
+
+// proposalUpdates 转换为proposals表的tx_*列
+func (p *ExecutionTxParams) proposalUpdates() map[string]interface{} {
+	updates := map[string]interface{}{
+		"tx_type":                     int(p.TxType),
+		"tx_gas_estimate":             int64(p.GasEstimate),
+		"tx_gas_limit":                int64(p.GasLimit),
+		"tx_max_fee_per_gas":          nil,
+		"tx_max_priority_fee_per_gas": nil,
+		"tx_gas_price":                nil,
+		"tx_base_fee":                 nil,
+	}
+	if p.MaxFeePerGas != nil {
+		updates["tx_max_fee_per_gas"] = p.MaxFeePerGas.String()
+	}
+	if p.MaxPriorityFeePerGas != nil {
+		updates["tx_max_priority_fee_per_gas"] = p.MaxPriorityFeePerGas.String()
+	}
+	if p.GasPrice != nil {
+		updates["tx_gas_price"] = p.GasPrice.String()
+	}
+	if p.BaseFee != nil {
+		updates["tx_base_fee"] = p.BaseFee.String()
+	}
+	return updates
+}
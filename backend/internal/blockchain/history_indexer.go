@@ -0,0 +1,387 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+// 历史索引器默认配置
+const (
+	defaultHistoryPollInterval   = 30 * time.Second
+	defaultHistoryBlockBatch     = 2000   // 单次eth_getLogs查询的区块跨度
+	defaultHistoryBackfillBlocks = 100000 // 找不到创建区块时向前回溯的区块数
+)
+
+// Safe及ERC-20事件签名
+var (
+	executionSuccessTopic = crypto.Keccak256Hash([]byte("ExecutionSuccess(bytes32,uint256)"))
+	executionFailureTopic = crypto.Keccak256Hash([]byte("ExecutionFailure(bytes32,uint256)"))
+	safeReceivedTopic     = crypto.Keccak256Hash([]byte("SafeReceived(address,uint256)"))
+	erc20TransferTopic    = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+)
+
+// SafeHistoryIndexer Safe交易历史索引器
+// 与SafeCreationMonitor并行运行，每条链一个实例，按Safe记录索引进度，
+// 将ExecutionSuccess/ExecutionFailure/SafeReceived和ERC-20 Transfer日志写入safe_history_events
+type SafeHistoryIndexer struct {
+	client *ethclient.Client
+	db     *gorm.DB
+	chain  *ChainConfig
+
+	pollInterval   time.Duration
+	blockBatch     uint64
+	backfillBlocks uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewSafeHistoryIndexer 创建历史索引器
+// 可通过 HISTORY_POLL_INTERVAL、HISTORY_BLOCK_BATCH、HISTORY_BACKFILL_BLOCKS 调整
+func NewSafeHistoryIndexer(chain *ChainConfig, db *gorm.DB) (*SafeHistoryIndexer, error) {
+	client, err := chain.Dial(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	indexer := &SafeHistoryIndexer{
+		client:         client,
+		db:             db,
+		chain:          chain,
+		pollInterval:   defaultHistoryPollInterval,
+		blockBatch:     defaultHistoryBlockBatch,
+		backfillBlocks: defaultHistoryBackfillBlocks,
+	}
+	if v := os.Getenv("HISTORY_POLL_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			indexer.pollInterval = parsed
+		}
+	}
+	if v := os.Getenv("HISTORY_BLOCK_BATCH"); v != "" {
+		if parsed, err := strconv.ParseUint(v, 10, 64); err == nil && parsed > 0 {
+			indexer.blockBatch = parsed
+		}
+	}
+	if v := os.Getenv("HISTORY_BACKFILL_BLOCKS"); v != "" {
+		if parsed, err := strconv.ParseUint(v, 10, 64); err == nil {
+			indexer.backfillBlocks = parsed
+		}
+	}
+
+	indexer.ctx, indexer.cancel = context.WithCancel(context.Background())
+	return indexer, nil
+}
+
+// Start 启动索引循环
+func (i *SafeHistoryIndexer) Start() {
+	log.Printf("📚 启动Safe历史索引器 (链: %s, 间隔: %v)", i.chain.Name, i.pollInterval)
+
+	go func() {
+		i.indexAllSafes()
+
+		ticker := time.NewTicker(i.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				i.indexAllSafes()
+			case <-i.ctx.Done():
+				log.Printf("🛑 Safe历史索引器收到停止信号 (链: %s)", i.chain.Name)
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止索引器
+func (i *SafeHistoryIndexer) Stop() {
+	i.cancel()
+	if i.client != nil {
+		i.client.Close()
+	}
+}
+
+// indexAllSafes 索引该链上所有Safe至最新确认区块
+func (i *SafeHistoryIndexer) indexAllSafes() {
+	head, err := i.client.BlockNumber(i.ctx)
+	if err != nil {
+		log.Printf("❌ [历史索引] 获取最新区块失败: %v", err)
+		return
+	}
+	confirmations := uint64(0)
+	if i.chain.ConfirmationBlocks > 0 {
+		confirmations = uint64(i.chain.ConfirmationBlocks)
+	}
+	if head < confirmations {
+		return
+	}
+	safeHead := head - confirmations
+
+	var safes []models.Safe
+	if err := i.db.WithContext(i.ctx).Where("chain_id = ?", i.chain.ChainID).Find(&safes).Error; err != nil {
+		log.Printf("❌ [历史索引] 查询Safe列表失败: %v", err)
+		return
+	}
+
+	for idx := range safes {
+		if i.ctx.Err() != nil {
+			return
+		}
+		if err := i.indexSafe(&safes[idx], safeHead); err != nil {
+			log.Printf("❌ [历史索引] Safe %s 索引失败: %v", safes[idx].Address, err)
+		}
+	}
+}
+
+// indexSafe 从检查点继续索引单个Safe，每批区块提交一次并推进检查点
+func (i *SafeHistoryIndexer) indexSafe(safe *models.Safe, safeHead uint64) error {
+	from, err := i.nextBlock(safe, safeHead)
+	if err != nil {
+		return err
+	}
+
+	for from <= safeHead {
+		to := from + i.blockBatch - 1
+		if to > safeHead {
+			to = safeHead
+		}
+
+		events, err := i.fetchEvents(safe, from, to)
+		if err != nil {
+			return err
+		}
+
+		if err := i.saveBatch(safe, events, to); err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			log.Printf("📚 [历史索引] Safe %s 区块 %d-%d 新增 %d 条记录", safe.Address, from, to, len(events))
+		}
+
+		from = to + 1
+	}
+
+	return nil
+}
+
+// nextBlock 计算下一个待索引区块：检查点之后；无检查点时从创建区块（或回溯窗口）开始
+func (i *SafeHistoryIndexer) nextBlock(safe *models.Safe, safeHead uint64) (uint64, error) {
+	var checkpoint models.SafeIndexCheckpoint
+	err := i.db.WithContext(i.ctx).Where("safe_id = ?", safe.ID).First(&checkpoint).Error
+	if err == nil {
+		return checkpoint.LastIndexedBlock + 1, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return 0, fmt.Errorf("查询索引检查点失败: %w", err)
+	}
+
+	// 由本系统创建的Safe从创建交易所在区块开始
+	if safe.TransactionID != nil {
+		var creation models.SafeTransaction
+		if err := i.db.WithContext(i.ctx).First(&creation, *safe.TransactionID).Error; err == nil && creation.BlockNumber != nil {
+			return uint64(*creation.BlockNumber), nil
+		}
+	}
+
+	if safeHead > i.backfillBlocks {
+		return safeHead - i.backfillBlocks, nil
+	}
+	return 0, nil
+}
+
+// fetchEvents 查询区块范围内与Safe相关的日志并转换为历史事件
+func (i *SafeHistoryIndexer) fetchEvents(safe *models.Safe, from, to uint64) ([]models.SafeHistoryEvent, error) {
+	safeAddress := common.HexToAddress(safe.Address)
+	safeTopic := common.BytesToHash(safeAddress.Bytes())
+	fromBlock, toBlock := new(big.Int).SetUint64(from), new(big.Int).SetUint64(to)
+
+	queries := []ethereum.FilterQuery{
+		// Safe合约自身事件
+		{
+			FromBlock: fromBlock,
+			ToBlock:   toBlock,
+			Addresses: []common.Address{safeAddress},
+			Topics:    [][]common.Hash{{executionSuccessTopic, executionFailureTopic, safeReceivedTopic}},
+		},
+		// ERC-20转入
+		{
+			FromBlock: fromBlock,
+			ToBlock:   toBlock,
+			Topics:    [][]common.Hash{{erc20TransferTopic}, nil, {safeTopic}},
+		},
+		// ERC-20转出
+		{
+			FromBlock: fromBlock,
+			ToBlock:   toBlock,
+			Topics:    [][]common.Hash{{erc20TransferTopic}, {safeTopic}},
+		},
+	}
+
+	var events []models.SafeHistoryEvent
+	timestamps := make(map[uint64]*time.Time)
+	for _, query := range queries {
+		logs, err := i.client.FilterLogs(i.ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("查询区块 %d-%d 日志失败: %w", from, to, err)
+		}
+
+		for _, vLog := range logs {
+			if vLog.Removed {
+				continue
+			}
+			event, ok := decodeHistoryLog(vLog, safeAddress)
+			if !ok {
+				continue
+			}
+			event.SafeID = safe.ID
+			event.ChainID = i.chain.ChainID
+			event.BlockTimestamp = i.blockTimestamp(vLog.BlockNumber, timestamps)
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// saveBatch 在同一事务中写入历史事件并推进检查点，保证重启后不丢不重
+func (i *SafeHistoryIndexer) saveBatch(safe *models.Safe, events []models.SafeHistoryEvent, lastBlock uint64) error {
+	return i.db.WithContext(i.ctx).Transaction(func(tx *gorm.DB) error {
+		if len(events) > 0 {
+			i.linkProposals(tx, safe.ID, events)
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&events).Error; err != nil {
+				return fmt.Errorf("写入历史事件失败: %w", err)
+			}
+		}
+
+		checkpoint := models.SafeIndexCheckpoint{
+			SafeID:           safe.ID,
+			ChainID:          i.chain.ChainID,
+			LastIndexedBlock: lastBlock,
+			UpdatedAt:        time.Now(),
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "safe_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"chain_id", "last_indexed_block", "updated_at"}),
+		}).Create(&checkpoint).Error; err != nil {
+			return fmt.Errorf("更新索引检查点失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// linkProposals 将执行事件关联到本系统中safeTxHash相同的提案
+func (i *SafeHistoryIndexer) linkProposals(tx *gorm.DB, safeID uuid.UUID, events []models.SafeHistoryEvent) {
+	for idx := range events {
+		if events[idx].SafeTxHash == nil {
+			continue
+		}
+		var proposal models.Proposal
+		if err := tx.Select("id").
+			Where("safe_id = ? AND LOWER(safe_tx_hash) = ?", safeID, strings.ToLower(*events[idx].SafeTxHash)).
+			First(&proposal).Error; err == nil {
+			events[idx].ProposalID = &proposal.ID
+		}
+	}
+}
+
+// blockTimestamp 获取区块时间（同一批次内缓存），失败时返回nil
+func (i *SafeHistoryIndexer) blockTimestamp(blockNumber uint64, cache map[uint64]*time.Time) *time.Time {
+	if ts, ok := cache[blockNumber]; ok {
+		return ts
+	}
+	header, err := i.client.HeaderByNumber(i.ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		log.Printf("⚠️ [历史索引] 获取区块 %d 时间失败: %v", blockNumber, err)
+		cache[blockNumber] = nil
+		return nil
+	}
+	ts := time.Unix(int64(header.Time), 0)
+	cache[blockNumber] = &ts
+	return &ts
+}
+
+// decodeHistoryLog 将日志解码为历史事件，无法识别的日志返回false
+func decodeHistoryLog(vLog types.Log, safeAddress common.Address) (models.SafeHistoryEvent, bool) {
+	event := models.SafeHistoryEvent{
+		TxHash:      vLog.TxHash.Hex(),
+		LogIndex:    vLog.Index,
+		BlockNumber: vLog.BlockNumber,
+		Value:       "0",
+	}
+	if len(vLog.Topics) == 0 {
+		return event, false
+	}
+
+	switch vLog.Topics[0] {
+	case executionSuccessTopic, executionFailureTopic:
+		// ExecutionSuccess/ExecutionFailure(bytes32 txHash, uint256 payment)，参数均未索引
+		if vLog.Address != safeAddress || len(vLog.Data) < 64 {
+			return event, false
+		}
+		event.EventType = models.HistoryEventExecutionSuccess
+		if vLog.Topics[0] == executionFailureTopic {
+			event.EventType = models.HistoryEventExecutionFailure
+		}
+		safeTxHash := common.BytesToHash(vLog.Data[:32]).Hex()
+		payment := new(big.Int).SetBytes(vLog.Data[32:64]).String()
+		event.SafeTxHash = &safeTxHash
+		event.Payment = &payment
+
+	case safeReceivedTopic:
+		// SafeReceived(address indexed sender, uint256 value)
+		if vLog.Address != safeAddress || len(vLog.Topics) < 2 || len(vLog.Data) < 32 {
+			return event, false
+		}
+		event.EventType = models.HistoryEventSafeReceived
+		from := common.BytesToAddress(vLog.Topics[1].Bytes()).Hex()
+		to := safeAddress.Hex()
+		event.FromAddress = &from
+		event.ToAddress = &to
+		event.Value = new(big.Int).SetBytes(vLog.Data[:32]).String()
+
+	case erc20TransferTopic:
+		// ERC-721的Transfer有4个topic（tokenId被索引），只处理ERC-20
+		if len(vLog.Topics) != 3 || len(vLog.Data) < 32 {
+			return event, false
+		}
+		from := common.BytesToAddress(vLog.Topics[1].Bytes())
+		to := common.BytesToAddress(vLog.Topics[2].Bytes())
+		switch safeAddress {
+		case to:
+			event.EventType = models.HistoryEventERC20TransferIn
+		case from:
+			event.EventType = models.HistoryEventERC20TransferOut
+		default:
+			return event, false
+		}
+		token := vLog.Address.Hex()
+		fromHex, toHex := from.Hex(), to.Hex()
+		event.TokenAddress = &token
+		event.FromAddress = &fromHex
+		event.ToAddress = &toHex
+		event.Value = new(big.Int).SetBytes(vLog.Data[:32]).String()
+
+	default:
+		return event, false
+	}
+
+	return event, true
+}
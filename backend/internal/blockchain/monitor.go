@@ -52,6 +52,9 @@ type SafeCreationMonitor struct {
 	// 提案执行监控
 	pendingProposals map[string]*PendingProposal // key: txHash
 	proposalMutex    sync.RWMutex                // 并发安全锁
+
+	// 提案执行确认后的Safe链上状态对账
+	reconciler *SafeReconciler
 }
 
 // MonitorConfig 监听器配置
@@ -117,6 +120,11 @@ func NewSafeCreationMonitor(
 	}, nil
 }
 
+// SetSafeReconciler 设置Safe状态对账器，提案执行确认后同步owners/threshold/nonce
+func (m *SafeCreationMonitor) SetSafeReconciler(reconciler *SafeReconciler) {
+	m.reconciler = reconciler
+}
+
 // Start 启动监听服务
 // 同时启动事件监听和轮询备份机制，确保不遗漏任何交易
 func (m *SafeCreationMonitor) Start() error {
@@ -142,6 +150,11 @@ func (m *SafeCreationMonitor) Start() error {
 	return nil
 }
 
+// ChainID 返回监听器所在链的链ID
+func (m *SafeCreationMonitor) ChainID() int64 {
+	return m.config.ChainID
+}
+
 // Stop 停止监听服务
 func (m *SafeCreationMonitor) Stop() {
 	log.Println("🛑 停止Safe创建监听服务...")
@@ -361,6 +374,10 @@ func (m *SafeCreationMonitor) checkPendingTransactions() error {
 	log.Printf("🔍 检查 %d 个待处理交易", len(pendingTxs))
 
 	for _, tx := range pendingTxs {
+		// 只处理本链的交易，其他链由对应的监听器处理
+		if int64(tx.ChainID) != m.config.ChainID {
+			continue
+		}
 		if err := m.checkTransactionStatus(tx); err != nil {
 			log.Printf("❌ 检查交易状态失败 (TxHash: %s): %v", tx.TxHash, err)
 		}
@@ -527,7 +544,7 @@ func (m *SafeCreationMonitor) extractSafeAddressFromReceipt(receipt *types.Recei
 func (m *SafeCreationMonitor) processConfirmedTransactions() error {
 	// 查询状态为CONFIRMED且有Safe地址的交易
 	var confirmedTxs []models.SafeTransaction
-	if err := m.db.Where("status = ? AND safe_address IS NOT NULL", models.StatusConfirmed).Find(&confirmedTxs).Error; err != nil {
+	if err := m.db.Where("status = ? AND safe_address IS NOT NULL AND chain_id = ?", models.StatusConfirmed, m.config.ChainID).Find(&confirmedTxs).Error; err != nil {
 		return fmt.Errorf("查询已确认交易失败: %w", err)
 	}
 
@@ -725,7 +742,10 @@ func (m *SafeCreationMonitor) recoverPendingProposals() {
 
 	// 查询状态为"executed"且有交易哈希的提案，预加载Safe关联数据
 	var proposals []models.Proposal
-	result := m.db.Preload("Safe").Where("status = ? AND tx_hash IS NOT NULL AND tx_hash != ''", "executed").Find(&proposals)
+	result := m.db.Preload("Safe").
+		Joins("JOIN safes ON safes.id = proposals.safe_id").
+		Where("proposals.status = ? AND proposals.tx_hash IS NOT NULL AND proposals.tx_hash != '' AND safes.chain_id = ?", "executed", m.config.ChainID).
+		Find(&proposals)
 	
 	log.Printf("📋 [提案监控] 数据库查询结果: 找到 %d 条executed状态且有tx_hash的提案", len(proposals))
 	
@@ -832,7 +852,11 @@ func (m *SafeCreationMonitor) recoverPendingProposals() {
 				SafeAddress: proposal.Safe.Address,
 			}
 			
-			if receipt.Status == 1 {
+			if receipt.Status == 1 && m.safeExecutionFailed(pendingProposal, receipt) {
+				log.Printf("❌ [提案监控] 历史提案Safe内部执行失败: ID=%s, TxHash=%s", 
+					proposal.ID.String(), *proposal.TxHash)
+				m.markProposalExecutionFailed(pendingProposal, receipt)
+			} else if receipt.Status == 1 {
 				log.Printf("✅ [提案监控] 历史提案已确认成功: ID=%s, TxHash=%s", 
 					proposal.ID.String(), *proposal.TxHash)
 				m.markProposalAsConfirmed(pendingProposal, receipt)
@@ -892,26 +916,63 @@ func (m *SafeCreationMonitor) checkPendingProposals() {
 }
 
 // checkProposalTransaction 检查单个提案执行交易状态
+// 原始执行交易与其加速/取消交易共用执行账户nonce，以实际上链的那笔为准
 func (m *SafeCreationMonitor) checkProposalTransaction(pending *PendingProposal) {
-	txHash := common.HexToHash(pending.TxHash)
-	
 	log.Printf("📋 [提案监控] 检查提案执行: 提案ID=%s, 交易哈希=%s", 
 		pending.ProposalID.String(), pending.TxHash)
-	
-	receipt, err := m.ethClient.TransactionReceipt(m.ctx, txHash)
-	if err != nil {
-		// 检查是否超时
-		if time.Since(pending.SubmitTime) > 10*time.Minute {
+
+	tracked := m.trackedExecutionTxs(pending.ProposalID)
+
+	// 先读取执行账户nonce再查收据，避免两次查询之间交易上链导致误判
+	nonceConsumed := false
+	if len(tracked) > 0 {
+		confirmedNonce, err := m.ethClient.NonceAt(m.ctx, common.HexToAddress(tracked[0].FromAddress), nil)
+		if err == nil && confirmedNonce > uint64(tracked[0].Nonce) {
+			nonceConsumed = true
+		}
+	}
+
+	candidates := []string{pending.TxHash}
+	actions := map[string]string{pending.TxHash: models.ExecutionTxActionExecute}
+	for _, record := range tracked {
+		if _, ok := actions[record.TxHash]; !ok {
+			candidates = append(candidates, record.TxHash)
+		}
+		actions[record.TxHash] = record.Action
+	}
+
+	var receipt *types.Receipt
+	minedHash := ""
+	for _, hash := range candidates {
+		r, err := m.ethClient.TransactionReceipt(m.ctx, common.HexToHash(hash))
+		if err == nil {
+			receipt = r
+			minedHash = hash
+			break
+		}
+	}
+
+	if receipt == nil {
+		switch {
+		case nonceConsumed:
+			log.Printf("❌ [提案监控] 执行账户nonce已被其他交易使用: 提案ID=%s", pending.ProposalID.String())
+			m.settleExecutionTxs(pending.ProposalID, "")
+			m.markProposalAsFailed(pending, "执行账户nonce已被其他交易使用，执行交易未上链")
+		case len(tracked) == 0 && time.Since(pending.SubmitTime) > 10*time.Minute:
+			// 没有nonce记录的历史交易无法加速或取消，保持原有超时处理
 			log.Printf("❌ [提案监控] 交易超时未确认: %s", pending.TxHash)
 			m.markProposalAsFailed(pending, "交易超时未确认")
-		} else {
+		case len(tracked) > 0 && time.Since(tracked[0].CreatedAt) > 10*time.Minute:
+			log.Printf("⚠️ [提案监控] 交易长时间未确认，可加速或取消: 提案ID=%s, 最新交易=%s, 已等待 %v",
+				pending.ProposalID.String(), tracked[0].TxHash, time.Since(tracked[0].CreatedAt).Round(time.Second))
+		default:
 			log.Printf("⏳ [提案监控] 交易尚未被挖矿: %s", pending.TxHash)
 		}
 		return
 	}
 
 	log.Printf("📄 [提案监控] 获取交易收据成功: TxHash=%s, BlockNumber=%d, Status=%d",
-		pending.TxHash, receipt.BlockNumber.Uint64(), receipt.Status)
+		minedHash, receipt.BlockNumber.Uint64(), receipt.Status)
 
 	// 检查确认区块数
 	currentBlock, err := m.ethClient.BlockNumber(m.ctx)
@@ -928,14 +989,111 @@ func (m *SafeCreationMonitor) checkProposalTransaction(pending *PendingProposal)
 		return
 	}
 
+	mined := *pending
+	mined.TxHash = minedHash
+
 	// 根据交易状态更新提案
-	if receipt.Status == 1 {
-		log.Printf("✅ [提案监控] 交易执行成功: %s", pending.TxHash)
-		m.markProposalAsConfirmed(pending, receipt)
+	if actions[minedHash] == models.ExecutionTxActionCancel && receipt.Status == 1 {
+		log.Printf("🚫 [提案监控] 执行交易已被取消: %s", minedHash)
+		m.markProposalExecutionCancelled(&mined)
+	} else if receipt.Status == 1 && m.safeExecutionFailed(&mined, receipt) {
+		log.Printf("❌ [提案监控] 交易已上链但Safe内部执行失败: %s", minedHash)
+		m.markProposalExecutionFailed(&mined, receipt)
+	} else if receipt.Status == 1 {
+		log.Printf("✅ [提案监控] 交易执行成功: %s", minedHash)
+		m.markProposalAsConfirmed(&mined, receipt)
 	} else {
-		log.Printf("❌ [提案监控] 交易执行失败: %s", pending.TxHash)
-		m.markProposalAsFailed(pending, "交易执行失败")
+		log.Printf("❌ [提案监控] 交易执行失败: %s", minedHash)
+		m.markProposalAsFailed(&mined, "交易执行失败")
+	}
+}
+
+// trackedExecutionTxs 提案尚未确定结果的执行交易（按广播时间倒序）
+func (m *SafeCreationMonitor) trackedExecutionTxs(proposalID uuid.UUID) []models.ProposalExecutionTx {
+	var records []models.ProposalExecutionTx
+	if err := m.db.Where("proposal_id = ? AND status = ?", proposalID, models.ExecutionTxStatusPending).
+		Order("created_at DESC").Find(&records).Error; err != nil {
+		log.Printf("⚠️ [提案监控] 查询提案 %s 的执行交易失败: %v", proposalID, err)
+		return nil
+	}
+	return records
+}
+
+// settleExecutionTxs 记录上链的交易，同nonce的其他交易标记为已替换
+// minedHash为空表示nonce被未跟踪的交易占用，全部标记为已替换
+func (m *SafeCreationMonitor) settleExecutionTxs(proposalID uuid.UUID, minedHash string) {
+	now := time.Now()
+	if err := m.db.Model(&models.ProposalExecutionTx{}).
+		Where("proposal_id = ? AND tx_hash = ? AND status = ?", proposalID, minedHash, models.ExecutionTxStatusPending).
+		Updates(map[string]interface{}{"status": models.ExecutionTxStatusMined, "mined_at": now}).Error; err != nil {
+		log.Printf("⚠️ 更新执行交易状态失败: %v", err)
+	}
+	if err := m.db.Model(&models.ProposalExecutionTx{}).
+		Where("proposal_id = ? AND tx_hash <> ? AND status = ?", proposalID, minedHash, models.ExecutionTxStatusPending).
+		Update("status", models.ExecutionTxStatusReplaced).Error; err != nil {
+		log.Printf("⚠️ 更新被替换的执行交易状态失败: %v", err)
+	}
+}
+
+// removePendingProposal 从监控队列中移除提案（键可能是原始交易哈希）
+func (m *SafeCreationMonitor) removePendingProposal(proposalID uuid.UUID) {
+	m.proposalMutex.Lock()
+	defer m.proposalMutex.Unlock()
+
+	for txHash, pending := range m.pendingProposals {
+		if pending.ProposalID == proposalID {
+			delete(m.pendingProposals, txHash)
+		}
+	}
+}
+
+// TrackProposalReplacement 加速/取消后确保提案在监控队列中，替换交易哈希从数据库读取
+func (m *SafeCreationMonitor) TrackProposalReplacement(proposalID uuid.UUID, txHash string, safeAddress string) {
+	m.proposalMutex.Lock()
+	defer m.proposalMutex.Unlock()
+
+	for _, pending := range m.pendingProposals {
+		if pending.ProposalID == proposalID {
+			pending.SubmitTime = time.Now()
+			log.Printf("📋 提案执行交易已替换: 提案ID=%s, 新交易哈希=%s", proposalID.String(), txHash)
+			return
+		}
+	}
+
+	m.pendingProposals[txHash] = &PendingProposal{
+		ProposalID:  proposalID,
+		TxHash:      txHash,
+		SubmitTime:  time.Now(),
+		SafeAddress: safeAddress,
 	}
+	log.Printf("📋 添加提案执行监控: 提案ID=%s, 交易哈希=%s, Safe地址=%s", proposalID.String(), txHash, safeAddress)
+}
+
+// markProposalExecutionCancelled 取消交易上链：Safe nonce未被消耗，提案回到approved状态可重新执行
+func (m *SafeCreationMonitor) markProposalExecutionCancelled(pending *PendingProposal) {
+	result := m.db.Model(&models.Proposal{}).
+		Where("id = ?", pending.ProposalID).
+		Updates(map[string]interface{}{
+			"status":     "approved",
+			"tx_hash":    nil,
+			"tx_nonce":   nil,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		log.Printf("❌ 更新提案状态失败: %v", result.Error)
+		return
+	}
+
+	m.settleExecutionTxs(pending.ProposalID, pending.TxHash)
+
+	if err := updateBatchItemsStatus(m.db, pending.ProposalID, "pending"); err != nil {
+		log.Printf("⚠️ 更新批量子交易状态失败: %v", err)
+	}
+
+	log.Printf("🚫 提案执行已取消: 提案ID=%s, 取消交易=%s", pending.ProposalID.String(), pending.TxHash)
+
+	m.notifyProposalExecutionResult(pending.ProposalID, "cancelled", pending.SafeAddress, pending.TxHash, nil)
+	m.removePendingProposal(pending.ProposalID)
 }
 
 // markProposalAsConfirmed 标记提案为已确认
@@ -960,16 +1118,30 @@ func (m *SafeCreationMonitor) markProposalAsConfirmed(pending *PendingProposal,
 		return
 	}
 
+	m.settleExecutionTxs(pending.ProposalID, pending.TxHash)
+
+	if err := updateBatchItemsStatus(m.db, pending.ProposalID, "confirmed"); err != nil {
+		log.Printf("⚠️ 更新批量子交易状态失败: %v", err)
+	}
+
 	log.Printf("✅ 提案执行成功: 提案ID=%s, 交易哈希=%s, 区块=%d", 
 		pending.ProposalID.String(), pending.TxHash, blockNumber)
 
 	// 发送WebSocket通知给Safe owners
 	m.notifyProposalExecutionResult(pending.ProposalID, "confirmed", pending.SafeAddress, pending.TxHash, nil)
 
+	// 拒绝交易上链后原提案转为rejected（需在对账重新排队前处理）
+	if _, err := FinalizeRejection(m.db, pending.ProposalID); err != nil {
+		log.Printf("⚠️ 更新被拒绝提案状态失败: %v", err)
+	}
+
+	// 执行可能修改了owners/threshold，且nonce必定变化，与链上状态对账
+	if m.reconciler != nil {
+		go m.reconciler.ReconcileAfterExecution(pending.ProposalID)
+	}
+
 	// 从监控队列中移除
-	m.proposalMutex.Lock()
-	delete(m.pendingProposals, pending.TxHash)
-	m.proposalMutex.Unlock()
+	m.removePendingProposal(pending.ProposalID)
 }
 
 // markProposalAsFailed 标记提案为执行失败
@@ -990,6 +1162,12 @@ func (m *SafeCreationMonitor) markProposalAsFailed(pending *PendingProposal, rea
 		return
 	}
 
+	m.settleExecutionTxs(pending.ProposalID, pending.TxHash)
+
+	if err := updateBatchItemsStatus(m.db, pending.ProposalID, "failed"); err != nil {
+		log.Printf("⚠️ 更新批量子交易状态失败: %v", err)
+	}
+
 	log.Printf("❌ 提案执行失败: 提案ID=%s, 交易哈希=%s, 原因=%s", 
 		pending.ProposalID.String(), pending.TxHash, reason)
 
@@ -997,9 +1175,53 @@ func (m *SafeCreationMonitor) markProposalAsFailed(pending *PendingProposal, rea
 	m.notifyProposalExecutionResult(pending.ProposalID, "failed", pending.SafeAddress, pending.TxHash, &reason)
 
 	// 从监控队列中移除
-	m.proposalMutex.Lock()
-	delete(m.pendingProposals, pending.TxHash)
-	m.proposalMutex.Unlock()
+	m.removePendingProposal(pending.ProposalID)
+}
+
+// safeExecutionFailed 收据中是否包含该提案的ExecutionFailure事件
+// safeTxGas或gasPrice非零时Safe会捕获内部调用的revert，外层交易仍然成功（Status=1）
+func (m *SafeCreationMonitor) safeExecutionFailed(pending *PendingProposal, receipt *types.Receipt) bool {
+	var proposal models.Proposal
+	if err := m.db.Select("id", "safe_tx_hash").First(&proposal, pending.ProposalID).Error; err != nil {
+		log.Printf("⚠️ [提案监控] 获取提案 %s 的Safe交易哈希失败: %v", pending.ProposalID, err)
+	}
+
+	safeAddress := common.HexToAddress(pending.SafeAddress)
+	for _, vLog := range receipt.Logs {
+		// ExecutionFailure(bytes32 txHash, uint256 payment)，参数均未索引
+		if vLog.Address != safeAddress || len(vLog.Topics) == 0 || vLog.Topics[0] != executionFailureTopic || len(vLog.Data) < 32 {
+			continue
+		}
+		// 未记录Safe交易哈希时，Safe在本交易中发出的ExecutionFailure即属于该提案
+		if proposal.SafeTxHash == nil || *proposal.SafeTxHash == "" {
+			return true
+		}
+		if common.BytesToHash(vLog.Data[:32]) == common.HexToHash(*proposal.SafeTxHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// markProposalExecutionFailed 标记提案为Safe内部执行失败
+// 交易已上链并消耗了Safe nonce，记录上链信息并与链上状态对账
+func (m *SafeCreationMonitor) markProposalExecutionFailed(pending *PendingProposal, receipt *types.Receipt) {
+	blockNumber := receipt.BlockNumber.Int64()
+	if err := m.db.Model(&models.Proposal{}).
+		Where("id = ?", pending.ProposalID).
+		Updates(map[string]interface{}{
+			"tx_hash":      pending.TxHash,
+			"block_number": &blockNumber,
+			"gas_used":     &receipt.GasUsed,
+		}).Error; err != nil {
+		log.Printf("⚠️ 记录提案上链信息失败: %v", err)
+	}
+
+	m.markProposalAsFailed(pending, "Safe内部交易执行失败（ExecutionFailure）")
+
+	if m.reconciler != nil {
+		go m.reconciler.ReconcileAfterExecution(pending.ProposalID)
+	}
 }
 
 // sendProposalConfirmedNotification 发送提案确认通知
@@ -1136,6 +1358,21 @@ func (m *SafeCreationMonitor) notifyProposalExecutionResult(proposalID uuid.UUID
 			},
 			"timestamp": time.Now(),
 		}
+	} else if status == "cancelled" {
+		message = map[string]interface{}{
+			"type":        "proposal_execution_cancelled",
+			"proposal_id": proposalID,
+			"title":       "提案执行已取消",
+			"message":     fmt.Sprintf("提案\"%s\"的执行交易已被取消，可重新执行", proposal.Title),
+			"data": map[string]interface{}{
+				"proposal_id":    proposalID,
+				"proposal_title": proposal.Title,
+				"safe_address":   safeAddress,
+				"tx_hash":        txHash,
+				"status":         status,
+			},
+			"timestamp": time.Now(),
+		}
 	} else {
 		reasonText := "未知原因"
 		if failureReason != nil {
@@ -0,0 +1,94 @@
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+// MultiSendCallOnly v1.3.0 ABI，只包含multiSend(bytes)
+const multiSendABIJSON = `[
+	{
+		"inputs": [{"internalType": "bytes", "name": "transactions", "type": "bytes"}],
+		"name": "multiSend",
+		"outputs": [],
+		"stateMutability": "payable",
+		"type": "function"
+	}
+]`
+
+var multiSendABI, _ = abi.JSON(strings.NewReader(multiSendABIJSON))
+
+// MultiSendCall MultiSend中的一笔子交易
+// MultiSendCallOnly只允许CALL，子交易不能再使用DELEGATECALL
+type MultiSendCall struct {
+	To    common.Address
+	Value *big.Int
+	Data  []byte
+}
+
+// EncodeMultiSendCallOnly 将子交易编码为MultiSendCallOnly.multiSend调用数据
+//
+// 每笔子交易按 abi.encodePacked(uint8 operation, address to, uint256 value, uint256 dataLength, bytes data) 拼接
+func EncodeMultiSendCallOnly(calls []MultiSendCall) ([]byte, error) {
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("multiSend requires at least one call")
+	}
+
+	var packed []byte
+	for _, call := range calls {
+		packed = append(packed, byte(models.OperationCall))
+		packed = append(packed, call.To.Bytes()...)
+		packed = append(packed, common.LeftPadBytes(bigOrZero(call.Value).Bytes(), 32)...)
+		packed = append(packed, common.LeftPadBytes(big.NewInt(int64(len(call.Data))).Bytes(), 32)...)
+		packed = append(packed, call.Data...)
+	}
+
+	data, err := multiSendABI.Pack("multiSend", packed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack multiSend: %w", err)
+	}
+	return data, nil
+}
+
+// MultiSendCallsFromBatchItems 将批量提案的子交易转换为MultiSend调用（按item_index排序后传入）
+func MultiSendCallsFromBatchItems(items []models.ProposalBatchItem) ([]MultiSendCall, error) {
+	calls := make([]MultiSendCall, 0, len(items))
+	for _, item := range items {
+		if !common.IsHexAddress(item.ToAddress) {
+			return nil, fmt.Errorf("invalid batch item %d to address: %s", item.ItemIndex, item.ToAddress)
+		}
+
+		value, err := parseUint256(item.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch item %d value: %s", item.ItemIndex, item.Value)
+		}
+
+		var data []byte
+		if item.Data != nil {
+			data = common.FromHex(*item.Data)
+		}
+
+		calls = append(calls, MultiSendCall{
+			To:    common.HexToAddress(item.ToAddress),
+			Value: value,
+			Data:  data,
+		})
+	}
+	return calls, nil
+}
+
+// updateBatchItemsStatus 同步批量提案子交易状态（MultiSend原子执行，子交易与父提案状态一致）
+// 非批量提案没有子交易，更新为空操作
+func updateBatchItemsStatus(db *gorm.DB, proposalID uuid.UUID, status string) error {
+	return db.Model(&models.ProposalBatchItem{}).
+		Where("proposal_id = ?", proposalID).
+		Update("status", status).Error
+}
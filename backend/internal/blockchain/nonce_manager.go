@@ -0,0 +1,402 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+const (
+	// staleReservationTimeout 分配后超过该时间仍未广播的nonce视为进程中断遗留，释放以便复用
+	staleReservationTimeout = 2 * time.Minute
+	// droppedTxGracePeriod 广播后超过该时间节点仍查不到交易，视为被丢弃
+	droppedTxGracePeriod = 2 * time.Minute
+	// defaultNonceCheckInterval nonce维护任务默认间隔
+	defaultNonceCheckInterval = time.Minute
+)
+
+// ExecutorNonceManager 执行账户nonce管理器
+// 同一执行账户的nonce在进程内互斥锁和PostgreSQL事务级advisory锁下分配，
+// 分配记录持久化到executor_nonces，重启后据此恢复；发送失败或被节点丢弃的nonce会被重新分配或用自转账填补
+type ExecutorNonceManager struct {
+	db *gorm.DB
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+var (
+	defaultNonceManager     *ExecutorNonceManager
+	defaultNonceManagerOnce sync.Once
+)
+
+// NewExecutorNonceManager 创建nonce管理器
+func NewExecutorNonceManager(db *gorm.DB) *ExecutorNonceManager {
+	return &ExecutorNonceManager{
+		db:    db,
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+// DefaultExecutorNonceManager 进程内共享的nonce管理器，所有SafeExecutor实例共用
+func DefaultExecutorNonceManager(db *gorm.DB) *ExecutorNonceManager {
+	defaultNonceManagerOnce.Do(func() {
+		defaultNonceManager = NewExecutorNonceManager(db)
+	})
+	return defaultNonceManager
+}
+
+// nonceLockKey 执行账户的锁键（同时用作advisory锁的哈希输入）
+func nonceLockKey(chainID int64, address common.Address) string {
+	return fmt.Sprintf("executor-nonce:%d:%s", chainID, strings.ToLower(address.Hex()))
+}
+
+// lock 获取执行账户的进程内锁
+func (m *ExecutorNonceManager) lock(key string) func() {
+	m.mu.Lock()
+	l, ok := m.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[key] = l
+	}
+	m.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// withAccountLock 在进程锁和advisory锁下执行fn
+func (m *ExecutorNonceManager) withAccountLock(ctx context.Context, chainID int64, address common.Address, fn func(tx *gorm.DB) error) error {
+	key := nonceLockKey(chainID, address)
+	unlock := m.lock(key)
+	defer unlock()
+
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 多实例部署时串行化同一执行账户的nonce操作
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error; err != nil {
+			return fmt.Errorf("failed to acquire nonce lock: %w", err)
+		}
+		return fn(tx)
+	})
+}
+
+// Allocate 为提案执行分配nonce：优先复用链上nonce之后的空洞，否则取下一个未使用的nonce
+func (m *ExecutorNonceManager) Allocate(ctx context.Context, client *ethclient.Client, chainID int64, address common.Address, proposalID uuid.UUID) (uint64, error) {
+	var allocated uint64
+	err := m.withAccountLock(ctx, chainID, address, func(tx *gorm.DB) error {
+		chainNonce, err := client.NonceAt(ctx, address, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get executor nonce: %w", err)
+		}
+		if err := m.settle(ctx, tx, client, chainID, address, chainNonce); err != nil {
+			return err
+		}
+
+		// 复用发送失败或被丢弃交易留下的空洞
+		var gap models.ExecutorNonce
+		err = tx.Where("chain_id = ? AND address = ? AND status = ? AND nonce >= ?",
+			chainID, address.Hex(), models.ExecutorNonceReleased, chainNonce).
+			Order("nonce ASC").First(&gap).Error
+		if err == nil {
+			if err := tx.Model(&gap).Updates(map[string]interface{}{
+				"status":      models.ExecutorNonceReserved,
+				"proposal_id": proposalID,
+				"tx_hash":     nil,
+				"updated_at":  time.Now(),
+			}).Error; err != nil {
+				return fmt.Errorf("failed to reserve nonce %d: %w", gap.Nonce, err)
+			}
+			allocated = uint64(gap.Nonce)
+			log.Printf("🔢 [Nonce] 复用空洞nonce %d (执行账户: %s, 提案: %s)", allocated, address.Hex(), proposalID)
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to query released nonces: %w", err)
+		}
+
+		next := chainNonce
+		var maxActive *int64
+		if err := tx.Model(&models.ExecutorNonce{}).
+			Where("chain_id = ? AND address = ? AND status IN ?", chainID, address.Hex(),
+				[]string{models.ExecutorNonceReserved, models.ExecutorNonceBroadcast}).
+			Select("MAX(nonce)").Scan(&maxActive).Error; err != nil {
+			return fmt.Errorf("failed to query active nonces: %w", err)
+		}
+		if maxActive != nil && uint64(*maxActive)+1 > next {
+			next = uint64(*maxActive) + 1
+		}
+		// 交易池中可能有不经过管理器发送的交易
+		if pending, err := client.PendingNonceAt(ctx, address); err == nil && pending > next {
+			next = pending
+		}
+
+		record := models.ExecutorNonce{
+			ChainID:    chainID,
+			Address:    address.Hex(),
+			Nonce:      int64(next),
+			Status:     models.ExecutorNonceReserved,
+			ProposalID: &proposalID,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "chain_id"}, {Name: "address"}, {Name: "nonce"}},
+			DoUpdates: clause.AssignmentColumns([]string{"status", "proposal_id", "tx_hash", "updated_at"}),
+		}).Create(&record).Error; err != nil {
+			return fmt.Errorf("failed to reserve nonce %d: %w", next, err)
+		}
+
+		allocated = next
+		log.Printf("🔢 [Nonce] 分配nonce %d (执行账户: %s, 链上nonce: %d, 提案: %s)", allocated, address.Hex(), chainNonce, proposalID)
+		return nil
+	})
+	return allocated, err
+}
+
+// MarkBroadcast 记录使用该nonce广播的交易（加速/取消时更新为最新的交易哈希）
+func (m *ExecutorNonceManager) MarkBroadcast(ctx context.Context, chainID int64, address common.Address, nonce uint64, txHash string) error {
+	return m.db.WithContext(ctx).Model(&models.ExecutorNonce{}).
+		Where("chain_id = ? AND address = ? AND nonce = ?", chainID, address.Hex(), nonce).
+		Updates(map[string]interface{}{
+			"status":     models.ExecutorNonceBroadcast,
+			"tx_hash":    txHash,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// Release 交易未能发送时释放nonce，供下一次分配复用
+func (m *ExecutorNonceManager) Release(ctx context.Context, chainID int64, address common.Address, nonce uint64) {
+	err := m.db.WithContext(ctx).Model(&models.ExecutorNonce{}).
+		Where("chain_id = ? AND address = ? AND nonce = ? AND status = ?", chainID, address.Hex(), nonce, models.ExecutorNonceReserved).
+		Updates(map[string]interface{}{
+			"status":     models.ExecutorNonceReleased,
+			"updated_at": time.Now(),
+		}).Error
+	if err != nil {
+		log.Printf("⚠️ [Nonce] 释放nonce %d 失败: %v", nonce, err)
+		return
+	}
+	log.Printf("🔢 [Nonce] 已释放nonce %d (执行账户: %s)", nonce, address.Hex())
+}
+
+// OwnedBy 检查nonce是否仍分配给该提案（被丢弃后可能已重新分配给其他提案或被填补）
+func (m *ExecutorNonceManager) OwnedBy(ctx context.Context, chainID int64, address common.Address, nonce uint64, proposalID uuid.UUID) (bool, error) {
+	var record models.ExecutorNonce
+	err := m.db.WithContext(ctx).
+		Where("chain_id = ? AND address = ? AND nonce = ?", chainID, address.Hex(), nonce).
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 启用nonce管理之前发送的交易没有分配记录
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return record.ProposalID != nil && *record.ProposalID == proposalID &&
+		(record.Status == models.ExecutorNonceBroadcast || record.Status == models.ExecutorNonceReleased), nil
+}
+
+// settle 根据链上nonce更新分配记录：
+// 链上nonce之前的记录已确认；超时未广播的预留和被节点丢弃的交易释放为空洞
+func (m *ExecutorNonceManager) settle(ctx context.Context, tx *gorm.DB, client *ethclient.Client, chainID int64, address common.Address, chainNonce uint64) error {
+	now := time.Now()
+
+	if err := tx.Model(&models.ExecutorNonce{}).
+		Where("chain_id = ? AND address = ? AND nonce < ? AND status <> ?", chainID, address.Hex(), chainNonce, models.ExecutorNonceConfirmed).
+		Updates(map[string]interface{}{"status": models.ExecutorNonceConfirmed, "updated_at": now}).Error; err != nil {
+		return fmt.Errorf("failed to settle confirmed nonces: %w", err)
+	}
+
+	result := tx.Model(&models.ExecutorNonce{}).
+		Where("chain_id = ? AND address = ? AND status = ? AND updated_at < ?",
+			chainID, address.Hex(), models.ExecutorNonceReserved, now.Add(-staleReservationTimeout)).
+		Updates(map[string]interface{}{"status": models.ExecutorNonceReleased, "updated_at": now})
+	if result.Error != nil {
+		return fmt.Errorf("failed to release stale reservations: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("🔢 [Nonce] 释放 %d 个超时未广播的nonce (执行账户: %s)", result.RowsAffected, address.Hex())
+	}
+
+	var broadcast []models.ExecutorNonce
+	if err := tx.Where("chain_id = ? AND address = ? AND status = ? AND updated_at < ?",
+		chainID, address.Hex(), models.ExecutorNonceBroadcast, now.Add(-droppedTxGracePeriod)).
+		Find(&broadcast).Error; err != nil {
+		return fmt.Errorf("failed to query broadcast nonces: %w", err)
+	}
+	for _, record := range broadcast {
+		if record.TxHash == nil {
+			continue
+		}
+		_, _, err := client.TransactionByHash(ctx, common.HexToHash(*record.TxHash))
+		if !errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err := tx.Model(&record).Updates(map[string]interface{}{
+			"status":     models.ExecutorNonceReleased,
+			"updated_at": now,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to release dropped nonce %d: %w", record.Nonce, err)
+		}
+		log.Printf("⚠️ [Nonce] 交易 %s 已被节点丢弃，释放nonce %d (执行账户: %s)", *record.TxHash, record.Nonce, address.Hex())
+	}
+
+	return nil
+}
+
+// Maintain 恢复并维护执行账户的nonce记录，用0值自转账填补阻塞后续交易的空洞
+func (m *ExecutorNonceManager) Maintain(ctx context.Context, client *ethclient.Client, signer TxSigner, chainID *big.Int, fees ExecutionFeeConfig) error {
+	address := signer.Address()
+	return m.withAccountLock(ctx, chainID.Int64(), address, func(tx *gorm.DB) error {
+		chainNonce, err := client.NonceAt(ctx, address, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get executor nonce: %w", err)
+		}
+		if err := m.settle(ctx, tx, client, chainID.Int64(), address, chainNonce); err != nil {
+			return err
+		}
+
+		var maxBroadcast *int64
+		if err := tx.Model(&models.ExecutorNonce{}).
+			Where("chain_id = ? AND address = ? AND status = ?", chainID.Int64(), address.Hex(), models.ExecutorNonceBroadcast).
+			Select("MAX(nonce)").Scan(&maxBroadcast).Error; err != nil {
+			return fmt.Errorf("failed to query broadcast nonces: %w", err)
+		}
+		if maxBroadcast == nil {
+			return nil
+		}
+
+		// 只填补已广播交易之前的空洞；之后的空洞留给下一次分配复用
+		var gaps []models.ExecutorNonce
+		if err := tx.Where("chain_id = ? AND address = ? AND status = ? AND nonce >= ? AND nonce < ?",
+			chainID.Int64(), address.Hex(), models.ExecutorNonceReleased, chainNonce, *maxBroadcast).
+			Order("nonce ASC").Find(&gaps).Error; err != nil {
+			return fmt.Errorf("failed to query nonce gaps: %w", err)
+		}
+
+		for _, gap := range gaps {
+			txHash, err := fillNonceGap(ctx, client, signer, chainID, fees, uint64(gap.Nonce))
+			if err != nil {
+				return fmt.Errorf("failed to fill nonce gap %d: %w", gap.Nonce, err)
+			}
+			if err := tx.Model(&gap).Updates(map[string]interface{}{
+				"status":      models.ExecutorNonceBroadcast,
+				"proposal_id": nil,
+				"tx_hash":     txHash,
+				"updated_at":  time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+			log.Printf("🔢 [Nonce] 已用自转账 %s 填补nonce空洞 %d (执行账户: %s)", txHash, gap.Nonce, address.Hex())
+		}
+		return nil
+	})
+}
+
+// fillNonceGap 发送0值自转账占用指定nonce
+func fillNonceGap(ctx context.Context, client *ethclient.Client, signer TxSigner, chainID *big.Int, fees ExecutionFeeConfig, nonce uint64) (string, error) {
+	params, err := suggestFees(ctx, client, fees)
+	if err != nil {
+		return "", err
+	}
+	params.GasEstimate = cancelTxGasLimit
+	params.GasLimit = cancelTxGasLimit
+
+	signedTx, err := signer.SignTx(ctx, params.buildTransaction(chainID, nonce, signer.Address(), nil), chainID)
+	if err != nil {
+		return "", err
+	}
+	if err := client.SendTransaction(ctx, signedTx); err != nil {
+		return "", err
+	}
+	return signedTx.Hash().Hex(), nil
+}
+
+// ExecutorNonceKeeper 定时维护执行账户nonce（启动时恢复，之后定期检查被丢弃的交易和空洞）
+type ExecutorNonceKeeper struct {
+	client   *ethclient.Client
+	chain    *ChainConfig
+	signer   TxSigner
+	manager  *ExecutorNonceManager
+	interval time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewExecutorNonceKeeper 创建nonce维护任务，间隔读取环境变量 EXECUTOR_NONCE_CHECK_INTERVAL（默认1分钟）
+func NewExecutorNonceKeeper(chain *ChainConfig, signer TxSigner, db *gorm.DB) (*ExecutorNonceKeeper, error) {
+	client, err := chain.Dial(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	interval := defaultNonceCheckInterval
+	if v := os.Getenv("EXECUTOR_NONCE_CHECK_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			interval = parsed
+		} else {
+			log.Printf("⚠️ 无效的EXECUTOR_NONCE_CHECK_INTERVAL: %s，使用默认值 %s", v, defaultNonceCheckInterval)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ExecutorNonceKeeper{
+		client:   client,
+		chain:    chain,
+		signer:   signer,
+		manager:  DefaultExecutorNonceManager(db),
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// Start 启动时立即恢复nonce记录，之后定时维护
+func (k *ExecutorNonceKeeper) Start() {
+	log.Printf("🔢 启动执行账户nonce维护 (链: %s, 执行账户: %s, 间隔: %v)", k.chain.Name, k.signer.Address().Hex(), k.interval)
+
+	go func() {
+		k.maintain()
+
+		ticker := time.NewTicker(k.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				k.maintain()
+			case <-k.ctx.Done():
+				log.Printf("🛑 执行账户nonce维护收到停止信号 (链: %s)", k.chain.Name)
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止维护任务
+func (k *ExecutorNonceKeeper) Stop() {
+	k.cancel()
+	if k.client != nil {
+		k.client.Close()
+	}
+}
+
+func (k *ExecutorNonceKeeper) maintain() {
+	if err := k.manager.Maintain(k.ctx, k.client, k.signer, big.NewInt(k.chain.ChainID), k.chain.Fees); err != nil {
+		log.Printf("❌ [Nonce] 链 %s 执行账户nonce维护失败: %v", k.chain.Name, err)
+	}
+}
@@ -0,0 +1,61 @@
+package blockchain
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+// FinalizeRejection 拒绝交易上链后，将其指向的原提案标记为rejected
+// 原提案已不在待处理状态（如已被执行）时不做修改，返回false
+func FinalizeRejection(db *gorm.DB, rejectionID uuid.UUID) (bool, error) {
+	var rejection models.Proposal
+	if err := db.First(&rejection, rejectionID).Error; err != nil {
+		return false, fmt.Errorf("failed to load rejection proposal: %w", err)
+	}
+	if rejection.RejectsProposalID == nil {
+		return false, nil
+	}
+	return markProposalRejected(db, *rejection.RejectsProposalID, &rejection, time.Now())
+}
+
+// markProposalRejected 按拒绝提案记录原提案的拒绝原因、操作人和时间
+func markProposalRejected(db *gorm.DB, proposalID uuid.UUID, rejection *models.Proposal, now time.Time) (bool, error) {
+	result := db.Model(&models.Proposal{}).
+		Where("id = ? AND status IN ?", proposalID, []string{"pending", "approved"}).
+		Updates(map[string]interface{}{
+			"status":           "rejected",
+			"rejection_reason": rejection.RejectionReason,
+			"rejected_by":      rejection.CreatedBy,
+			"rejected_at":      now,
+			"updated_at":       now,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark proposal %s rejected: %w", proposalID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	log.Printf("🚫 提案 %s 已被链上拒绝 (拒绝提案: %s)", proposalID, rejection.ID)
+	return true, nil
+}
+
+// executedRejection 返回已上链（或已广播）的指向该提案的拒绝提案
+func executedRejection(db *gorm.DB, proposalID uuid.UUID) (*models.Proposal, error) {
+	var rejection models.Proposal
+	result := db.Where("rejects_proposal_id = ? AND status IN ?", proposalID, []string{"executed", "confirmed"}).
+		Order("created_at ASC").Limit(1).Find(&rejection)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query rejection of proposal %s: %w", proposalID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &rejection, nil
+}
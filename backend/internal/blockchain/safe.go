@@ -1,20 +1,25 @@
 package blockchain
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 )
 
 type SafeService struct {
 	client     *EthereumClient
 	validator  *SignatureValidator
 	serviceURL string
+	apiKey     string // 交易服务要求认证时以Bearer token发送
 	httpClient *http.Client
 }
 
@@ -40,6 +45,47 @@ type SafeInfo struct {
 	Version   string           `json:"version"`
 }
 
+// TxServiceConfirmation Safe Transaction Service返回的所有者确认
+type TxServiceConfirmation struct {
+	Owner          string    `json:"owner"`
+	SubmissionDate time.Time `json:"submissionDate"`
+	Signature      string    `json:"signature"`
+	SignatureType  string    `json:"signatureType"` // EOA、ETH_SIGN、CONTRACT_SIGNATURE、APPROVED_HASH
+}
+
+// TxServiceMultisigTransaction Safe Transaction Service返回的多签交易
+type TxServiceMultisigTransaction struct {
+	Safe           string                  `json:"safe"`
+	To             string                  `json:"to"`
+	Value          json.Number             `json:"value"`
+	Data           *string                 `json:"data"`
+	Operation      int                     `json:"operation"`
+	GasToken       *string                 `json:"gasToken"`
+	SafeTxGas      json.Number             `json:"safeTxGas"`
+	BaseGas        json.Number             `json:"baseGas"`
+	GasPrice       json.Number             `json:"gasPrice"`
+	RefundReceiver *string                 `json:"refundReceiver"`
+	Nonce          json.Number             `json:"nonce"`
+	SafeTxHash     string                  `json:"safeTxHash"`
+	Proposer       *string                 `json:"proposer"`
+	IsExecuted     bool                    `json:"isExecuted"`
+	Origin         json.RawMessage         `json:"origin"`
+	Confirmations  []TxServiceConfirmation `json:"confirmations"`
+}
+
+// txServicePage 交易服务的分页响应
+type txServicePage struct {
+	Count   int                            `json:"count"`
+	Next    *string                        `json:"next"`
+	Results []TxServiceMultisigTransaction `json:"results"`
+}
+
+// txServicePageSize 拉取多签交易的分页大小，maxTxServicePages 单次拉取的最大页数
+const (
+	txServicePageSize = 100
+	maxTxServicePages = 20
+)
+
 func NewSafeService(client *EthereumClient, serviceURL string) *SafeService {
 	return &SafeService{
 		client:     client,
@@ -51,28 +97,24 @@ func NewSafeService(client *EthereumClient, serviceURL string) *SafeService {
 	}
 }
 
+// NewSafeServiceClient 创建只访问Safe Transaction Service的客户端，不连接区块链节点
+func NewSafeServiceClient(serviceURL, apiKey string) *SafeService {
+	return &SafeService{
+		serviceURL: strings.TrimRight(serviceURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
 // BuildTransactionHash 构建交易哈希
 func (ss *SafeService) BuildTransactionHash(
 	safeAddress common.Address,
 	tx *SafeTransaction,
 ) (common.Hash, error) {
-	// Safe 交易哈希计算
-	// 这里简化实现，实际应该按照 Safe 合约的 encodeTransactionData 方法
-
-	data := []byte{}
-	data = append(data, safeAddress.Bytes()...)
-	data = append(data, tx.To.Bytes()...)
-	data = append(data, common.LeftPadBytes(tx.Value.Bytes(), 32)...)
-	data = append(data, tx.Data...)
-	data = append(data, byte(tx.Operation))
-	data = append(data, common.LeftPadBytes(tx.SafeTxGas.Bytes(), 32)...)
-	data = append(data, common.LeftPadBytes(tx.BaseGas.Bytes(), 32)...)
-	data = append(data, common.LeftPadBytes(tx.GasPrice.Bytes(), 32)...)
-	data = append(data, tx.GasToken.Bytes()...)
-	data = append(data, tx.RefundReceiver.Bytes()...)
-	data = append(data, common.LeftPadBytes(tx.Nonce.Bytes(), 32)...)
-
-	return crypto.Keccak256Hash(data), nil
+	// Safe 交易哈希计算，与Safe合约的 getTransactionHash (EIP-712) 一致
+	return ComputeSafeTxHash(ss.client.chainID, safeAddress, tx), nil
 }
 
 // ValidateSignatures 验证交易签名
@@ -158,3 +200,58 @@ func (ss *SafeService) GetSafeInfo(safeAddress common.Address) (*SafeInfo, error
 
 	return &safeInfo, nil
 }
+
+// GetQueuedMultisigTransactions 分页拉取Safe尚未执行的多签交易及其确认
+// nonceFrom不为nil时只拉取nonce不小于该值的交易（链上nonce之前的交易已无法执行）
+func (ss *SafeService) GetQueuedMultisigTransactions(ctx context.Context, safeAddress common.Address, nonceFrom *int64) ([]TxServiceMultisigTransaction, error) {
+	query := url.Values{}
+	query.Set("executed", "false")
+	query.Set("ordering", "nonce")
+	query.Set("limit", strconv.Itoa(txServicePageSize))
+	if nonceFrom != nil {
+		query.Set("nonce__gte", strconv.FormatInt(*nonceFrom, 10))
+	}
+
+	transactions := make([]TxServiceMultisigTransaction, 0)
+	// 按offset翻页而不跟随响应中的next链接，避免把认证信息发送到其他主机
+	for page, offset := 0, 0; page < maxTxServicePages; page++ {
+		query.Set("offset", strconv.Itoa(offset))
+		endpoint := fmt.Sprintf("%s/api/v1/safes/%s/multisig-transactions/?%s", ss.serviceURL, safeAddress.Hex(), query.Encode())
+
+		var result txServicePage
+		if err := ss.getJSON(ctx, endpoint, &result); err != nil {
+			return nil, fmt.Errorf("failed to get multisig transactions: %w", err)
+		}
+		transactions = append(transactions, result.Results...)
+
+		offset += len(result.Results)
+		if result.Next == nil || len(result.Results) == 0 {
+			return transactions, nil
+		}
+	}
+	return transactions, nil
+}
+
+// getJSON 请求交易服务并解码JSON响应
+func (ss *SafeService) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if ss.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+ss.apiKey)
+	}
+
+	resp, err := ss.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("safe service returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
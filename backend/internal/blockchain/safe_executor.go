@@ -2,19 +2,16 @@ package blockchain
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"encoding/hex"
 	"fmt"
 	"log"
 	"math/big"
-	"sort"
 	"strings"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -24,20 +21,43 @@ import (
 
 // SafeExecutor 处理Safe合约的交易执行
 type SafeExecutor struct {
-	client     *ethclient.Client
-	privateKey *ecdsa.PrivateKey
-	chainID    *big.Int
-	db         *gorm.DB
+	client      *ethclient.Client
+	signer      TxSigner
+	chainID     *big.Int
+	db          *gorm.DB
+	explorerURL string
+	fees        ExecutionFeeConfig
+	nonces      *ExecutorNonceManager
+
+	// simulateTxAccessor SimulateTxAccessor合约地址，用于签名前模拟Safe内部调用
+	simulateTxAccessor common.Address
 }
 
 // NewSafeExecutor 创建新的Safe执行器
-func NewSafeExecutor(client *ethclient.Client, privateKey *ecdsa.PrivateKey, chainID *big.Int, db *gorm.DB) *SafeExecutor {
+// signer为执行账户签名器（keystore或远程签名服务），执行器不接触明文私钥
+func NewSafeExecutor(client *ethclient.Client, signer TxSigner, chainID *big.Int, db *gorm.DB) *SafeExecutor {
 	return &SafeExecutor{
-		client:     client,
-		privateKey: privateKey,
-		chainID:    chainID,
-		db:         db,
+		client:  client,
+		signer:  signer,
+		chainID: chainID,
+		db:      db,
+		fees:    loadExecutionFeeConfig(chainID.Int64()),
+		nonces:  DefaultExecutorNonceManager(db),
+
+		simulateTxAccessor: common.HexToAddress(defaultSimulateTxAccessor),
+	}
+}
+
+// NewSafeExecutorForChain 根据链配置创建Safe执行器
+// client通常是ChainRegistry.Client返回的共享客户端，执行器不负责关闭；使用链配置中的区块浏览器地址和费用配置
+func NewSafeExecutorForChain(chain *ChainConfig, client *ethclient.Client, signer TxSigner, db *gorm.DB) *SafeExecutor {
+	executor := NewSafeExecutor(client, signer, big.NewInt(chain.ChainID), db)
+	executor.explorerURL = chain.ExplorerURL
+	executor.fees = chain.Fees
+	if common.IsHexAddress(chain.SimulateTxAccessor) {
+		executor.simulateTxAccessor = common.HexToAddress(chain.SimulateTxAccessor)
 	}
+	return executor
 }
 
 // ExecuteProposal 执行提案到区块链
@@ -55,35 +75,46 @@ func (se *SafeExecutor) ExecuteProposal(proposalID uuid.UUID) error {
 		return fmt.Errorf("proposal %s cannot be executed, current status: %s", proposalID, proposal.Status)
 	}
 
+	// 验证Safe所在链与执行器一致
+	if int64(proposal.Safe.ChainID) != se.chainID.Int64() {
+		return fmt.Errorf("safe %s is on chain %d, executor is connected to chain %s",
+			proposal.Safe.Address, proposal.Safe.ChainID, se.chainID.String())
+	}
+
 	// 获取Safe合约地址
 	safeAddress := common.HexToAddress(proposal.Safe.Address)
 	log.Printf("Executing proposal on Safe: %s", safeAddress.Hex())
 
-	// 根据提案类型执行不同的逻辑
-	var txHash string
-	var err error
-
-	// 根据提案数据判断类型
+	// 根据提案类型构建Safe交易
 	proposalType := se.determineProposalType(&proposal)
+	safeTx, err := se.buildSafeTransaction(&proposal, safeAddress, proposalType)
+	if err != nil {
+		log.Printf("Failed to build Safe transaction for proposal %s: %v", proposalID, err)
+		return err
+	}
 
-	switch proposalType {
-	case "transfer":
-		txHash, err = se.executeTransfer(&proposal, safeAddress)
-	case "contract_call":
-		txHash, err = se.executeContractCall(&proposal, safeAddress)
-	case "add_owner":
-		txHash, err = se.executeAddOwner(&proposal, safeAddress)
-	case "remove_owner":
-		txHash, err = se.executeRemoveOwner(&proposal, safeAddress)
-	case "change_threshold":
-		txHash, err = se.executeChangeThreshold(&proposal, safeAddress)
-	default:
-		return fmt.Errorf("unsupported proposal type: %s", proposalType)
+	// 提案分配了Safe nonce时，必须轮到该nonce才能执行，避免消耗其他提案的nonce
+	if proposal.Nonce != nil {
+		chainNonce, err := se.getSafeNonce(safeAddress)
+		if err != nil {
+			return fmt.Errorf("failed to get Safe nonce: %v", err)
+		}
+		if err := checkProposalNonce(*proposal.Nonce, chainNonce); err != nil {
+			log.Printf("Proposal %s cannot be executed yet: %v", proposalID, err)
+			return err
+		}
+	}
+
+	if proposalType == "transfer" {
+		if err := se.requireCurrentNonceSignatures(&proposal, safeAddress, safeTx.Nonce); err != nil {
+			return err
+		}
 	}
 
+	txHash, err := se.executeSafeTransaction(safeAddress, safeTx, proposal.ID)
 	if err != nil {
 		log.Printf("Failed to execute proposal %s: %v", proposalID, err)
-		return err
+		return fmt.Errorf("failed to execute %s: %w", proposalType, err)
 	}
 
 	// 更新提案状态
@@ -96,40 +127,83 @@ func (se *SafeExecutor) ExecuteProposal(proposalID uuid.UUID) error {
 	return nil
 }
 
-// executeTransfer 执行转账提案
-func (se *SafeExecutor) executeTransfer(proposal *models.Proposal, safeAddress common.Address) (string, error) {
-	log.Printf("Executing transfer: %s ETH to %s", proposal.Value, *proposal.ToAddress)
-
-	// 构建交易数据
-	toAddress := common.HexToAddress(*proposal.ToAddress)
-	value, ok := new(big.Int).SetString(proposal.Value, 10)
-	if !ok {
-		return "", fmt.Errorf("invalid value: %s", proposal.Value)
+// buildSafeTransaction 根据提案类型构建Safe交易
+// 提案创建时已分配Safe nonce的按分配的nonce构建，否则使用当前链上Safe nonce
+func (se *SafeExecutor) buildSafeTransaction(proposal *models.Proposal, safeAddress common.Address, proposalType string) (*SafeTransaction, error) {
+	safeTx, err := se.buildSafeTransactionByType(proposal, safeAddress, proposalType)
+	if err != nil {
+		return nil, err
 	}
+	if proposal.Nonce != nil {
+		safeTx.Nonce = big.NewInt(*proposal.Nonce)
+	}
+	return safeTx, nil
+}
 
-	// 企业级nonce管理：动态获取当前Safe nonce
-	currentNonce, err := se.getSafeNonce(safeAddress)
+// ProposalSafeTx 返回提案对应的Safe交易及其safeTxHash，与签名和执行时使用的交易一致
+func (se *SafeExecutor) ProposalSafeTx(proposal *models.Proposal) (*SafeTransaction, common.Hash, error) {
+	safeAddress := common.HexToAddress(proposal.Safe.Address)
+	safeTx, err := se.buildSafeTransaction(proposal, safeAddress, se.determineProposalType(proposal))
 	if err != nil {
-		return "", fmt.Errorf("failed to get current Safe nonce: %v", err)
+		return nil, common.Hash{}, err
 	}
+	return safeTx, ComputeSafeTxHash(se.chainID, safeAddress, safeTx), nil
+}
 
-	log.Printf("Current Safe nonce: %s", currentNonce.String())
+// buildSafeTransactionByType 按提案类型构建使用当前链上nonce的Safe交易
+func (se *SafeExecutor) buildSafeTransactionByType(proposal *models.Proposal, safeAddress common.Address, proposalType string) (*SafeTransaction, error) {
+	switch proposalType {
+	case "transfer":
+		return se.buildTransferSafeTx(proposal, safeAddress)
+	case "contract_call":
+		return se.buildContractCallSafeTx(proposal, safeAddress)
+	case "batch":
+		return se.buildBatchSafeTx(proposal, safeAddress)
+	case "token_transfer":
+		return se.buildTokenTransferSafeTx(proposal, safeAddress)
+	case "add_owner":
+		return se.buildAddOwnerSafeTx(proposal, safeAddress)
+	case "remove_owner":
+		return se.buildRemoveOwnerSafeTx(proposal, safeAddress)
+	case "change_threshold":
+		return se.buildChangeThresholdSafeTx(proposal, safeAddress)
+	case "rejection":
+		return se.buildRejectionSafeTx(proposal, safeAddress)
+	default:
+		return nil, fmt.Errorf("unsupported proposal type: %s", proposalType)
+	}
+}
 
+// requireCurrentNonceSignatures 检查针对当前Safe nonce的有效签名是否达到阈值
+func (se *SafeExecutor) requireCurrentNonceSignatures(proposal *models.Proposal, safeAddress common.Address, nonce *big.Int) error {
 	// 企业级nonce管理：验证签名并处理nonce不匹配情况
 	log.Printf("=== 企业级nonce管理：智能签名验证 ===")
 
 	// 首先尝试验证当前nonce的签名
-	validSignatures, err := se.validateSignaturesForCurrentNonce(proposal.ID, safeAddress, currentNonce)
+	validSignatures, err := se.validateSignaturesForCurrentNonce(proposal.ID, safeAddress, nonce)
 	if err != nil {
-		return "", fmt.Errorf("failed to validate signatures for current nonce: %v", err)
+		return fmt.Errorf("failed to validate signatures for current nonce: %v", err)
 	}
 
-	log.Printf("Found %d signatures valid for current nonce %s", len(validSignatures), currentNonce.String())
+	log.Printf("Found %d signatures valid for current nonce %s", len(validSignatures), nonce.String())
+
+	// 链上approveHash的所有者同样计入阈值
+	approvals := len(validSignatures)
+	if safeTx, err := SafeTxFromProposal(proposal, nonce); err == nil {
+		parts := make([]safeSignaturePart, 0, len(validSignatures))
+		for _, sig := range validSignatures {
+			if sig.Signer.WalletAddress != nil {
+				parts = append(parts, safeSignaturePart{Signer: common.HexToAddress(*sig.Signer.WalletAddress)})
+			}
+		}
+		safeTxHash := ComputeSafeTxHash(se.chainID, safeAddress, safeTx)
+		approvals += len(se.appendApprovedHashParts(context.Background(), safeAddress, safeTxHash, parts)) - len(parts)
+	}
 
 	// 如果当前nonce的签名不足，检查是否有其他nonce的签名可用
-	if len(validSignatures) < int(proposal.RequiredSignatures) {
+	if approvals < int(proposal.RequiredSignatures) {
 		log.Printf("⚠️ 当前nonce %s的签名不足 (%d/%d)，检查是否需要重新签名",
-			currentNonce.String(), len(validSignatures), proposal.RequiredSignatures)
+			nonce.String(), approvals, proposal.RequiredSignatures)
 
 		// 查询所有签名，分析nonce分布
 		var allSignatures []models.Signature
@@ -140,83 +214,146 @@ func (se *SafeExecutor) executeTransfer(proposal *models.Proposal, safeAddress c
 			nonceCount := make(map[string]int)
 			for _, sig := range allSignatures {
 				if sig.UsedNonce != nil {
-					nonce := big.NewInt(*sig.UsedNonce).String()
-					nonceCount[nonce]++
+					usedNonce := big.NewInt(*sig.UsedNonce).String()
+					nonceCount[usedNonce]++
 					walletAddr := "unknown"
 					if sig.Signer.WalletAddress != nil {
 						walletAddr = *sig.Signer.WalletAddress
 					}
-					log.Printf("  - 签名者 %s 使用nonce %s", walletAddr, nonce)
+					log.Printf("  - 签名者 %s 使用nonce %s", walletAddr, usedNonce)
 				}
 			}
 
-			for nonce, count := range nonceCount {
-				log.Printf("  - Nonce %s: %d个签名", nonce, count)
+			for usedNonce, count := range nonceCount {
+				log.Printf("  - Nonce %s: %d个签名", usedNonce, count)
 			}
 		}
 
-		return "", fmt.Errorf("insufficient valid signatures for current nonce %s. Need %d, have %d. 请用户使用当前nonce %s重新签名",
-			currentNonce.String(), proposal.RequiredSignatures, len(validSignatures), currentNonce.String())
+		return fmt.Errorf("insufficient valid signatures for current nonce %s. Need %d, have %d. 请用户使用当前nonce %s重新签名",
+			nonce.String(), proposal.RequiredSignatures, approvals, nonce.String())
 	}
 
-	// 执行Safe交易，使用验证过的签名
-	txHash, err := se.executeSafeTransaction(
-		safeAddress,
-		toAddress,
-		value,
-		[]byte{}, // 转账不需要data
-		currentNonce,
-		proposal.ID,
-	)
+	return nil
+}
+
+// buildTransferSafeTx 构建转账提案的Safe交易
+func (se *SafeExecutor) buildTransferSafeTx(proposal *models.Proposal, safeAddress common.Address) (*SafeTransaction, error) {
+	log.Printf("Executing transfer: %s ETH to %s", proposal.Value, *proposal.ToAddress)
 
+	// 企业级nonce管理：动态获取当前Safe nonce
+	currentNonce, err := se.getSafeNonce(safeAddress)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute transfer: %v", err)
+		return nil, fmt.Errorf("failed to get current Safe nonce: %v", err)
 	}
 
-	return txHash, nil
+	log.Printf("Current Safe nonce: %s", currentNonce.String())
+
+	// 构建Safe交易，使用提案中的operation和gas参数
+	safeTx, err := SafeTxFromProposal(proposal, currentNonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return safeTx, nil
 }
 
-// executeContractCall 执行合约调用提案
-func (se *SafeExecutor) executeContractCall(proposal *models.Proposal, safeAddress common.Address) (string, error) {
+// buildContractCallSafeTx 构建合约调用提案的Safe交易
+func (se *SafeExecutor) buildContractCallSafeTx(proposal *models.Proposal, safeAddress common.Address) (*SafeTransaction, error) {
 	log.Printf("Executing contract call to %s", *proposal.ToAddress)
 
-	toAddress := common.HexToAddress(*proposal.ToAddress)
-	value, ok := new(big.Int).SetString(proposal.Value, 10)
-	if !ok {
-		return "", fmt.Errorf("invalid value: %s", proposal.Value)
+	// 获取Safe nonce
+	nonce, err := se.getSafeNonce(safeAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Safe nonce: %v", err)
+	}
+
+	// 构建Safe交易（支持DELEGATECALL，如MultiSend批量交易）
+	safeTx, err := SafeTxFromProposal(proposal, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return safeTx, nil
+}
+
+// buildBatchSafeTx 构建批量交易提案的Safe交易
+// 子交易已在创建时编码为MultiSendCallOnly调用，这里校验编码未被篡改后按DELEGATECALL执行
+func (se *SafeExecutor) buildBatchSafeTx(proposal *models.Proposal, safeAddress common.Address) (*SafeTransaction, error) {
+	var items []models.ProposalBatchItem
+	if err := se.db.Where("proposal_id = ?", proposal.ID).Order("item_index ASC").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get batch items: %v", err)
 	}
+	log.Printf("Executing batch of %d calls via MultiSendCallOnly %s", len(items), *proposal.ToAddress)
 
-	// 解析合约调用数据
-	var data []byte
-	if proposal.Data != nil {
-		data = common.FromHex(*proposal.Data)
+	calls, err := MultiSendCallsFromBatchItems(items)
+	if err != nil {
+		return nil, err
+	}
+	expectedData, err := EncodeMultiSendCallOnly(calls)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.Data == nil || !strings.EqualFold(strings.TrimPrefix(*proposal.Data, "0x"), hex.EncodeToString(expectedData)) {
+		return nil, fmt.Errorf("batch proposal %s data does not match its batch items", proposal.ID)
+	}
+	if proposal.Operation != models.OperationDelegateCall {
+		return nil, fmt.Errorf("batch proposal %s must use DELEGATECALL", proposal.ID)
 	}
 
 	// 获取Safe nonce
 	nonce, err := se.getSafeNonce(safeAddress)
 	if err != nil {
-		return "", fmt.Errorf("failed to get Safe nonce: %v", err)
+		return nil, fmt.Errorf("failed to get Safe nonce: %v", err)
 	}
 
-	// 执行Safe交易
-	txHash, err := se.executeSafeTransaction(
-		safeAddress,
-		toAddress,
-		value,
-		data,
-		nonce,
-		proposal.ID,
-	)
+	safeTx, err := SafeTxFromProposal(proposal, nonce)
+	if err != nil {
+		return nil, err
+	}
 
+	return safeTx, nil
+}
+
+// buildTokenTransferSafeTx 构建ERC-20代币转账提案的Safe交易
+// 调用数据在创建时编码，这里校验其与记录的代币、接收方和数量一致后执行
+func (se *SafeExecutor) buildTokenTransferSafeTx(proposal *models.Proposal, safeAddress common.Address) (*SafeTransaction, error) {
+	if proposal.TokenAddress == nil || proposal.TokenRecipient == nil || proposal.TokenAmount == nil {
+		return nil, fmt.Errorf("token transfer proposal %s is missing token fields", proposal.ID)
+	}
+	log.Printf("Executing token transfer: %s of token %s to %s", *proposal.TokenAmount, *proposal.TokenAddress, *proposal.TokenRecipient)
+
+	amount, err := parseUint256(*proposal.TokenAmount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token amount: %s", *proposal.TokenAmount)
+	}
+	expectedData, err := EncodeERC20Transfer(common.HexToAddress(*proposal.TokenRecipient), amount)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.ToAddress == nil || !strings.EqualFold(*proposal.ToAddress, *proposal.TokenAddress) ||
+		proposal.Data == nil || !strings.EqualFold(strings.TrimPrefix(*proposal.Data, "0x"), hex.EncodeToString(expectedData)) {
+		return nil, fmt.Errorf("token transfer proposal %s data does not match its token fields", proposal.ID)
+	}
+	if proposal.Operation != models.OperationCall {
+		return nil, fmt.Errorf("token transfer proposal %s must use CALL", proposal.ID)
+	}
+
+	// 获取Safe nonce
+	nonce, err := se.getSafeNonce(safeAddress)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute contract call: %v", err)
+		return nil, fmt.Errorf("failed to get Safe nonce: %v", err)
 	}
 
-	return txHash, nil
+	safeTx, err := SafeTxFromProposal(proposal, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return safeTx, nil
 }
 
-// executeAddOwner 执行添加所有者提案
-func (se *SafeExecutor) executeAddOwner(proposal *models.Proposal, safeAddress common.Address) (string, error) {
+// buildAddOwnerSafeTx 构建添加所有者提案的Safe交易
+func (se *SafeExecutor) buildAddOwnerSafeTx(proposal *models.Proposal, safeAddress common.Address) (*SafeTransaction, error) {
 	log.Printf("Adding owner %s to Safe", *proposal.ToAddress)
 
 	// 构建addOwnerWithThreshold调用数据
@@ -226,41 +363,33 @@ func (se *SafeExecutor) executeAddOwner(proposal *models.Proposal, safeAddress c
 
 	data, err := safeABI.Pack("addOwnerWithThreshold", newOwner, threshold)
 	if err != nil {
-		return "", fmt.Errorf("failed to pack addOwnerWithThreshold: %v", err)
+		return nil, fmt.Errorf("failed to pack addOwnerWithThreshold: %v", err)
 	}
 
 	// 获取Safe nonce - 修复硬编码问题
 	nonce, err := se.getSafeNonce(safeAddress)
 	if err != nil {
-		return "", fmt.Errorf("failed to get Safe nonce: %v", err)
+		return nil, fmt.Errorf("failed to get Safe nonce: %v", err)
 	}
 	log.Printf("Using current Safe nonce=%s for add owner", nonce.String())
 
-	// 执行Safe交易（调用自身）
-	txHash, err := se.executeSafeTransaction(
-		safeAddress,
-		safeAddress, // 调用自身
-		big.NewInt(0),
-		data,
-		nonce,
-		proposal.ID,
-	)
-
+	// 构建Safe交易（调用自身，管理操作只能使用CALL）
+	safeTx, err := selfCallSafeTx(proposal, safeAddress, data, nonce)
 	if err != nil {
-		return "", fmt.Errorf("failed to add owner: %v", err)
+		return nil, err
 	}
 
-	return txHash, nil
+	return safeTx, nil
 }
 
-// executeRemoveOwner 执行移除所有者提案
-func (se *SafeExecutor) executeRemoveOwner(proposal *models.Proposal, safeAddress common.Address) (string, error) {
+// buildRemoveOwnerSafeTx 构建移除所有者提案的Safe交易
+func (se *SafeExecutor) buildRemoveOwnerSafeTx(proposal *models.Proposal, safeAddress common.Address) (*SafeTransaction, error) {
 	log.Printf("Removing owner %s from Safe", *proposal.ToAddress)
 
 	// 获取当前所有者列表
 	owners, err := se.getSafeOwners(safeAddress)
 	if err != nil {
-		return "", fmt.Errorf("failed to get Safe owners: %v", err)
+		return nil, fmt.Errorf("failed to get Safe owners: %v", err)
 	}
 
 	// 找到要移除的所有者的前一个所有者
@@ -284,34 +413,26 @@ func (se *SafeExecutor) executeRemoveOwner(proposal *models.Proposal, safeAddres
 
 	data, err := safeABI.Pack("removeOwner", prevOwner, ownerToRemove, threshold)
 	if err != nil {
-		return "", fmt.Errorf("failed to pack removeOwner: %v", err)
+		return nil, fmt.Errorf("failed to pack removeOwner: %v", err)
 	}
 
 	// 获取Safe nonce
 	nonce, err := se.getSafeNonce(safeAddress)
 	if err != nil {
-		return "", fmt.Errorf("failed to get Safe nonce: %v", err)
+		return nil, fmt.Errorf("failed to get Safe nonce: %v", err)
 	}
 
-	// 执行Safe交易
-	txHash, err := se.executeSafeTransaction(
-		safeAddress,
-		safeAddress,
-		big.NewInt(0),
-		data,
-		nonce,
-		proposal.ID,
-	)
-
+	// 构建Safe交易（调用自身，管理操作只能使用CALL）
+	safeTx, err := selfCallSafeTx(proposal, safeAddress, data, nonce)
 	if err != nil {
-		return "", fmt.Errorf("failed to remove owner: %v", err)
+		return nil, err
 	}
 
-	return txHash, nil
+	return safeTx, nil
 }
 
-// executeChangeThreshold 执行修改阈值提案
-func (se *SafeExecutor) executeChangeThreshold(proposal *models.Proposal, safeAddress common.Address) (string, error) {
+// buildChangeThresholdSafeTx 构建修改阈值提案的Safe交易
+func (se *SafeExecutor) buildChangeThresholdSafeTx(proposal *models.Proposal, safeAddress common.Address) (*SafeTransaction, error) {
 	newThreshold := big.NewInt(int64(proposal.RequiredSignatures))
 	log.Printf("Changing Safe threshold to %s", newThreshold.String())
 
@@ -319,55 +440,59 @@ func (se *SafeExecutor) executeChangeThreshold(proposal *models.Proposal, safeAd
 	safeABI := getSafeABI()
 	data, err := safeABI.Pack("changeThreshold", newThreshold)
 	if err != nil {
-		return "", fmt.Errorf("failed to pack changeThreshold: %v", err)
+		return nil, fmt.Errorf("failed to pack changeThreshold: %v", err)
 	}
 
 	// 获取Safe nonce
 	nonce, err := se.getSafeNonce(safeAddress)
 	if err != nil {
-		return "", fmt.Errorf("failed to get Safe nonce: %v", err)
+		return nil, fmt.Errorf("failed to get Safe nonce: %v", err)
 	}
 
-	// 执行Safe交易
-	txHash, err := se.executeSafeTransaction(
-		safeAddress,
-		safeAddress,
-		big.NewInt(0),
-		data,
-		nonce,
-		proposal.ID,
-	)
+	// 构建Safe交易（调用自身，管理操作只能使用CALL）
+	safeTx, err := selfCallSafeTx(proposal, safeAddress, data, nonce)
+	if err != nil {
+		return nil, err
+	}
 
+	return safeTx, nil
+}
+
+// buildRejectionSafeTx 构建拒绝提案的Safe交易
+// 在原提案的nonce上执行0值、空data的自调用，消耗该nonce使原提案的签名失效
+func (se *SafeExecutor) buildRejectionSafeTx(proposal *models.Proposal, safeAddress common.Address) (*SafeTransaction, error) {
+	log.Printf("Rejecting proposal %v on chain with a 0-value self call", proposal.RejectsProposalID)
+
+	nonce, err := se.getSafeNonce(safeAddress)
 	if err != nil {
-		return "", fmt.Errorf("failed to change threshold: %v", err)
+		return nil, fmt.Errorf("failed to get Safe nonce: %v", err)
 	}
 
-	return txHash, nil
+	return selfCallSafeTx(proposal, safeAddress, nil, nonce)
 }
 
 // executeSafeTransaction 执行Safe交易的核心方法
 func (se *SafeExecutor) executeSafeTransaction(
-	safeAddress, to common.Address,
-	value *big.Int,
-	data []byte,
-	nonce *big.Int,
+	safeAddress common.Address,
+	safeTx *SafeTransaction,
 	proposalID uuid.UUID,
 ) (string, error) {
+	to, value, data := safeTx.To, safeTx.Value, safeTx.Data
 	log.Printf("=== 开始执行Safe交易 ===")
 	log.Printf("Safe地址: %s", safeAddress.Hex())
 	log.Printf("目标地址: %s", to.Hex())
 	log.Printf("转账金额: %s wei", value.String())
 	log.Printf("交易数据: %x", data)
-	log.Printf("Safe Nonce: %s", nonce.String())
+	log.Printf("Safe Nonce: %s", safeTx.Nonce.String())
 	log.Printf("提案ID: %s", proposalID.String())
 
 	// Safe交易参数
-	operation := uint8(0) // CALL
-	safeTxGas := big.NewInt(0)
-	baseGas := big.NewInt(0)
-	gasPrice := big.NewInt(0)
-	gasToken := common.Address{}
-	refundReceiver := common.Address{}
+	operation := safeTx.Operation
+	safeTxGas := safeTx.SafeTxGas
+	baseGas := safeTx.BaseGas
+	gasPrice := safeTx.GasPrice
+	gasToken := safeTx.GasToken
+	refundReceiver := safeTx.RefundReceiver
 
 	log.Printf("Safe交易参数:")
 	log.Printf("  - operation: %d", operation)
@@ -378,18 +503,17 @@ func (se *SafeExecutor) executeSafeTransaction(
 	log.Printf("  - refundReceiver: %s", refundReceiver.Hex())
 
 	// 构建Safe交易哈希 - 使用统一的buildSafeTxHash方法
-	safeTxHash := se.buildSafeTxHash(safeAddress, to, value, data, nonce)
+	safeTxHash := se.buildSafeTxHash(safeAddress, safeTx)
 	log.Printf("Safe交易哈希: %s", safeTxHash.Hex())
 
 	// 收集提案的所有签名
-	signatures, err := se.collectProposalSignatures(proposalID, safeTxHash)
+	signatures, _, err := se.collectProposalSignatures(proposalID, safeAddress, safeTx)
 	if err != nil {
 		return "", fmt.Errorf("failed to collect signatures: %v", err)
 	}
 	log.Printf("收集到签名数据: %x (长度: %d字节)", signatures, len(signatures))
 
 	// 构建execTransaction调用
-	safeABI := getSafeABI()
 	log.Printf("准备调用execTransaction，参数:")
 	log.Printf("  - to: %s", to.Hex())
 	log.Printf("  - value: %s", value.String())
@@ -402,58 +526,75 @@ func (se *SafeExecutor) executeSafeTransaction(
 	log.Printf("  - refundReceiver: %s", refundReceiver.Hex())
 	log.Printf("  - signatures: %x", signatures)
 
-	execData, err := safeABI.Pack(
-		"execTransaction",
-		to, value, data, operation,
-		safeTxGas, baseGas, gasPrice,
-		gasToken, refundReceiver, signatures,
-	)
+	execData, err := packExecTransaction(safeTx, signatures)
 	if err != nil {
-		return "", fmt.Errorf("failed to pack execTransaction: %v", err)
+		return "", err
 	}
 	log.Printf("execTransaction编码数据: %x (长度: %d字节)", execData, len(execData))
 
-	// 获取当前nonce
 	ctx := context.Background()
-	fromAddress := crypto.PubkeyToAddress(se.privateKey.PublicKey)
+	fromAddress := se.signer.Address()
 	log.Printf("发送方地址: %s", fromAddress.Hex())
 
-	nonceUint64, err := se.client.PendingNonceAt(ctx, fromAddress)
+	// 广播前在pending区块上模拟execTransaction，会revert的交易直接拒绝，不消耗gas
+	simulation, err := se.simulateExecTransaction(ctx, fromAddress, safeAddress, execData)
 	if err != nil {
-		return "", fmt.Errorf("failed to get nonce: %v", err)
+		return "", err
+	}
+	if !simulation.Success {
+		simulation.SafeNonce = safeTx.Nonce.String()
+		simulation.SafeTxHash = safeTxHash.Hex()
+		log.Printf("🚫 execTransaction模拟失败，拒绝广播: %s", simulation.Summary())
+		return "", &SimulationError{ProposalID: proposalID, Result: simulation}
 	}
-	log.Printf("发送方nonce: %d", nonceUint64)
 
-	// 获取gas价格
-	gasPriceBig, err := se.client.SuggestGasPrice(ctx)
+	// 估算gas：估算失败说明execTransaction会revert（签名无效、余额不足等），不发送交易
+	gasEstimate, gasLimit, err := estimateGasLimit(ctx, se.client, ethereum.CallMsg{
+		From: fromAddress,
+		To:   &safeAddress,
+		Data: execData,
+	}, se.fees.GasLimitBufferPercent)
 	if err != nil {
-		return "", fmt.Errorf("failed to get gas price: %v", err)
+		return "", err
 	}
-	log.Printf("建议gas价格: %s wei", gasPriceBig.String())
+	log.Printf("gas估算: %d, 缓冲%d%%后gas限制: %d", gasEstimate, se.fees.GasLimitBufferPercent, gasLimit)
 
-	// 估算gas
-	gasLimit := uint64(500000) // 设置合理的gas限制
-	log.Printf("gas限制: %d", gasLimit)
+	// 计算费用（EIP-1559优先）
+	txParams, err := suggestFees(ctx, se.client, se.fees)
+	if err != nil {
+		return "", err
+	}
+	txParams.GasEstimate = gasEstimate
+	txParams.GasLimit = gasLimit
+
+	// 在nonce管理器中分配执行账户nonce，并发执行的提案不会拿到相同nonce
+	nonceUint64, err := se.nonces.Allocate(ctx, se.client, se.chainID.Int64(), fromAddress, proposalID)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("发送方nonce: %d", nonceUint64)
 
-	tx := types.NewTransaction(
-		nonceUint64,
-		safeAddress,
-		big.NewInt(0), // 调用Safe合约本身不需要发送ETH，ETH由Safe合约内部转账
-		gasLimit,
-		gasPriceBig,
-		execData,
-	)
+	// 调用Safe合约本身不需要发送ETH，ETH由Safe合约内部转账
+	tx := txParams.buildTransaction(se.chainID, nonceUint64, safeAddress, execData)
 	log.Printf("创建以太坊交易:")
+	log.Printf("  - type: %d", tx.Type())
 	log.Printf("  - nonce: %d", nonceUint64)
 	log.Printf("  - to: %s", safeAddress.Hex())
 	log.Printf("  - value: 0 ETH")
 	log.Printf("  - gasLimit: %d", gasLimit)
-	log.Printf("  - gasPrice: %s", gasPriceBig.String())
+	if txParams.TxType == types.DynamicFeeTxType {
+		log.Printf("  - baseFee: %s", txParams.BaseFee.String())
+		log.Printf("  - maxFeePerGas: %s", txParams.MaxFeePerGas.String())
+		log.Printf("  - maxPriorityFeePerGas: %s", txParams.MaxPriorityFeePerGas.String())
+	} else {
+		log.Printf("  - gasPrice: %s", txParams.GasPrice.String())
+	}
 	log.Printf("  - data长度: %d字节", len(execData))
 
 	// 签名并发送交易
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(se.chainID), se.privateKey)
+	signedTx, err := se.signer.SignTx(ctx, tx, se.chainID)
 	if err != nil {
+		se.nonces.Release(ctx, se.chainID.Int64(), fromAddress, nonceUint64)
 		return "", fmt.Errorf("failed to sign transaction: %v", err)
 	}
 	log.Printf("交易签名成功")
@@ -461,13 +602,29 @@ func (se *SafeExecutor) executeSafeTransaction(
 	err = se.client.SendTransaction(ctx, signedTx)
 	if err != nil {
 		log.Printf("发送交易失败: %v", err)
+		se.nonces.Release(ctx, se.chainID.Int64(), fromAddress, nonceUint64)
 		return "", fmt.Errorf("failed to send transaction: %v", err)
 	}
 
 	txHash := signedTx.Hash().Hex()
+	if err := se.nonces.MarkBroadcast(ctx, se.chainID.Int64(), fromAddress, nonceUint64, txHash); err != nil {
+		log.Printf("⚠️ 记录nonce %d 广播状态失败: %v", nonceUint64, err)
+	}
 	log.Printf("=== 交易发送成功 ===")
 	log.Printf("交易哈希: %s", txHash)
-	log.Printf("Etherscan链接: https://sepolia.etherscan.io/tx/%s", txHash)
+	if se.explorerURL != "" {
+		log.Printf("区块浏览器链接: %s/tx/%s", strings.TrimRight(se.explorerURL, "/"), txHash)
+	}
+
+	// 记录执行交易使用的nonce、gas和费用参数，供监控器跟踪及加速/取消
+	updates := txParams.proposalUpdates()
+	updates["tx_nonce"] = int64(nonceUint64)
+	if err := se.db.Model(&models.Proposal{}).Where("id = ?", proposalID).Updates(updates).Error; err != nil {
+		log.Printf("⚠️ 记录提案 %s 的交易费用参数失败: %v", proposalID, err)
+	}
+	if _, err := recordExecutionTx(se.db, proposalID, se.chainID.Int64(), fromAddress, signedTx, txParams, models.ExecutionTxActionExecute, nil, nil); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
 
 	return txHash, nil
 }
@@ -528,7 +685,11 @@ func (se *SafeExecutor) updateProposalExecution(proposal *models.Proposal, txHas
 		"tx_hash": txHash,
 	}
 
-	return se.db.Model(proposal).Updates(updates).Error
+	if err := se.db.Model(proposal).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	return updateBatchItemsStatus(se.db, proposal.ID, "executed")
 }
 
 // determineProposalType 根据提案数据判断提案类型
@@ -536,6 +697,16 @@ func (se *SafeExecutor) determineProposalType(proposal *models.Proposal) string
 	// 简化的类型判断逻辑
 	// 实际应用中可以根据更复杂的规则判断
 
+	if proposal.ProposalType == "batch" || proposal.ProposalType == "token_transfer" {
+		// 批量交易和代币转账需要校验调用数据编码，不能按普通合约调用处理
+		return proposal.ProposalType
+	}
+
+	if proposal.ProposalType == "rejection" {
+		// 拒绝提案固定为对Safe自身的0值调用，不使用提案中的目标和数据
+		return proposal.ProposalType
+	}
+
 	if proposal.Data != nil && len(*proposal.Data) > 2 {
 		// 有合约调用数据，判断为合约调用
 		return "contract_call"
@@ -586,6 +757,16 @@ func getSafeABI() abi.ABI {
 			"stateMutability": "view",
 			"type": "function"
 		},
+		{
+			"inputs": [
+				{"internalType": "address", "name": "", "type": "address"},
+				{"internalType": "bytes32", "name": "", "type": "bytes32"}
+			],
+			"name": "approvedHashes",
+			"outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
+			"stateMutability": "view",
+			"type": "function"
+		},
 		{
 			"inputs": [],
 			"name": "getThreshold",
@@ -629,8 +810,10 @@ func getSafeABI() abi.ABI {
 	return parsedABI
 }
 
-// collectProposalSignatures 从数据库收集提案的所有签名并验证其有效性
-func (se *SafeExecutor) collectProposalSignatures(proposalID uuid.UUID, safeTxHash common.Hash) ([]byte, error) {
+// collectProposalSignatures 从数据库收集提案的所有签名，针对safeTx验证后连同链上approveHash审批编码为Safe合约要求的签名格式
+// 返回编码后的签名数据及其中包含的签名数量（合约签名的动态数据附在末尾，不能按65字节计数）
+func (se *SafeExecutor) collectProposalSignatures(proposalID uuid.UUID, safeAddress common.Address, safeTx *SafeTransaction) ([]byte, int, error) {
+	safeTxHash := ComputeSafeTxHash(se.chainID, safeAddress, safeTx)
 	log.Printf("=== 开始收集提案签名 ===")
 	log.Printf("提案ID: %s", proposalID.String())
 	log.Printf("Safe交易哈希: %s", safeTxHash.Hex())
@@ -639,201 +822,64 @@ func (se *SafeExecutor) collectProposalSignatures(proposalID uuid.UUID, safeTxHa
 	err := se.db.Preload("Signer").Where("proposal_id = ? AND status = ?", proposalID, "valid").
 		Find(&signatures).Error
 	if err != nil {
-		return nil, fmt.Errorf("failed to query signatures: %v", err)
-	}
-
-	if len(signatures) == 0 {
-		return nil, fmt.Errorf("no valid signatures found for proposal")
+		return nil, 0, fmt.Errorf("failed to query signatures: %v", err)
 	}
 
 	log.Printf("从数据库查询到 %d 个有效签名", len(signatures))
 
-	// 验证签名的有效性结构
-	type ValidatedSignature struct {
-		Address   common.Address
-		Signature []byte
-		Original  models.Signature
-	}
-
-	var validatedSigs []ValidatedSignature
-
-	// 验证每个签名
+	var parts []safeSignaturePart
 	for i, sig := range signatures {
-		log.Printf("验证签名 %d:", i+1)
+		log.Printf("验证签名 %d (%s):", i+1, sig.SignatureType)
 
-		// 验证签名数据格式
 		if sig.SignatureData == "" {
 			log.Printf("  ❌ 签名数据为空，跳过")
 			continue
 		}
-
-		// 获取签名者钱包地址
 		if sig.Signer.WalletAddress == nil {
 			log.Printf("  ❌ 签名者钱包地址为空，跳过")
 			continue
 		}
 		expectedSigner := common.HexToAddress(*sig.Signer.WalletAddress)
-		log.Printf("  - 期望签名者: %s", expectedSigner.Hex())
 
-		// 移除0x前缀并解码
-		sigData := strings.TrimPrefix(sig.SignatureData, "0x")
-		sigBytes, err := hex.DecodeString(sigData)
+		sigBytes, err := hex.DecodeString(strings.TrimPrefix(sig.SignatureData, "0x"))
 		if err != nil {
 			log.Printf("  ❌ 签名解码失败: %v", err)
 			continue
 		}
 
-		// 验证签名长度
-		if len(sigBytes) != 65 {
-			log.Printf("  ❌ 签名长度无效: %d字节，期望65字节", len(sigBytes))
-			continue
-		}
-
-		// 关键修复：验证签名是否针对当前safeTxHash
-		recoveredAddr, err := se.recoverSignerFromSignature(safeTxHash, sigBytes)
+		// 签名必须针对当前safeTxHash（nonce变化后旧签名失效）
+		verified, err := se.verifySafeSignature(context.Background(), safeAddress, safeTx, safeTxHash, expectedSigner, sig.SignatureType, sigBytes)
 		if err != nil {
-			log.Printf("  ❌ 签名恢复失败: %v", err)
-			log.Printf("    签名数据: %x", sigBytes)
-			log.Printf("    SafeTxHash: %s", safeTxHash.Hex())
-			continue
-		}
-
-		log.Printf("  - 恢复的签名者: %s", recoveredAddr.Hex())
-
-		// 验证恢复的地址与期望的签名者地址匹配 (大小写不敏感)
-		if !strings.EqualFold(recoveredAddr.Hex(), expectedSigner.Hex()) {
-			log.Printf("  ❌ 签名者地址不匹配:")
-			log.Printf("    期望: %s", expectedSigner.Hex())
-			log.Printf("    实际: %s", recoveredAddr.Hex())
-
-			// 检查签名是否记录了SafeTxHash，用于调试
-			if sig.SafeTxHash != nil {
-				log.Printf("    记录的SafeTxHash: %s", *sig.SafeTxHash)
-				log.Printf("    当前SafeTxHash:   %s", safeTxHash.Hex())
-				if !strings.EqualFold(*sig.SafeTxHash, safeTxHash.Hex()) {
-					log.Printf("    ⚠️ SafeTxHash不匹配，可能是nonce变化导致")
-				}
+			log.Printf("  ❌ 签名验证失败: %v", err)
+			if sig.SafeTxHash != nil && !strings.EqualFold(*sig.SafeTxHash, safeTxHash.Hex()) {
+				log.Printf("    ⚠️ 记录的SafeTxHash %s 与当前 %s 不一致，可能是nonce变化导致", *sig.SafeTxHash, safeTxHash.Hex())
 			}
 			continue
 		}
 
-		// 处理v值以符合Safe合约要求
-		processedSig := make([]byte, 65)
-		copy(processedSig, sigBytes)
-
-		// Safe合约签名v值处理
-		if processedSig[64] == 0 || processedSig[64] == 1 {
-			processedSig[64] += 27
-		} else if processedSig[64] > 30 {
-			if processedSig[64]%2 == 0 {
-				processedSig[64] = 28
-			} else {
-				processedSig[64] = 27
-			}
-		}
-
-		log.Printf("  ✅ 签名验证通过:")
-		log.Printf("    - 签名者地址: %s", recoveredAddr.Hex())
-		log.Printf("    - r: %x", processedSig[:32])
-		log.Printf("    - s: %x", processedSig[32:64])
-		log.Printf("    - v: %d", processedSig[64])
-
-		validatedSigs = append(validatedSigs, ValidatedSignature{
-			Address:   recoveredAddr,
-			Signature: processedSig,
-			Original:  sig,
+		log.Printf("  ✅ 签名验证通过: %s (%s)", verified.Signer.Hex(), verified.SignatureType)
+		parts = append(parts, safeSignaturePart{
+			Signer:    verified.Signer,
+			Type:      verified.SignatureType,
+			Signature: verified.Signature,
 		})
 	}
 
-	if len(validatedSigs) == 0 {
-		return nil, fmt.Errorf("no valid signatures after verification - all signatures failed validation against safeTxHash %s", safeTxHash.Hex())
-	}
+	// 链上approveHash的所有者以v=1预验证签名计入
+	parts = se.appendApprovedHashParts(context.Background(), safeAddress, safeTxHash, parts)
 
-	// 按签名者地址排序（Safe合约要求）
-	sort.Slice(validatedSigs, func(i, j int) bool {
-		return strings.ToLower(validatedSigs[i].Address.Hex()) < strings.ToLower(validatedSigs[j].Address.Hex())
-	})
-
-	log.Printf("签名按地址排序完成")
-
-	// 组合最终签名数据
-	var combinedSignatures []byte
-	for i, validSig := range validatedSigs {
-		log.Printf("添加签名 %d: %s", i+1, validSig.Address.Hex())
-		combinedSignatures = append(combinedSignatures, validSig.Signature...)
+	if len(parts) == 0 {
+		return nil, 0, fmt.Errorf("no valid signatures or on-chain approvals for safeTxHash %s", safeTxHash.Hex())
 	}
 
+	// 按签名者地址排序并合并（Safe合约要求）
+	combinedSignatures := encodeSafeSignatures(parts)
+
 	log.Printf("=== 签名收集和验证完成 ===")
-	log.Printf("验证通过的签名数量: %d", len(validatedSigs))
+	log.Printf("验证通过的签名数量: %d", len(parts))
 	log.Printf("合并后签名总长度: %d字节", len(combinedSignatures))
-	log.Printf("合并签名数据: %x", combinedSignatures)
 
-	return combinedSignatures, nil
-}
-
-// recoverSignerFromSignature 从签名中恢复签名者地址
-func (se *SafeExecutor) recoverSignerFromSignature(hash common.Hash, signature []byte) (common.Address, error) {
-	if len(signature) != 65 {
-		return common.Address{}, fmt.Errorf("invalid signature length: %d", len(signature))
-	}
-
-	// 复制签名以避免修改原始数据
-	sig := make([]byte, 65)
-	copy(sig, signature)
-
-	// 详细日志记录签名数据
-	log.Printf("    原始签名长度: %d", len(signature))
-	log.Printf("    原始v值: %d", signature[64])
-
-	// 处理v值 - EIP-712签名通常使用27/28，需要转换为0/1
-	v := sig[64]
-
-	// 尝试不同的v值处理方式
-	if v == 27 || v == 28 {
-		sig[64] = v - 27 // 标准以太坊签名：27/28 -> 0/1
-	} else if v == 0 || v == 1 {
-		// 已经是正确格式，无需修改
-	} else if v > 28 {
-		// 处理EIP-155格式的v值
-		sig[64] = (v - 35) % 2
-	} else {
-		log.Printf("    尝试使用原始v值: %d", v)
-	}
-
-	log.Printf("    处理后v值: %d", sig[64])
-
-	// 确保v值在有效范围内
-	if sig[64] > 1 {
-		// 尝试其他可能的v值
-		for tryV := 0; tryV <= 1; tryV++ {
-			sig[64] = byte(tryV)
-			pubKey, err := crypto.Ecrecover(hash.Bytes(), sig)
-			if err == nil {
-				pubKeyECDSA, err := crypto.UnmarshalPubkey(pubKey)
-				if err == nil {
-					address := crypto.PubkeyToAddress(*pubKeyECDSA)
-					log.Printf("    成功使用v=%d恢复地址: %s", tryV, address.Hex())
-					return address, nil
-				}
-			}
-		}
-		return common.Address{}, fmt.Errorf("invalid recovery id after all attempts: original=%d", v)
-	}
-
-	// 使用go-ethereum的crypto.Ecrecover恢复公钥
-	pubKey, err := crypto.Ecrecover(hash.Bytes(), sig)
-	if err != nil {
-		return common.Address{}, fmt.Errorf("failed to recover public key: %v", err)
-	}
-
-	// 从公钥计算地址
-	pubKeyECDSA, err := crypto.UnmarshalPubkey(pubKey)
-	if err != nil {
-		return common.Address{}, fmt.Errorf("failed to unmarshal public key: %v", err)
-	}
-
-	address := crypto.PubkeyToAddress(*pubKeyECDSA)
-	return address, nil
+	return combinedSignatures, len(parts), nil
 }
 
 // validateSignaturesForCurrentNonce 验证签名是否对当前Safe nonce有效
@@ -857,17 +903,12 @@ func (se *SafeExecutor) validateSignaturesForCurrentNonce(proposalID uuid.UUID,
 
 	log.Printf("Found %d signatures to validate", len(signatures))
 
-	// 3. 构建当前nonce的SafeTxHash用于验证
-	toAddress := common.HexToAddress(*proposal.ToAddress)
-	value, _ := new(big.Int).SetString(proposal.Value, 10)
-
-	safeTxHash := se.buildSafeTxHash(
-		safeAddress,
-		toAddress,
-		value,
-		[]byte{}, // 转账无data
-		currentNonce,
-	)
+	// 3. 构建当前nonce的SafeTxHash用于验证（包含提案的data、operation和gas参数）
+	safeTx, err := SafeTxFromProposal(&proposal, currentNonce)
+	if err != nil {
+		return nil, err
+	}
+	safeTxHash := se.buildSafeTxHash(safeAddress, safeTx)
 
 	log.Printf("Expected SafeTxHash for nonce %s: %s", currentNonce.String(), safeTxHash.Hex())
 
@@ -901,34 +942,15 @@ func (se *SafeExecutor) validateSignaturesForCurrentNonce(proposalID uuid.UUID,
 		}
 
 		// 解码签名数据
-		sigData := strings.TrimPrefix(sig.SignatureData, "0x")
-		sigBytes, err := hex.DecodeString(sigData)
+		sigBytes, err := hex.DecodeString(strings.TrimPrefix(sig.SignatureData, "0x"))
 		if err != nil {
 			log.Printf("  ❌ 签名解码失败: %v", err)
 			continue
 		}
 
-		// 验证签名长度
-		if len(sigBytes) != 65 {
-			log.Printf("  ❌ 签名长度无效: %d字节，期望65字节", len(sigBytes))
-			continue
-		}
-
-		// 恢复签名者地址
-		recoveredAddr, err := se.recoverSignerFromSignature(safeTxHash, sigBytes)
-		if err != nil {
-			log.Printf("  ❌ 签名恢复失败: %v", err)
-			continue
-		}
-
-		expectedSigner := common.HexToAddress(walletAddr)
-		log.Printf("  🔍 期望签名者: %s, 恢复的签名者: %s", expectedSigner.Hex(), recoveredAddr.Hex())
-
-		// 验证恢复的地址与期望的签名者地址匹配 (大小写不敏感)
-		if !strings.EqualFold(recoveredAddr.Hex(), expectedSigner.Hex()) {
-			log.Printf("  ❌ 签名者地址不匹配:")
-			log.Printf("    期望: %s", expectedSigner.Hex())
-			log.Printf("    实际: %s", recoveredAddr.Hex())
+		// 按签名类型（EIP-712/eth_sign/合约签名）验证签名者
+		if _, err := se.verifySafeSignature(context.Background(), safeAddress, safeTx, safeTxHash, common.HexToAddress(walletAddr), sig.SignatureType, sigBytes); err != nil {
+			log.Printf("  ❌ 签名验证失败: %v", err)
 			continue
 		}
 
@@ -942,71 +964,20 @@ func (se *SafeExecutor) validateSignaturesForCurrentNonce(proposalID uuid.UUID,
 	return validSignatures, nil
 }
 
-// buildSafeTxHash 构建Safe交易哈希 - 修复版本，与前端EIP-712逻辑完全一致
-func (se *SafeExecutor) buildSafeTxHash(safeAddress, to common.Address, value *big.Int, data []byte, nonce *big.Int) common.Hash {
-	log.Printf("=== 构建SafeTxHash (修复版本) ===")
+// buildSafeTxHash 构建Safe交易哈希 - 与NewSafeTxTypedData提供给前端签名的EIP-712数据一致
+func (se *SafeExecutor) buildSafeTxHash(safeAddress common.Address, safeTx *SafeTransaction) common.Hash {
+	log.Printf("=== 构建SafeTxHash ===")
 	log.Printf("Safe地址: %s", safeAddress.Hex())
-	log.Printf("目标地址: %s", to.Hex())
-	log.Printf("转账金额: %s wei", value.String())
-	log.Printf("数据长度: %d bytes", len(data))
-	log.Printf("Nonce: %s", nonce.String())
-
-	// 1. EIP-712 Domain Separator - 与前端完全一致
-	domainTypeHash := crypto.Keccak256([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
-	chainIdBytes := common.LeftPadBytes(big.NewInt(11155111).Bytes(), 32) // Sepolia
-	verifyingContractBytes := common.LeftPadBytes(safeAddress.Bytes(), 32)
-
-	domainSeparator := crypto.Keccak256Hash(
-		domainTypeHash,
-		chainIdBytes,
-		verifyingContractBytes,
-	)
-
-	log.Printf("Domain separator: %s", domainSeparator.Hex())
-
-	// 2. SafeTx TypeHash - 与前端完全一致
-	safeTxTypeHash := crypto.Keccak256([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
-
-	log.Printf("SafeTx TypeHash: %s", common.BytesToHash(safeTxTypeHash).Hex())
-
-	// 3. 构建SafeTx结构体哈希 - 与前端参数完全一致
-	// 确保data为空时使用空字节数组
-	if data == nil {
-		data = []byte{}
-	}
-	dataHash := crypto.Keccak256(data)
-
-	// 构建结构体哈希的所有字段
-	structHashData := [][]byte{
-		safeTxTypeHash,                                    // typeHash
-		common.LeftPadBytes(to.Bytes(), 32),               // to
-		common.LeftPadBytes(value.Bytes(), 32),            // value
-		common.LeftPadBytes(dataHash, 32),                 // keccak256(data)
-		common.LeftPadBytes([]byte{0}, 32),                // operation = 0 (CALL)
-		common.LeftPadBytes(big.NewInt(0).Bytes(), 32),    // safeTxGas = 0
-		common.LeftPadBytes(big.NewInt(0).Bytes(), 32),    // baseGas = 0
-		common.LeftPadBytes(big.NewInt(0).Bytes(), 32),    // gasPrice = 0
-		common.LeftPadBytes(common.Address{}.Bytes(), 32), // gasToken = 0x0
-		common.LeftPadBytes(common.Address{}.Bytes(), 32), // refundReceiver = 0x0
-		common.LeftPadBytes(nonce.Bytes(), 32),            // nonce
-	}
-
-	// 合并所有字段
-	var combined []byte
-	for _, field := range structHashData {
-		combined = append(combined, field...)
-	}
-
-	structHash := crypto.Keccak256(combined)
-	log.Printf("Struct hash: %s", common.BytesToHash(structHash).Hex())
-
-	// 4. 最终EIP-712哈希 - 与前端完全一致
-	finalHashData := []byte{}
-	finalHashData = append(finalHashData, []byte("\x19\x01")...)
-	finalHashData = append(finalHashData, domainSeparator.Bytes()...)
-	finalHashData = append(finalHashData, structHash...)
-
-	finalHash := crypto.Keccak256Hash(finalHashData)
+	log.Printf("目标地址: %s", safeTx.To.Hex())
+	log.Printf("转账金额: %s wei", safeTx.Value.String())
+	log.Printf("数据长度: %d bytes", len(safeTx.Data))
+	log.Printf("Operation: %d", safeTx.Operation)
+	log.Printf("SafeTxGas: %s, BaseGas: %s, GasPrice: %s", safeTx.SafeTxGas, safeTx.BaseGas, safeTx.GasPrice)
+	log.Printf("GasToken: %s, RefundReceiver: %s", safeTx.GasToken.Hex(), safeTx.RefundReceiver.Hex())
+	log.Printf("Nonce: %s", safeTx.Nonce.String())
+	log.Printf("链ID: %s", se.chainID.String())
+
+	finalHash := ComputeSafeTxHash(se.chainID, safeAddress, safeTx)
 
 	log.Printf("=== SafeTxHash构建完成 ===")
 	log.Printf("最终哈希: %s", finalHash.Hex())
@@ -0,0 +1,380 @@
+package blockchain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+// ErrSafeNonceNotReady 提案分配的Safe nonce之前还有未执行的交易
+var ErrSafeNonceNotReady = errors.New("proposal nonce is queued behind earlier Safe transactions")
+
+// ErrSafeNonceConsumed 提案分配的Safe nonce已被其他交易消耗
+var ErrSafeNonceConsumed = errors.New("proposal nonce has already been consumed on chain")
+
+// QueuedNonceStatuses 占用Safe nonce的提案状态（executed为执行交易已广播、尚未确认）
+var QueuedNonceStatuses = []string{"pending", "approved", "executed"}
+
+// RequeuedProposal 因nonce被越过而重新排队的提案
+type RequeuedProposal struct {
+	ProposalID uuid.UUID `json:"proposal_id"`
+	Title      string    `json:"title"`
+	OldNonce   int64     `json:"old_nonce"`
+	NewNonce   int64     `json:"new_nonce"`
+}
+
+// ReadSafeNonce 读取Safe合约当前nonce
+func ReadSafeNonce(ctx context.Context, caller ethereum.ContractCaller, safeAddress common.Address) (int64, error) {
+	safeABI := getSafeABI()
+	data, err := safeABI.Pack("nonce")
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &safeAddress, Data: data}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call nonce on %s: %w", safeAddress.Hex(), err)
+	}
+	if len(result) == 0 {
+		return 0, fmt.Errorf("empty result from nonce, %s may not be a Safe contract", safeAddress.Hex())
+	}
+	return new(big.Int).SetBytes(result).Int64(), nil
+}
+
+// LockSafeNonceQueue 在事务中锁定Safe记录，串行化同一Safe的nonce分配和重新排队
+// 持有锁期间计算的队尾nonce在事务提交前不会被其他事务分配
+func LockSafeNonceQueue(tx *gorm.DB, safeID uuid.UUID) error {
+	var safe models.Safe
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("id = ?", safeID).First(&safe).Error; err != nil {
+		return fmt.Errorf("failed to lock Safe %s nonce queue: %w", safeID, err)
+	}
+	return nil
+}
+
+// NextSafeNonce 返回Safe队列中下一个可分配的nonce：链上nonce与已排队提案最大nonce+1中的较大者
+func NextSafeNonce(db *gorm.DB, safeID uuid.UUID, chainNonce int64) (int64, error) {
+	var maxQueued sql.NullInt64
+	err := db.Model(&models.Proposal{}).
+		Select("MAX(nonce)").
+		Where("safe_id = ? AND status IN ? AND nonce >= ?", safeID, QueuedNonceStatuses, chainNonce).
+		Row().Scan(&maxQueued)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query queued Safe nonces: %w", err)
+	}
+
+	if maxQueued.Valid && maxQueued.Int64+1 > chainNonce {
+		return maxQueued.Int64 + 1, nil
+	}
+	return chainNonce, nil
+}
+
+// checkProposalNonce 提案分配的nonce必须等于Safe链上nonce才能执行
+func checkProposalNonce(assigned int64, chainNonce *big.Int) error {
+	current := chainNonce.Int64()
+	switch {
+	case assigned > current:
+		return fmt.Errorf("%w: proposal nonce %d, Safe nonce %d", ErrSafeNonceNotReady, assigned, current)
+	case assigned < current:
+		return fmt.Errorf("%w: proposal nonce %d, Safe nonce %d", ErrSafeNonceConsumed, assigned, current)
+	}
+	return nil
+}
+
+// executedSafeTxLookbackBlocks 历史索引中找不到时，向前查询Safe执行日志的区块数
+const executedSafeTxLookbackBlocks = 10000
+
+// safeLogReader 查询Safe执行日志所需的链接口（*ethclient.Client实现）
+type safeLogReader interface {
+	ethereum.LogFilterer
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// executedSafeTx 链上已执行（ExecutionSuccess/ExecutionFailure）的Safe交易
+type executedSafeTx struct {
+	TxHash      string
+	BlockNumber int64
+	Success     bool
+}
+
+// RequeueConsumedNonces 处理nonce已被链上越过的待处理提案
+// 提案自身的safeTxHash已在链上执行（如通过其他客户端或交易服务执行）时按执行结果标记为confirmed/failed；
+// 否则签名针对已消耗的nonce，无法再执行：签名作废、签名数清零、状态回到pending并重新排到队尾
+// 被链上拒绝的提案改为rejected，nonce被消耗的拒绝提案直接作废，二者都不重新排队
+// 无法确认提案是否已执行（查询日志失败）时返回错误且不做修改，留待下次对账
+func RequeueConsumedNonces(ctx context.Context, db *gorm.DB, client safeLogReader, safe *models.Safe, chainNonce int64) ([]RequeuedProposal, error) {
+	var candidates []models.Proposal
+	if err := db.WithContext(ctx).
+		Where("safe_id = ? AND status IN ? AND nonce < ?", safe.ID, []string{"pending", "approved"}, chainNonce).
+		Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to query stale proposals: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// 查询链上执行记录需要网络请求，在加锁事务之外完成
+	hashes := make(map[uuid.UUID]common.Hash, len(candidates))
+	for i := range candidates {
+		hash, err := proposalSafeTxHash(safe, &candidates[i])
+		if err != nil {
+			log.Printf("⚠️ [Nonce队列] 计算提案 %s 的safeTxHash失败: %v", candidates[i].ID, err)
+			continue
+		}
+		hashes[candidates[i].ID] = hash
+	}
+	executed, err := findExecutedSafeTxs(ctx, db, client, safe, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	var requeued []RequeuedProposal
+	var settled []uuid.UUID
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := LockSafeNonceQueue(tx, safe.ID); err != nil {
+			return err
+		}
+
+		var stale []models.Proposal
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("safe_id = ? AND status IN ? AND nonce < ?", safe.ID, []string{"pending", "approved"}, chainNonce).
+			Order("nonce ASC, created_at ASC").
+			Find(&stale).Error; err != nil {
+			return fmt.Errorf("failed to query stale proposals: %w", err)
+		}
+		if len(stale) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		handled := make(map[uuid.UUID]bool, len(stale))
+
+		// 先处理自身已在链上执行的提案，已执行的拒绝提案同时使原提案转为rejected
+		for _, proposal := range stale {
+			hash, ok := hashes[proposal.ID]
+			if !ok {
+				continue
+			}
+			execution, ok := executed[hash]
+			if !ok {
+				continue
+			}
+			if err := markProposalExecutedOnChain(tx, &proposal, hash, execution, now); err != nil {
+				return err
+			}
+			handled[proposal.ID] = true
+			settled = append(settled, proposal.ID)
+
+			if execution.Success && proposal.RejectsProposalID != nil {
+				if _, err := markProposalRejected(tx, *proposal.RejectsProposalID, &proposal, now); err != nil {
+					return err
+				}
+				handled[*proposal.RejectsProposalID] = true
+			}
+		}
+
+		next, err := NextSafeNonce(tx, safe.ID, chainNonce)
+		if err != nil {
+			return err
+		}
+
+		for _, proposal := range stale {
+			if handled[proposal.ID] {
+				continue
+			}
+
+			// 拒绝交易已上链：原提案按拒绝处理，不再排队
+			rejection, err := executedRejection(tx, proposal.ID)
+			if err != nil {
+				return err
+			}
+			if rejection != nil {
+				if _, err := markProposalRejected(tx, proposal.ID, rejection, now); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// 拒绝提案的nonce被其他交易（通常是原提案）消耗，拒绝已无意义
+			if proposal.RejectsProposalID != nil {
+				if err := tx.Model(&models.Proposal{}).Where("id = ?", proposal.ID).Updates(map[string]interface{}{
+					"status":           "rejected",
+					"rejection_reason": "Safe nonce已被其他交易消耗，拒绝交易作废",
+					"rejected_at":      now,
+					"updated_at":       now,
+				}).Error; err != nil {
+					return fmt.Errorf("failed to close rejection proposal %s: %w", proposal.ID, err)
+				}
+				log.Printf("🗑️ [Nonce队列] 拒绝提案 %s 的Safe nonce %d 已被消耗，拒绝作废", proposal.ID, *proposal.Nonce)
+				continue
+			}
+
+			if err := tx.Model(&models.Proposal{}).Where("id = ?", proposal.ID).Updates(map[string]interface{}{
+				"nonce":              next,
+				"status":             "pending",
+				"current_signatures": 0,
+				"safe_tx_hash":       nil,
+				"approved_at":        nil,
+				"updated_at":         now,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to requeue proposal %s: %w", proposal.ID, err)
+			}
+			// 失效签名保留记录，所有者按新nonce重新签名时被替换
+			if err := tx.Model(&models.Signature{}).
+				Where("proposal_id = ? AND status = ?", proposal.ID, "valid").
+				Update("status", "invalid").Error; err != nil {
+				return fmt.Errorf("failed to invalidate signatures of proposal %s: %w", proposal.ID, err)
+			}
+
+			requeued = append(requeued, RequeuedProposal{
+				ProposalID: proposal.ID,
+				Title:      proposal.Title,
+				OldNonce:   *proposal.Nonce,
+				NewNonce:   next,
+			})
+			next++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, proposalID := range settled {
+		log.Printf("🔗 [Nonce队列] 提案 %s 的safeTxHash已在链上执行，按链上结果更新状态", proposalID)
+	}
+	for _, item := range requeued {
+		log.Printf("🔁 [Nonce队列] 提案 %s 的Safe nonce %d 已被消耗，重新排队到nonce %d，需重新签名",
+			item.ProposalID, item.OldNonce, item.NewNonce)
+	}
+	return requeued, nil
+}
+
+// proposalSafeTxHash 按提案分配的nonce计算safeTxHash
+func proposalSafeTxHash(safe *models.Safe, proposal *models.Proposal) (common.Hash, error) {
+	if proposal.Nonce == nil {
+		return common.Hash{}, fmt.Errorf("proposal %s has no nonce", proposal.ID)
+	}
+	safeTx, err := SafeTxFromProposal(proposal, big.NewInt(*proposal.Nonce))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return ComputeSafeTxHash(big.NewInt(int64(safe.ChainID)), common.HexToAddress(safe.Address), safeTx), nil
+}
+
+// findExecutedSafeTxs 查找已在链上执行的safeTxHash
+// 先查历史索引（safe_history_events），索引未覆盖的再查询最近executedSafeTxLookbackBlocks个区块的执行日志
+func findExecutedSafeTxs(ctx context.Context, db *gorm.DB, client safeLogReader, safe *models.Safe, hashes map[uuid.UUID]common.Hash) (map[common.Hash]executedSafeTx, error) {
+	executed := make(map[common.Hash]executedSafeTx)
+	if len(hashes) == 0 {
+		return executed, nil
+	}
+
+	wanted := make(map[common.Hash]bool, len(hashes))
+	hexHashes := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		if !wanted[hash] {
+			wanted[hash] = true
+			hexHashes = append(hexHashes, strings.ToLower(hash.Hex()))
+		}
+	}
+
+	var events []models.SafeHistoryEvent
+	if err := db.WithContext(ctx).
+		Where("safe_id = ? AND event_type IN ? AND LOWER(safe_tx_hash) IN ?", safe.ID,
+			[]string{models.HistoryEventExecutionSuccess, models.HistoryEventExecutionFailure}, hexHashes).
+		Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to query Safe execution history: %w", err)
+	}
+	for _, event := range events {
+		executed[common.HexToHash(*event.SafeTxHash)] = executedSafeTx{
+			TxHash:      event.TxHash,
+			BlockNumber: int64(event.BlockNumber),
+			Success:     event.EventType == models.HistoryEventExecutionSuccess,
+		}
+	}
+	if len(executed) == len(wanted) {
+		return executed, nil
+	}
+
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	from := uint64(0)
+	if head > executedSafeTxLookbackBlocks {
+		from = head - executedSafeTxLookbackBlocks
+	}
+
+	safeAddress := common.HexToAddress(safe.Address)
+	for start := from; start <= head; start += defaultHistoryBlockBatch {
+		end := start + defaultHistoryBlockBatch - 1
+		if end > head {
+			end = head
+		}
+		logs, err := client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{safeAddress},
+			Topics:    [][]common.Hash{{executionSuccessTopic, executionFailureTopic}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query Safe execution logs: %w", err)
+		}
+		for _, vLog := range logs {
+			event, ok := decodeHistoryLog(vLog, safeAddress)
+			if !ok || event.SafeTxHash == nil {
+				continue
+			}
+			hash := common.HexToHash(*event.SafeTxHash)
+			if !wanted[hash] {
+				continue
+			}
+			executed[hash] = executedSafeTx{
+				TxHash:      event.TxHash,
+				BlockNumber: int64(event.BlockNumber),
+				Success:     event.EventType == models.HistoryEventExecutionSuccess,
+			}
+		}
+	}
+	return executed, nil
+}
+
+// markProposalExecutedOnChain 提案的safeTxHash已在链上执行：按执行结果记录交易哈希和区块，签名保持有效
+func markProposalExecutedOnChain(tx *gorm.DB, proposal *models.Proposal, safeTxHash common.Hash, execution executedSafeTx, now time.Time) error {
+	status := "confirmed"
+	updates := map[string]interface{}{
+		"tx_hash":      execution.TxHash,
+		"block_number": execution.BlockNumber,
+		"safe_tx_hash": safeTxHash.Hex(),
+		"updated_at":   now,
+	}
+	if execution.Success {
+		updates["confirmed_at"] = now
+	} else {
+		status = "failed"
+		updates["failure_reason"] = "Safe交易在链上执行失败（ExecutionFailure）"
+	}
+	updates["status"] = status
+
+	if err := tx.Model(&models.Proposal{}).Where("id = ?", proposal.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to mark proposal %s executed: %w", proposal.ID, err)
+	}
+	if err := updateBatchItemsStatus(tx, proposal.ID, status); err != nil {
+		return fmt.Errorf("failed to update batch items of proposal %s: %w", proposal.ID, err)
+	}
+	return nil
+}
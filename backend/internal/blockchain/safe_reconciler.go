@@ -0,0 +1,382 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"web3-enterprise-multisig/internal/models"
+	"web3-enterprise-multisig/internal/websocket"
+)
+
+// defaultReconcileInterval 定时对账默认间隔
+const defaultReconcileInterval = 10 * time.Minute
+
+// SafeOnChainState Safe合约的链上配置
+type SafeOnChainState struct {
+	Owners    []common.Address
+	Threshold uint64
+	Nonce     uint64
+}
+
+// ReadSafeState 通过getOwners()/getThreshold()/nonce()读取Safe链上状态
+func ReadSafeState(ctx context.Context, caller ethereum.ContractCaller, safeAddress common.Address) (*SafeOnChainState, error) {
+	safeABI := getSafeABI()
+
+	call := func(method string) ([]interface{}, error) {
+		data, err := safeABI.Pack(method)
+		if err != nil {
+			return nil, err
+		}
+		result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &safeAddress, Data: data}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call %s on %s: %w", method, safeAddress.Hex(), err)
+		}
+		if len(result) == 0 {
+			return nil, fmt.Errorf("empty result from %s, %s may not be a Safe contract", method, safeAddress.Hex())
+		}
+		out, err := safeABI.Unpack(method, result)
+		if err != nil || len(out) == 0 {
+			return nil, fmt.Errorf("invalid %s result from %s", method, safeAddress.Hex())
+		}
+		return out, nil
+	}
+
+	ownersOut, err := call("getOwners")
+	if err != nil {
+		return nil, err
+	}
+	owners, ok := ownersOut[0].([]common.Address)
+	if !ok {
+		return nil, fmt.Errorf("invalid getOwners result from %s", safeAddress.Hex())
+	}
+
+	thresholdOut, err := call("getThreshold")
+	if err != nil {
+		return nil, err
+	}
+	threshold, ok := thresholdOut[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("invalid getThreshold result from %s", safeAddress.Hex())
+	}
+
+	nonceOut, err := call("nonce")
+	if err != nil {
+		return nil, err
+	}
+	nonce, ok := nonceOut[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("invalid nonce result from %s", safeAddress.Hex())
+	}
+
+	return &SafeOnChainState{
+		Owners:    owners,
+		Threshold: threshold.Uint64(),
+		Nonce:     nonce.Uint64(),
+	}, nil
+}
+
+// SafeReconciler Safe链上状态对账器
+// owners/threshold只在创建时写入数据库，add_owner/remove_owner/change_threshold执行后以及
+// 在其他客户端修改配置时会与链上不一致。对账器在提案执行确认后和定时任务中读取链上状态，
+// 更新models.Safe，发现漂移时写入safe_state_drifts审计记录并推送WebSocket事件
+type SafeReconciler struct {
+	client   *ethclient.Client
+	db       *gorm.DB
+	chain    *ChainConfig
+	wsHub    *websocket.Hub
+	interval time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewSafeReconciler 创建对账器，定时间隔读取环境变量 SAFE_RECONCILE_INTERVAL（默认10分钟）
+func NewSafeReconciler(chain *ChainConfig, db *gorm.DB, wsHub *websocket.Hub) (*SafeReconciler, error) {
+	client, err := chain.Dial(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	interval := defaultReconcileInterval
+	if v := os.Getenv("SAFE_RECONCILE_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			interval = parsed
+		} else {
+			log.Printf("⚠️ 无效的SAFE_RECONCILE_INTERVAL: %s，使用默认值 %s", v, defaultReconcileInterval)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SafeReconciler{
+		client:   client,
+		db:       db,
+		chain:    chain,
+		wsHub:    wsHub,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// Start 启动定时对账
+func (r *SafeReconciler) Start() {
+	log.Printf("🔄 启动Safe状态对账器 (链: %s, 间隔: %v)", r.chain.Name, r.interval)
+
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.reconcileAll()
+			case <-r.ctx.Done():
+				log.Printf("🛑 Safe状态对账器收到停止信号 (链: %s)", r.chain.Name)
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止对账器
+func (r *SafeReconciler) Stop() {
+	r.cancel()
+	if r.client != nil {
+		r.client.Close()
+	}
+}
+
+// ReconcileAfterExecution 提案执行确认后对账对应的Safe
+func (r *SafeReconciler) ReconcileAfterExecution(proposalID uuid.UUID) {
+	var proposal models.Proposal
+	if err := r.db.Preload("Safe").First(&proposal, proposalID).Error; err != nil {
+		log.Printf("❌ [Safe对账] 获取提案 %s 失败: %v", proposalID, err)
+		return
+	}
+
+	if _, err := r.ReconcileSafe(r.ctx, &proposal.Safe, models.DriftSourceExecution, &proposal.ID); err != nil {
+		log.Printf("❌ [Safe对账] 提案 %s 执行后对账失败: %v", proposalID, err)
+	}
+}
+
+// reconcileAll 对账该链上的所有活跃Safe
+func (r *SafeReconciler) reconcileAll() {
+	var safes []models.Safe
+	if err := r.db.WithContext(r.ctx).
+		Where("chain_id = ? AND status = ?", r.chain.ChainID, "active").
+		Find(&safes).Error; err != nil {
+		log.Printf("❌ [Safe对账] 查询Safe列表失败: %v", err)
+		return
+	}
+
+	for i := range safes {
+		if r.ctx.Err() != nil {
+			return
+		}
+		if _, err := r.ReconcileSafe(r.ctx, &safes[i], models.DriftSourceScheduled, nil); err != nil {
+			log.Printf("❌ [Safe对账] Safe %s 对账失败: %v", safes[i].Address, err)
+		}
+	}
+}
+
+// ReconcileSafe 读取Safe链上状态并更新数据库
+// owners或threshold发生变化时返回写入的漂移记录，否则只刷新nonce并返回nil
+func (r *SafeReconciler) ReconcileSafe(ctx context.Context, safe *models.Safe, source string, proposalID *uuid.UUID) (*models.SafeStateDrift, error) {
+	if int64(safe.ChainID) != r.chain.ChainID {
+		return nil, fmt.Errorf("safe %s is on chain %d, reconciler is connected to chain %d", safe.Address, safe.ChainID, r.chain.ChainID)
+	}
+
+	state, err := ReadSafeState(ctx, r.client, common.HexToAddress(safe.Address))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	newNonce := int64(state.Nonce)
+	newOwners := mergeOwnerAddresses(safe.Owners, state.Owners)
+	newThreshold := int(state.Threshold)
+
+	updates := map[string]interface{}{
+		"nonce":          newNonce,
+		"last_synced_at": now,
+	}
+
+	drifted := !sameOwnerSet(safe.Owners, newOwners) || safe.Threshold != newThreshold
+	if !drifted {
+		if err := r.db.WithContext(ctx).Model(&models.Safe{}).Where("id = ?", safe.ID).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("更新Safe同步状态失败: %w", err)
+		}
+		safe.Nonce = &newNonce
+		safe.LastSyncedAt = &now
+		r.requeueConsumedNonces(ctx, safe, newNonce)
+		return nil, nil
+	}
+
+	drift := &models.SafeStateDrift{
+		SafeID:       safe.ID,
+		ChainID:      r.chain.ChainID,
+		Source:       source,
+		ProposalID:   proposalID,
+		OldOwners:    safe.Owners,
+		NewOwners:    newOwners,
+		OldThreshold: safe.Threshold,
+		NewThreshold: newThreshold,
+		OldNonce:     safe.Nonce,
+		NewNonce:     newNonce,
+		DetectedAt:   now,
+	}
+
+	updates["owners"] = newOwners
+	updates["threshold"] = newThreshold
+	updates["updated_at"] = now
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Safe{}).Where("id = ?", safe.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新Safe owners/threshold失败: %w", err)
+		}
+		if err := tx.Create(drift).Error; err != nil {
+			return fmt.Errorf("写入Safe状态漂移记录失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("⚠️ [Safe对账] Safe %s 链上状态与数据库不一致，已同步: owners %d -> %d, threshold %d -> %d (来源: %s)",
+		safe.Address, len(drift.OldOwners), len(drift.NewOwners), drift.OldThreshold, drift.NewThreshold, source)
+
+	safe.Owners = newOwners
+	safe.Threshold = newThreshold
+	safe.Nonce = &newNonce
+	safe.LastSyncedAt = &now
+
+	r.notifyDrift(safe, drift)
+	r.requeueConsumedNonces(ctx, safe, newNonce)
+	return drift, nil
+}
+
+// notifyDrift 向变更前后的所有owners推送状态漂移事件
+func (r *SafeReconciler) notifyDrift(safe *models.Safe, drift *models.SafeStateDrift) {
+	if r.wsHub == nil {
+		log.Printf("⚠️ WebSocket Hub未初始化，跳过Safe状态漂移通知")
+		return
+	}
+
+	message := websocket.WebSocketMessage{
+		Type: "safe_state_drift",
+		Data: map[string]interface{}{
+			"safe_id":       safe.ID,
+			"safe_address":  safe.Address,
+			"safe_name":     safe.Name,
+			"chain_id":      drift.ChainID,
+			"source":        drift.Source,
+			"proposal_id":   drift.ProposalID,
+			"old_owners":    drift.OldOwners,
+			"new_owners":    drift.NewOwners,
+			"old_threshold": drift.OldThreshold,
+			"new_threshold": drift.NewThreshold,
+			"nonce":         drift.NewNonce,
+		},
+		Timestamp: time.Now().Unix(),
+	}
+
+	recipients := append(append([]string{}, drift.OldOwners...), drift.NewOwners...)
+	notified := r.sendToOwners(recipients, message)
+
+	log.Printf("📡 已发送Safe状态漂移通知: Safe=%s, 接收用户数=%d", safe.Address, notified)
+}
+
+// requeueConsumedNonces Safe nonce前进后，处理nonce被越过的提案并通知owners重新签名被重新排队的提案
+func (r *SafeReconciler) requeueConsumedNonces(ctx context.Context, safe *models.Safe, chainNonce int64) {
+	requeued, err := RequeueConsumedNonces(ctx, r.db, r.client, safe, chainNonce)
+	if err != nil {
+		log.Printf("❌ [Safe对账] Safe %s 重新排队提案失败: %v", safe.Address, err)
+		return
+	}
+	if len(requeued) == 0 {
+		return
+	}
+	if r.wsHub == nil {
+		log.Printf("⚠️ WebSocket Hub未初始化，跳过提案重新排队通知")
+		return
+	}
+
+	for _, item := range requeued {
+		message := websocket.WebSocketMessage{
+			Type: "proposal_nonce_requeued",
+			Data: map[string]interface{}{
+				"proposal_id":  item.ProposalID,
+				"title":        item.Title,
+				"safe_id":      safe.ID,
+				"safe_address": safe.Address,
+				"old_nonce":    item.OldNonce,
+				"new_nonce":    item.NewNonce,
+				"message":      fmt.Sprintf("提案\"%s\"的Safe nonce %d 已被其他交易使用，已重新排队到nonce %d，请重新签名", item.Title, item.OldNonce, item.NewNonce),
+			},
+			Timestamp: time.Now().Unix(),
+		}
+		r.sendToOwners(safe.Owners, message)
+	}
+}
+
+// sendToOwners 向钱包地址对应的用户推送消息（同一用户只推送一次），返回接收用户数
+func (r *SafeReconciler) sendToOwners(ownerAddresses []string, message websocket.WebSocketMessage) int {
+	notified := make(map[uuid.UUID]bool)
+	for _, ownerAddress := range ownerAddresses {
+		var ownerUser models.User
+		if err := r.db.Where("LOWER(wallet_address) = ?", strings.ToLower(ownerAddress)).First(&ownerUser).Error; err != nil {
+			continue
+		}
+		if notified[ownerUser.ID] {
+			continue
+		}
+		notified[ownerUser.ID] = true
+		r.wsHub.SendToUser(ownerUser.ID, message)
+	}
+	return len(notified)
+}
+
+// mergeOwnerAddresses 按链上顺序生成owners列表，已存在的地址保留数据库中的原始写法
+func mergeOwnerAddresses(existing []string, onChain []common.Address) models.PostgreSQLStringArray {
+	merged := make(models.PostgreSQLStringArray, 0, len(onChain))
+	for _, owner := range onChain {
+		address := owner.Hex()
+		for _, old := range existing {
+			if strings.EqualFold(old, address) {
+				address = old
+				break
+			}
+		}
+		merged = append(merged, address)
+	}
+	return merged
+}
+
+// sameOwnerSet 比较两个owners集合（忽略顺序和大小写）
+func sameOwnerSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]bool, len(a))
+	for _, owner := range a {
+		set[strings.ToLower(owner)] = true
+	}
+	for _, owner := range b {
+		if !set[strings.ToLower(owner)] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Safe合约把singleton、fallback handler和guard保存在固定存储槽中（Safe v1.3.0+）
+var (
+	singletonStorageSlot       = common.Hash{}
+	fallbackHandlerStorageSlot = crypto.Keccak256Hash([]byte("fallback_manager.handler.address"))
+	guardStorageSlot           = crypto.Keccak256Hash([]byte("guard_manager.guard.address"))
+)
+
+// sentinelModules 模块链表的哨兵地址
+var sentinelModules = common.HexToAddress("0x0000000000000000000000000000000000000001")
+
+// safeModulesPageSize 分页读取模块列表的页大小
+const safeModulesPageSize = 50
+
+const safeSetupABIJSON = `[
+	{"inputs":[{"name":"start","type":"address"},{"name":"pageSize","type":"uint256"}],"name":"getModulesPaginated","outputs":[{"name":"array","type":"address[]"},{"name":"next","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"VERSION","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"}
+]`
+
+var safeSetupABI, _ = abi.JSON(strings.NewReader(safeSetupABIJSON))
+
+// SafeSetup Safe合约的部署配置（owners/threshold/nonce见SafeOnChainState）
+type SafeSetup struct {
+	MasterCopy      common.Address
+	FallbackHandler common.Address
+	Guard           common.Address
+	Modules         []common.Address
+	Version         string
+}
+
+// ReadSafeSetup 读取Safe的singleton、fallback handler、guard、已启用模块和合约版本
+func ReadSafeSetup(ctx context.Context, client *ethclient.Client, safeAddress common.Address) (*SafeSetup, error) {
+	readSlot := func(slot common.Hash) (common.Address, error) {
+		value, err := client.StorageAt(ctx, safeAddress, slot, nil)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("failed to read storage of %s: %w", safeAddress.Hex(), err)
+		}
+		return common.BytesToAddress(value), nil
+	}
+
+	setup := &SafeSetup{Modules: []common.Address{}}
+	var err error
+	if setup.MasterCopy, err = readSlot(singletonStorageSlot); err != nil {
+		return nil, err
+	}
+	if setup.FallbackHandler, err = readSlot(fallbackHandlerStorageSlot); err != nil {
+		return nil, err
+	}
+	if setup.Guard, err = readSlot(guardStorageSlot); err != nil {
+		return nil, err
+	}
+
+	if setup.Version, err = readSafeVersion(ctx, client, safeAddress); err != nil {
+		return nil, err
+	}
+
+	start := sentinelModules
+	for {
+		page, next, err := readSafeModules(ctx, client, safeAddress, start)
+		if err != nil {
+			return nil, err
+		}
+		setup.Modules = append(setup.Modules, page...)
+		if next == sentinelModules || next == (common.Address{}) || len(page) == 0 {
+			break
+		}
+		start = next
+	}
+
+	return setup, nil
+}
+
+func readSafeVersion(ctx context.Context, caller ethereum.ContractCaller, safeAddress common.Address) (string, error) {
+	data, err := safeSetupABI.Pack("VERSION")
+	if err != nil {
+		return "", err
+	}
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &safeAddress, Data: data}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to call VERSION on %s: %w", safeAddress.Hex(), err)
+	}
+	out, err := safeSetupABI.Unpack("VERSION", result)
+	if err != nil || len(out) == 0 {
+		return "", fmt.Errorf("invalid VERSION result from %s", safeAddress.Hex())
+	}
+	version, _ := out[0].(string)
+	return version, nil
+}
+
+func readSafeModules(ctx context.Context, caller ethereum.ContractCaller, safeAddress, start common.Address) ([]common.Address, common.Address, error) {
+	data, err := safeSetupABI.Pack("getModulesPaginated", start, big.NewInt(safeModulesPageSize))
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &safeAddress, Data: data}, nil)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("failed to call getModulesPaginated on %s: %w", safeAddress.Hex(), err)
+	}
+	out, err := safeSetupABI.Unpack("getModulesPaginated", result)
+	if err != nil || len(out) != 2 {
+		return nil, common.Address{}, fmt.Errorf("invalid getModulesPaginated result from %s", safeAddress.Hex())
+	}
+	modules, _ := out[0].([]common.Address)
+	next, _ := out[1].(common.Address)
+	return modules, next, nil
+}
@@ -0,0 +1,257 @@
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+// TypedDataField EIP-712类型定义中的字段
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Safe合约（v1.3.0+）的EIP-712类型定义，类型哈希和对外提供的签名数据都由此生成
+var (
+	safeDomainFields = []TypedDataField{
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	}
+	safeTxFields = []TypedDataField{
+		{Name: "to", Type: "address"},
+		{Name: "value", Type: "uint256"},
+		{Name: "data", Type: "bytes"},
+		{Name: "operation", Type: "uint8"},
+		{Name: "safeTxGas", Type: "uint256"},
+		{Name: "baseGas", Type: "uint256"},
+		{Name: "gasPrice", Type: "uint256"},
+		{Name: "gasToken", Type: "address"},
+		{Name: "refundReceiver", Type: "address"},
+		{Name: "nonce", Type: "uint256"},
+	}
+)
+
+// Safe合约 EIP-712 类型哈希
+var (
+	safeDomainTypeHash = crypto.Keccak256([]byte(encodeTypedDataType("EIP712Domain", safeDomainFields)))
+	safeTxTypeHash     = crypto.Keccak256([]byte(encodeTypedDataType("SafeTx", safeTxFields)))
+)
+
+// SafeTxTypedDataDomain Safe交易的EIP-712域
+type SafeTxTypedDataDomain struct {
+	ChainID           int64  `json:"chainId"`
+	VerifyingContract string `json:"verifyingContract"`
+}
+
+// SafeTxTypedDataMessage SafeTx消息，uint256字段以十进制字符串表示
+type SafeTxTypedDataMessage struct {
+	To             string `json:"to"`
+	Value          string `json:"value"`
+	Data           string `json:"data"`
+	Operation      uint8  `json:"operation"`
+	SafeTxGas      string `json:"safeTxGas"`
+	BaseGas        string `json:"baseGas"`
+	GasPrice       string `json:"gasPrice"`
+	GasToken       string `json:"gasToken"`
+	RefundReceiver string `json:"refundReceiver"`
+	Nonce          string `json:"nonce"`
+}
+
+// SafeTxTypedData Safe交易的EIP-712签名数据，可直接用于eth_signTypedData_v4
+type SafeTxTypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      SafeTxTypedDataDomain       `json:"domain"`
+	Message     SafeTxTypedDataMessage      `json:"message"`
+}
+
+// NewSafeTxTypedData 生成SafeTx的EIP-712签名数据，按其签名得到的哈希即ComputeSafeTxHash的结果
+func NewSafeTxTypedData(chainID *big.Int, safeAddress common.Address, safeTx *SafeTransaction) *SafeTxTypedData {
+	return &SafeTxTypedData{
+		Types: map[string][]TypedDataField{
+			"EIP712Domain": safeDomainFields,
+			"SafeTx":       safeTxFields,
+		},
+		PrimaryType: "SafeTx",
+		Domain: SafeTxTypedDataDomain{
+			ChainID:           chainID.Int64(),
+			VerifyingContract: safeAddress.Hex(),
+		},
+		Message: SafeTxTypedDataMessage{
+			To:             safeTx.To.Hex(),
+			Value:          bigOrZero(safeTx.Value).String(),
+			Data:           hexutil.Encode(safeTx.Data),
+			Operation:      safeTx.Operation,
+			SafeTxGas:      bigOrZero(safeTx.SafeTxGas).String(),
+			BaseGas:        bigOrZero(safeTx.BaseGas).String(),
+			GasPrice:       bigOrZero(safeTx.GasPrice).String(),
+			GasToken:       safeTx.GasToken.Hex(),
+			RefundReceiver: safeTx.RefundReceiver.Hex(),
+			Nonce:          bigOrZero(safeTx.Nonce).String(),
+		},
+	}
+}
+
+// encodeTypedDataType 按EIP-712 encodeType生成类型字符串，如 "SafeTx(address to,...)"
+func encodeTypedDataType(name string, fields []TypedDataField) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field.Type + " " + field.Name
+	}
+	return name + "(" + strings.Join(parts, ",") + ")"
+}
+
+// SafeTxFromProposal 根据提案字段构建SafeTx
+// to/value/data/operation 以及 safeTxGas、baseGas、gasPrice、gasToken、refundReceiver 均取自提案
+func SafeTxFromProposal(proposal *models.Proposal, nonce *big.Int) (*SafeTransaction, error) {
+	var to common.Address
+	if proposal.ToAddress != nil && *proposal.ToAddress != "" {
+		to = common.HexToAddress(*proposal.ToAddress)
+	}
+
+	value, err := parseUint256(proposal.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %s", proposal.Value)
+	}
+
+	var data []byte
+	if proposal.Data != nil {
+		data = common.FromHex(*proposal.Data)
+	}
+
+	if proposal.Operation != models.OperationCall && proposal.Operation != models.OperationDelegateCall {
+		return nil, fmt.Errorf("invalid operation: %d", proposal.Operation)
+	}
+
+	safeTx := &SafeTransaction{
+		To:        to,
+		Value:     value,
+		Data:      data,
+		Operation: uint8(proposal.Operation),
+		Nonce:     nonce,
+	}
+	if err := applyProposalGasParams(proposal, safeTx); err != nil {
+		return nil, err
+	}
+
+	return safeTx, nil
+}
+
+// selfCallSafeTx 构建调用Safe自身的管理交易（添加/移除所有者、修改阈值）
+// 管理操作固定使用CALL，gas/退款参数仍取自提案
+func selfCallSafeTx(proposal *models.Proposal, safeAddress common.Address, data []byte, nonce *big.Int) (*SafeTransaction, error) {
+	safeTx := &SafeTransaction{
+		To:        safeAddress,
+		Value:     big.NewInt(0),
+		Data:      data,
+		Operation: models.OperationCall,
+		Nonce:     nonce,
+	}
+	if err := applyProposalGasParams(proposal, safeTx); err != nil {
+		return nil, err
+	}
+
+	return safeTx, nil
+}
+
+// applyProposalGasParams 将提案中的gas与退款参数写入SafeTx
+func applyProposalGasParams(proposal *models.Proposal, safeTx *SafeTransaction) error {
+	var err error
+	if safeTx.SafeTxGas, err = parseUint256(proposal.SafeTxGas); err != nil {
+		return fmt.Errorf("invalid safeTxGas: %s", proposal.SafeTxGas)
+	}
+	if safeTx.BaseGas, err = parseUint256(proposal.BaseGas); err != nil {
+		return fmt.Errorf("invalid baseGas: %s", proposal.BaseGas)
+	}
+	if safeTx.GasPrice, err = parseUint256(proposal.GasPrice); err != nil {
+		return fmt.Errorf("invalid gasPrice: %s", proposal.GasPrice)
+	}
+
+	safeTx.GasToken = common.Address{}
+	if proposal.GasToken != "" {
+		if !common.IsHexAddress(proposal.GasToken) {
+			return fmt.Errorf("invalid gasToken: %s", proposal.GasToken)
+		}
+		safeTx.GasToken = common.HexToAddress(proposal.GasToken)
+	}
+
+	safeTx.RefundReceiver = common.Address{}
+	if proposal.RefundReceiver != "" {
+		if !common.IsHexAddress(proposal.RefundReceiver) {
+			return fmt.Errorf("invalid refundReceiver: %s", proposal.RefundReceiver)
+		}
+		safeTx.RefundReceiver = common.HexToAddress(proposal.RefundReceiver)
+	}
+
+	return nil
+}
+
+// ComputeSafeTxHash 按Safe合约的EIP-712规则计算safeTxHash
+func ComputeSafeTxHash(chainID *big.Int, safeAddress common.Address, safeTx *SafeTransaction) common.Hash {
+	return crypto.Keccak256Hash(EncodeSafeTxData(chainID, safeAddress, safeTx))
+}
+
+// EncodeSafeTxData 返回safeTxHash的原像 0x1901 || domainSeparator || safeTxStructHash
+// 即Safe合约encodeTransactionData的结果，旧版EIP-1271合约签名按此数据校验
+func EncodeSafeTxData(chainID *big.Int, safeAddress common.Address, safeTx *SafeTransaction) []byte {
+	// 1. EIP-712 Domain Separator
+	domainSeparator := crypto.Keccak256Hash(
+		safeDomainTypeHash,
+		common.LeftPadBytes(chainID.Bytes(), 32),
+		common.LeftPadBytes(safeAddress.Bytes(), 32),
+	)
+
+	// 2. SafeTx结构体哈希
+	data := safeTx.Data
+	if data == nil {
+		data = []byte{}
+	}
+	structHash := crypto.Keccak256(
+		safeTxTypeHash,
+		common.LeftPadBytes(safeTx.To.Bytes(), 32),
+		common.LeftPadBytes(bigOrZero(safeTx.Value).Bytes(), 32),
+		crypto.Keccak256(data),
+		common.LeftPadBytes([]byte{safeTx.Operation}, 32),
+		common.LeftPadBytes(bigOrZero(safeTx.SafeTxGas).Bytes(), 32),
+		common.LeftPadBytes(bigOrZero(safeTx.BaseGas).Bytes(), 32),
+		common.LeftPadBytes(bigOrZero(safeTx.GasPrice).Bytes(), 32),
+		common.LeftPadBytes(safeTx.GasToken.Bytes(), 32),
+		common.LeftPadBytes(safeTx.RefundReceiver.Bytes(), 32),
+		common.LeftPadBytes(bigOrZero(safeTx.Nonce).Bytes(), 32),
+	)
+
+	// 3. EIP-712编码
+	encoded := make([]byte, 0, 66)
+	encoded = append(encoded, 0x19, 0x01)
+	encoded = append(encoded, domainSeparator.Bytes()...)
+	return append(encoded, structHash...)
+}
+
+// parseUint256 解析十进制无符号整数字符串，空字符串视为0
+func parseUint256(value string) (*big.Int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return big.NewInt(0), nil
+	}
+
+	parsed, ok := new(big.Int).SetString(value, 10)
+	if !ok || parsed.Sign() < 0 || parsed.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid uint256: %s", value)
+	}
+	return parsed, nil
+}
+
+// bigOrZero 将nil视为0
+func bigOrZero(v *big.Int) *big.Int {
+	if v == nil {
+		return big.NewInt(0)
+	}
+	return v
+}
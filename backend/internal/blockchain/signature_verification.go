@@ -0,0 +1,365 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+// Safe签名类型，与提交签名接口的signature_type一致
+// Safe合约按v值区分：0=合约签名(EIP-1271)，1=approveHash，27/28=EIP-712，31/32=eth_sign(v+4)
+const (
+	SafeSignatureEIP712   = "eth_signTypedData"
+	SafeSignatureEthSign  = "eth_sign"
+	SafeSignatureContract = "contract"
+	// SafeSignatureApprovedHash 所有者已在链上调用approveHash，仅在打包execTransaction时使用，不作为提交的签名类型
+	SafeSignatureApprovedHash = "approved_hash"
+)
+
+// EIP-1271 magic value
+var (
+	eip1271MagicValue       = [4]byte{0x16, 0x26, 0xba, 0x7e} // isValidSignature(bytes32,bytes)
+	eip1271LegacyMagicValue = [4]byte{0x20, 0xc1, 0x3b, 0x0b} // isValidSignature(bytes,bytes)，Safe 1.3.0合约签名校验使用
+)
+
+const eip1271ABIJSON = `[
+	{
+		"inputs": [
+			{"internalType": "bytes32", "name": "hash", "type": "bytes32"},
+			{"internalType": "bytes", "name": "signature", "type": "bytes"}
+		],
+		"name": "isValidSignature",
+		"outputs": [{"internalType": "bytes4", "name": "", "type": "bytes4"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+const eip1271LegacyABIJSON = `[
+	{
+		"inputs": [
+			{"internalType": "bytes", "name": "data", "type": "bytes"},
+			{"internalType": "bytes", "name": "signature", "type": "bytes"}
+		],
+		"name": "isValidSignature",
+		"outputs": [{"internalType": "bytes4", "name": "", "type": "bytes4"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+var (
+	eip1271ABI, _       = abi.JSON(strings.NewReader(eip1271ABIJSON))
+	eip1271LegacyABI, _ = abi.JSON(strings.NewReader(eip1271LegacyABIJSON))
+)
+
+// SignatureVerificationError 签名与提案的SafeTxHash或签名者不匹配
+type SignatureVerificationError struct {
+	Reason     string          `json:"reason"`
+	SafeTxHash string          `json:"safe_tx_hash,omitempty"`
+	Nonce      string          `json:"nonce,omitempty"`
+	Expected   *common.Address `json:"expected_signer,omitempty"`
+	Recovered  *common.Address `json:"recovered_signer,omitempty"`
+}
+
+func (e *SignatureVerificationError) Error() string {
+	if e.Expected != nil && e.Recovered != nil {
+		return fmt.Sprintf("signature verification failed: %s (expected %s, recovered %s)", e.Reason, e.Expected.Hex(), e.Recovered.Hex())
+	}
+	return "signature verification failed: " + e.Reason
+}
+
+// VerifiedSignature 验证通过的签名
+// Signature为Safe合约可直接使用的编码：ECDSA签名v值规范化为27/28(EIP-712)或31/32(eth_sign)，合约签名为原始签名数据
+type VerifiedSignature struct {
+	Signer        common.Address
+	SignatureType string
+	Signature     []byte
+	SafeTxHash    common.Hash
+	Nonce         *big.Int
+}
+
+// VerifyProposalSignature 按签名使用的Safe nonce重建提案的SafeTxHash并验证签名者
+// 提案已分配nonce时签名必须使用该nonce；未分配时nonce为nil表示链上当前nonce，nonce已被消耗的签名无法执行，直接拒绝
+func (se *SafeExecutor) VerifyProposalSignature(ctx context.Context, proposal *models.Proposal, signer common.Address, signatureType string, signature []byte, nonce *big.Int) (*VerifiedSignature, error) {
+	if int64(proposal.Safe.ChainID) != se.chainID.Int64() {
+		return nil, fmt.Errorf("safe %s is on chain %d, executor is connected to chain %s",
+			proposal.Safe.Address, proposal.Safe.ChainID, se.chainID.String())
+	}
+
+	safeAddress := common.HexToAddress(proposal.Safe.Address)
+	safeTx, err := se.buildSafeTransaction(proposal, safeAddress, se.determineProposalType(proposal))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Safe transaction: %w", err)
+	}
+
+	if proposal.Nonce != nil {
+		// 提案已分配Safe nonce，签名必须针对该nonce
+		if nonce != nil && nonce.Cmp(safeTx.Nonce) != 0 {
+			return nil, &SignatureVerificationError{
+				Reason: fmt.Sprintf("signature uses Safe nonce %s, proposal is queued at nonce %s", nonce, safeTx.Nonce),
+				Nonce:  nonce.String(),
+			}
+		}
+		nonce = safeTx.Nonce
+	} else if nonce == nil {
+		nonce = safeTx.Nonce
+	} else if nonce.Cmp(safeTx.Nonce) < 0 {
+		return nil, &SignatureVerificationError{
+			Reason: fmt.Sprintf("Safe nonce %s has already been used, current nonce is %s", nonce, safeTx.Nonce),
+			Nonce:  nonce.String(),
+		}
+	}
+	safeTx.Nonce = nonce
+	safeTxHash := ComputeSafeTxHash(se.chainID, safeAddress, safeTx)
+
+	verified, err := se.verifySafeSignature(ctx, safeAddress, safeTx, safeTxHash, signer, signatureType, signature)
+	if err != nil {
+		if verificationErr, ok := err.(*SignatureVerificationError); ok {
+			verificationErr.SafeTxHash = safeTxHash.Hex()
+			verificationErr.Nonce = nonce.String()
+		}
+		return nil, err
+	}
+	verified.Nonce = nonce
+	return verified, nil
+}
+
+// verifySafeSignature 按签名类型验证单个签名是否由signer针对safeTxHash签署
+func (se *SafeExecutor) verifySafeSignature(ctx context.Context, safeAddress common.Address, safeTx *SafeTransaction, safeTxHash common.Hash, signer common.Address, signatureType string, signature []byte) (*VerifiedSignature, error) {
+	if signatureType == SafeSignatureContract {
+		txData := EncodeSafeTxData(se.chainID, safeAddress, safeTx)
+		if err := verifyContractSignature(ctx, se.client, signer, safeTxHash, txData, signature); err != nil {
+			return nil, err
+		}
+		return &VerifiedSignature{
+			Signer:        signer,
+			SignatureType: SafeSignatureContract,
+			Signature:     signature,
+			SafeTxHash:    safeTxHash,
+		}, nil
+	}
+
+	recovered, detectedType, normalized, err := recoverSafeSignature(safeTxHash, signature, signer)
+	if err != nil {
+		return nil, err
+	}
+	if recovered != signer {
+		expected := signer
+		return nil, &SignatureVerificationError{
+			Reason:    "signature was not produced by the signer's wallet for this Safe transaction",
+			Expected:  &expected,
+			Recovered: &recovered,
+		}
+	}
+
+	return &VerifiedSignature{
+		Signer:        signer,
+		SignatureType: detectedType,
+		Signature:     normalized,
+		SafeTxHash:    safeTxHash,
+	}, nil
+}
+
+// SafeSignatureSigner 解析Safe格式的单个所有者签名，返回签名者、签名类型和可交给VerifyProposalSignature验证的签名
+// 用于只提交签名、不提交签名者地址的场景（如Safe Transaction Service的确认接口）：
+// 合约签名(v=0)的r为签名者地址、s为内层签名的偏移；v=27/28按EIP-712、v=31/32按eth_sign恢复签名者
+func SafeSignatureSigner(safeTxHash common.Hash, signature []byte) (common.Address, string, []byte, error) {
+	if len(signature) < 65 {
+		return common.Address{}, "", nil, &SignatureVerificationError{
+			Reason: fmt.Sprintf("invalid signature length %d, expected at least 65 bytes", len(signature)),
+		}
+	}
+
+	v := signature[64]
+	switch {
+	case v == 0:
+		signer := common.BytesToAddress(signature[:32])
+		offset := new(big.Int).SetBytes(signature[32:64])
+		if !offset.IsUint64() || offset.Uint64() < 65 || offset.Uint64()+32 > uint64(len(signature)) {
+			return common.Address{}, "", nil, &SignatureVerificationError{Reason: "contract signature offset is out of range"}
+		}
+		start := offset.Uint64() + 32
+		length := new(big.Int).SetBytes(signature[start-32 : start])
+		if !length.IsUint64() || start+length.Uint64() > uint64(len(signature)) {
+			return common.Address{}, "", nil, &SignatureVerificationError{Reason: "contract signature length is out of range"}
+		}
+		return signer, SafeSignatureContract, signature[start : start+length.Uint64()], nil
+
+	case v == 1:
+		return common.Address{}, "", nil, &SignatureVerificationError{
+			Reason: "approved hash signatures must be submitted on chain with approveHash",
+		}
+
+	case len(signature) != 65:
+		return common.Address{}, "", nil, &SignatureVerificationError{
+			Reason: fmt.Sprintf("invalid signature length %d, expected 65 bytes", len(signature)),
+		}
+
+	case v == 31 || v == 32:
+		signer, err := ecrecoverAddress(accounts.TextHash(safeTxHash.Bytes()), signature[:64], v-31)
+		if err != nil {
+			return common.Address{}, "", nil, err
+		}
+		return signer, SafeSignatureEthSign, withV(signature, v), nil
+
+	case v == 27 || v == 28:
+		signer, err := ecrecoverAddress(safeTxHash.Bytes(), signature[:64], v-27)
+		if err != nil {
+			return common.Address{}, "", nil, err
+		}
+		return signer, SafeSignatureEIP712, withV(signature, v), nil
+
+	default:
+		return common.Address{}, "", nil, &SignatureVerificationError{
+			Reason: fmt.Sprintf("unsupported signature v value %d", v),
+		}
+	}
+}
+
+// recoverSafeSignature 从65字节ECDSA签名恢复签名者
+// v=31/32 为Safe的eth_sign格式（对"\x19Ethereum Signed Message:\n32"+safeTxHash签名，v+4）；
+// v=27/28（或0/1）既可能是EIP-712签名也可能是钱包personal_sign直接返回的结果，两种摘要都尝试，优先返回与expected匹配的结果
+func recoverSafeSignature(safeTxHash common.Hash, signature []byte, expected common.Address) (common.Address, string, []byte, error) {
+	if len(signature) != 65 {
+		return common.Address{}, "", nil, &SignatureVerificationError{
+			Reason: fmt.Sprintf("invalid signature length %d, expected 65 bytes", len(signature)),
+		}
+	}
+
+	v := signature[64]
+	switch {
+	case v == 31 || v == 32:
+		recovered, err := ecrecoverAddress(accounts.TextHash(safeTxHash.Bytes()), signature[:64], v-31)
+		if err != nil {
+			return common.Address{}, "", nil, err
+		}
+		return recovered, SafeSignatureEthSign, withV(signature, v), nil
+
+	case v == 27 || v == 28 || v == 0 || v == 1:
+		recoveryID := v
+		if v >= 27 {
+			recoveryID = v - 27
+		}
+
+		typedSigner, err := ecrecoverAddress(safeTxHash.Bytes(), signature[:64], recoveryID)
+		if err == nil && typedSigner == expected {
+			return typedSigner, SafeSignatureEIP712, withV(signature, recoveryID+27), nil
+		}
+		ethSigner, ethErr := ecrecoverAddress(accounts.TextHash(safeTxHash.Bytes()), signature[:64], recoveryID)
+		if ethErr == nil && ethSigner == expected {
+			return ethSigner, SafeSignatureEthSign, withV(signature, recoveryID+31), nil
+		}
+		if err != nil {
+			return common.Address{}, "", nil, err
+		}
+		return typedSigner, SafeSignatureEIP712, withV(signature, recoveryID+27), nil
+
+	default:
+		return common.Address{}, "", nil, &SignatureVerificationError{
+			Reason: fmt.Sprintf("unsupported signature v value %d", v),
+		}
+	}
+}
+
+func ecrecoverAddress(digest []byte, rs []byte, recoveryID byte) (common.Address, error) {
+	sig := make([]byte, 65)
+	copy(sig, rs)
+	sig[64] = recoveryID
+
+	pubKey, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return common.Address{}, &SignatureVerificationError{Reason: fmt.Sprintf("failed to recover signer: %v", err)}
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+func withV(signature []byte, v byte) []byte {
+	normalized := make([]byte, 65)
+	copy(normalized, signature)
+	normalized[64] = v
+	return normalized
+}
+
+// verifyContractSignature 通过RPC调用合约签名者的isValidSignature验证EIP-1271签名
+// 先按EIP-1271标准(bytes32)校验safeTxHash，失败时按Safe 1.3.0使用的旧接口(bytes)校验交易编码数据
+func verifyContractSignature(ctx context.Context, client *ethclient.Client, signer common.Address, safeTxHash common.Hash, txData []byte, signature []byte) error {
+	code, err := client.CodeAt(ctx, signer, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get code of %s: %w", signer.Hex(), err)
+	}
+	if len(code) == 0 {
+		return &SignatureVerificationError{Reason: fmt.Sprintf("contract signer %s has no code on chain", signer.Hex())}
+	}
+
+	if callData, err := eip1271ABI.Pack("isValidSignature", safeTxHash, signature); err == nil {
+		if ok, _ := callIsValidSignature(ctx, client, signer, callData, eip1271MagicValue); ok {
+			return nil
+		}
+	}
+	if callData, err := eip1271LegacyABI.Pack("isValidSignature", txData, signature); err == nil {
+		if ok, _ := callIsValidSignature(ctx, client, signer, callData, eip1271LegacyMagicValue); ok {
+			return nil
+		}
+	}
+
+	return &SignatureVerificationError{
+		Reason: fmt.Sprintf("contract signer %s rejected the signature (isValidSignature)", signer.Hex()),
+	}
+}
+
+func callIsValidSignature(ctx context.Context, caller ethereum.ContractCaller, signer common.Address, callData []byte, magic [4]byte) (bool, error) {
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &signer, Data: callData}, nil)
+	if err != nil {
+		return false, err
+	}
+	return len(result) >= 4 && bytes.Equal(result[:4], magic[:]), nil
+}
+
+// safeSignaturePart 待合并的单个所有者签名
+type safeSignaturePart struct {
+	Signer    common.Address
+	Type      string
+	Signature []byte
+}
+
+// encodeSafeSignatures 按签名者地址升序合并签名（Safe合约要求）
+// 合约签名的静态部分为 r=签名者地址, s=动态数据偏移, v=0，签名数据以 长度||数据 追加在所有静态部分之后
+func encodeSafeSignatures(parts []safeSignaturePart) []byte {
+	sort.Slice(parts, func(i, j int) bool {
+		return bytes.Compare(parts[i].Signer.Bytes(), parts[j].Signer.Bytes()) < 0
+	})
+
+	static := make([]byte, 0, 65*len(parts))
+	var dynamic []byte
+	staticLength := 65 * len(parts)
+
+	for _, part := range parts {
+		if part.Type != SafeSignatureContract {
+			static = append(static, part.Signature...)
+			continue
+		}
+
+		offset := big.NewInt(int64(staticLength + len(dynamic)))
+		static = append(static, common.LeftPadBytes(part.Signer.Bytes(), 32)...)
+		static = append(static, common.LeftPadBytes(offset.Bytes(), 32)...)
+		static = append(static, 0)
+
+		dynamic = append(dynamic, common.LeftPadBytes(big.NewInt(int64(len(part.Signature))).Bytes(), 32)...)
+		dynamic = append(dynamic, part.Signature...)
+	}
+
+	return append(static, dynamic...)
+}
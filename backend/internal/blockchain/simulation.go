@@ -0,0 +1,311 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/google/uuid"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+// 模拟方式
+const (
+	// SimulationModeExecTransaction 签名已达阈值，完整模拟execTransaction（含签名校验）
+	SimulationModeExecTransaction = "exec_transaction"
+	// SimulationModeInnerCall 签名未达阈值，通过simulateAndRevert只模拟Safe内部调用
+	SimulationModeInnerCall = "inner_call"
+)
+
+// safeErrorCodes Safe v1.3.0合约的GSxxx错误码说明
+var safeErrorCodes = map[string]string{
+	"GS000": "Could not finish initialization",
+	"GS001": "Threshold needs to be defined",
+	"GS010": "Not enough gas to execute Safe transaction",
+	"GS011": "Could not pay gas costs with ether",
+	"GS012": "Could not pay gas costs with token",
+	"GS013": "Safe transaction failed when gasPrice and safeTxGas were 0",
+	"GS020": "Signatures data too short",
+	"GS021": "Invalid contract signature location: inside static part",
+	"GS022": "Invalid contract signature location: length not present",
+	"GS023": "Invalid contract signature location: data not complete",
+	"GS024": "Invalid contract signature provided",
+	"GS025": "Hash has not been approved",
+	"GS026": "Invalid owner provided",
+	"GS030": "Only owners can approve a hash",
+	"GS031": "Method can only be called from this contract",
+	"GS100": "Modules have already been initialized",
+	"GS101": "Invalid module address provided",
+	"GS102": "Module has already been added",
+	"GS103": "Invalid prevModule, module pair provided",
+	"GS104": "Method can only be called from an enabled module",
+	"GS200": "Owners have already been setup",
+	"GS201": "Threshold cannot exceed owner count",
+	"GS202": "Threshold needs to be greater than 0",
+	"GS203": "Invalid owner address provided",
+	"GS204": "Address is already an owner",
+	"GS205": "Invalid prevOwner, owner pair provided",
+	"GS300": "Guard does not implement IERC165",
+}
+
+var safeErrorCodePattern = regexp.MustCompile(`GS\d{3}`)
+
+// safeSimulationABI StorageAccessible.simulateAndRevert 与 SimulateTxAccessor.simulate
+const safeSimulationABI = `[
+	{"name":"simulateAndRevert","type":"function","stateMutability":"nonpayable",
+	 "inputs":[{"name":"targetContract","type":"address"},{"name":"calldataPayload","type":"bytes"}],"outputs":[]},
+	{"name":"simulate","type":"function","stateMutability":"nonpayable",
+	 "inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},{"name":"operation","type":"uint8"}],
+	 "outputs":[{"name":"estimate","type":"uint256"},{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}
+]`
+
+// SimulationResult execTransaction模拟结果
+type SimulationResult struct {
+	Mode               string `json:"mode"`
+	Success            bool   `json:"success"`
+	GasUsed            uint64 `json:"gas_used"`
+	SafeNonce          string `json:"safe_nonce"`
+	SafeTxHash         string `json:"safe_tx_hash"`
+	RevertCode         string `json:"revert_code,omitempty"`        // Safe错误码，如GS013
+	RevertReason       string `json:"revert_reason,omitempty"`      // 解码后的revert原因
+	RevertDescription  string `json:"revert_description,omitempty"` // 错误码说明
+	ReturnData         string `json:"return_data,omitempty"`
+	SignaturesIncluded int    `json:"signatures_included"`
+	SignaturesRequired int    `json:"signatures_required"`
+}
+
+// Summary 单行描述，用于日志和错误信息
+func (r *SimulationResult) Summary() string {
+	if r.Success {
+		return fmt.Sprintf("success (gas %d)", r.GasUsed)
+	}
+	if r.RevertDescription != "" {
+		return fmt.Sprintf("%s: %s", r.RevertReason, r.RevertDescription)
+	}
+	if r.RevertReason != "" {
+		return r.RevertReason
+	}
+	return "reverted without reason"
+}
+
+// SimulationError 模拟失败时拒绝执行
+type SimulationError struct {
+	ProposalID uuid.UUID
+	Result     *SimulationResult
+}
+
+func (e *SimulationError) Error() string {
+	return fmt.Sprintf("execTransaction simulation failed for proposal %s: %s", e.ProposalID, e.Result.Summary())
+}
+
+// SimulateProposal 在pending区块上模拟提案的execTransaction，不发送交易
+// 签名达到阈值时完整模拟execTransaction；否则通过simulateAndRevert模拟Safe内部调用，供签名前检查
+func (se *SafeExecutor) SimulateProposal(ctx context.Context, proposalID uuid.UUID) (*SimulationResult, error) {
+	var proposal models.Proposal
+	if err := se.db.Preload("Safe").First(&proposal, proposalID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get proposal: %w", err)
+	}
+	if int64(proposal.Safe.ChainID) != se.chainID.Int64() {
+		return nil, fmt.Errorf("safe %s is on chain %d, executor is connected to chain %s",
+			proposal.Safe.Address, proposal.Safe.ChainID, se.chainID.String())
+	}
+
+	safeAddress := common.HexToAddress(proposal.Safe.Address)
+	safeTx, err := se.buildSafeTransaction(&proposal, safeAddress, se.determineProposalType(&proposal))
+	if err != nil {
+		return nil, err
+	}
+	safeTxHash := se.buildSafeTxHash(safeAddress, safeTx)
+
+	threshold := proposal.Safe.Threshold
+	if threshold <= 0 {
+		threshold = proposal.RequiredSignatures
+	}
+
+	// 签名不足或验证失败时included为0，退回内部调用模拟
+	signatures, included, _ := se.collectProposalSignatures(proposalID, safeAddress, safeTx)
+
+	// 提案排在其他Safe交易之后时，签名对应的nonce尚未轮到，execTransaction必然失败，只模拟内部调用
+	nonceReady := true
+	if proposal.Nonce != nil {
+		if chainNonce, err := se.getSafeNonce(safeAddress); err == nil {
+			nonceReady = checkProposalNonce(*proposal.Nonce, chainNonce) == nil
+		}
+	}
+
+	var result *SimulationResult
+	if included >= threshold && nonceReady {
+		execData, err := packExecTransaction(safeTx, signatures)
+		if err != nil {
+			return nil, err
+		}
+		result, err = se.simulateExecTransaction(ctx, se.fromAddress(), safeAddress, execData)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		result, err = se.simulateInnerCall(ctx, safeAddress, safeTx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.SafeNonce = safeTx.Nonce.String()
+	result.SafeTxHash = safeTxHash.Hex()
+	result.SignaturesIncluded = included
+	result.SignaturesRequired = threshold
+	return result, nil
+}
+
+// simulateExecTransaction 以eth_call在pending区块上调用execTransaction
+func (se *SafeExecutor) simulateExecTransaction(ctx context.Context, from, safeAddress common.Address, execData []byte) (*SimulationResult, error) {
+	msg := ethereum.CallMsg{From: from, To: &safeAddress, Data: execData}
+	result := &SimulationResult{Mode: SimulationModeExecTransaction}
+
+	output, err := se.client.PendingCallContract(ctx, msg)
+	if err != nil {
+		revertData, reason, reverted := decodeCallRevert(err)
+		if !reverted {
+			return nil, fmt.Errorf("failed to simulate execTransaction: %w", err)
+		}
+		result.setRevert(revertData, reason)
+		return result, nil
+	}
+
+	// safeTxGas或gasPrice非0时内部调用失败不会revert，execTransaction返回false并发出ExecutionFailure
+	out, err := getSafeABI().Unpack("execTransaction", output)
+	if err != nil || len(out) == 0 {
+		return nil, fmt.Errorf("invalid execTransaction simulation result: %x", output)
+	}
+	if success, _ := out[0].(bool); !success {
+		result.RevertReason = "inner transaction failed (ExecutionFailure)"
+		return result, nil
+	}
+
+	result.Success = true
+	if gas, err := se.client.EstimateGas(ctx, msg); err == nil {
+		result.GasUsed = gas
+	}
+	return result, nil
+}
+
+// simulateInnerCall 通过Safe的simulateAndRevert委托调用SimulateTxAccessor.simulate，
+// 在Safe上下文中执行to/value/data/operation，不校验签名
+func (se *SafeExecutor) simulateInnerCall(ctx context.Context, safeAddress common.Address, safeTx *SafeTransaction) (*SimulationResult, error) {
+	simulationABI, err := abi.JSON(strings.NewReader(safeSimulationABI))
+	if err != nil {
+		return nil, err
+	}
+	payload, err := simulationABI.Pack("simulate", safeTx.To, safeTx.Value, safeTx.Data, safeTx.Operation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack simulate: %w", err)
+	}
+	callData, err := simulationABI.Pack("simulateAndRevert", se.simulateTxAccessor, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack simulateAndRevert: %w", err)
+	}
+
+	_, err = se.client.PendingCallContract(ctx, ethereum.CallMsg{To: &safeAddress, Data: callData})
+	if err == nil {
+		return nil, fmt.Errorf("safe %s does not support simulateAndRevert", safeAddress.Hex())
+	}
+	revertData, _, reverted := decodeCallRevert(err)
+	if !reverted {
+		return nil, fmt.Errorf("failed to simulate safe transaction: %w", err)
+	}
+
+	// revert数据: success(32字节) | returndatasize(32字节) | SimulateTxAccessor.simulate的返回值
+	if len(revertData) < 64 || new(big.Int).SetBytes(revertData[:32]).Sign() == 0 {
+		return nil, fmt.Errorf("simulateAndRevert via accessor %s failed: %x", se.simulateTxAccessor.Hex(), revertData)
+	}
+	size := new(big.Int).SetBytes(revertData[32:64])
+	if !size.IsUint64() || uint64(len(revertData)-64) < size.Uint64() {
+		return nil, fmt.Errorf("invalid simulateAndRevert result: %x", revertData)
+	}
+
+	out, err := simulationABI.Unpack("simulate", revertData[64:64+size.Uint64()])
+	if err != nil || len(out) != 3 {
+		return nil, fmt.Errorf("invalid simulate result: %x", revertData)
+	}
+	estimate, _ := out[0].(*big.Int)
+	success, _ := out[1].(bool)
+	returnData, _ := out[2].([]byte)
+
+	result := &SimulationResult{Mode: SimulationModeInnerCall, Success: success}
+	if estimate != nil {
+		result.GasUsed = estimate.Uint64()
+	}
+	if !success {
+		reason, _ := abi.UnpackRevert(returnData)
+		result.setRevert(returnData, reason)
+	} else if len(returnData) > 0 {
+		result.ReturnData = common.Bytes2Hex(returnData)
+	}
+	return result, nil
+}
+
+// setRevert 记录revert原因并识别Safe错误码
+func (r *SimulationResult) setRevert(data []byte, reason string) {
+	r.Success = false
+	r.RevertReason = reason
+	if len(data) > 0 {
+		r.ReturnData = common.Bytes2Hex(data)
+	}
+	if code := safeErrorCodePattern.FindString(reason); code != "" {
+		r.RevertCode = code
+		r.RevertDescription = safeErrorCodes[code]
+	}
+}
+
+// decodeCallRevert 从eth_call错误中取出revert数据和原因
+// 返回reverted=false表示不是合约revert（网络或节点错误）
+func decodeCallRevert(err error) (data []byte, reason string, reverted bool) {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if hexData, ok := dataErr.ErrorData().(string); ok {
+			data = common.FromHex(hexData)
+		}
+	}
+
+	message := err.Error()
+	if len(data) == 0 && !strings.Contains(message, "revert") {
+		return nil, "", false
+	}
+
+	if decoded, unpackErr := abi.UnpackRevert(data); unpackErr == nil {
+		reason = decoded
+	} else {
+		reason = strings.TrimSpace(strings.TrimPrefix(message, "execution reverted:"))
+	}
+	return data, reason, true
+}
+
+// packExecTransaction 编码execTransaction调用数据
+func packExecTransaction(safeTx *SafeTransaction, signatures []byte) ([]byte, error) {
+	execData, err := getSafeABI().Pack(
+		"execTransaction",
+		safeTx.To, safeTx.Value, safeTx.Data, safeTx.Operation,
+		safeTx.SafeTxGas, safeTx.BaseGas, safeTx.GasPrice,
+		safeTx.GasToken, safeTx.RefundReceiver, signatures,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack execTransaction: %v", err)
+	}
+	return execData, nil
+}
+
+// fromAddress 模拟调用的发送方，未配置签名器时使用零地址
+func (se *SafeExecutor) fromAddress() common.Address {
+	if se.signer == nil {
+		return common.Address{}
+	}
+	return se.signer.Address()
+}
@@ -0,0 +1,267 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+// 替换交易的费用涨幅（千分比）：节点交易池要求同nonce替换交易至少提高10%，这里使用12.5%
+const replacementFeeBumpPermille = 1125
+
+// cancelTxGasLimit 0值自转账的gas
+const cancelTxGasLimit = 21000
+
+// ErrExecutionTxNotPending 执行交易已上链（或执行账户nonce已被占用），不能再加速或取消
+var ErrExecutionTxNotPending = errors.New("execution transaction is no longer pending")
+
+// ErrExecutionTxNotTracked 提案没有可替换的执行交易记录
+var ErrExecutionTxNotTracked = errors.New("no pending execution transaction tracked for proposal")
+
+// recordExecutionTx 记录广播的执行交易，供监控器跟踪和后续加速/取消
+func recordExecutionTx(db *gorm.DB, proposalID uuid.UUID, chainID int64, from common.Address, tx *types.Transaction, params *ExecutionTxParams, action string, replaces *string, createdBy *uuid.UUID) (*models.ProposalExecutionTx, error) {
+	record := &models.ProposalExecutionTx{
+		ProposalID:     proposalID,
+		ChainID:        chainID,
+		TxHash:         tx.Hash().Hex(),
+		FromAddress:    from.Hex(),
+		Nonce:          int64(tx.Nonce()),
+		Action:         action,
+		ReplacesTxHash: replaces,
+		TxType:         int(params.TxType),
+		GasLimit:       int64(params.GasLimit),
+		Status:         models.ExecutionTxStatusPending,
+		CreatedBy:      createdBy,
+	}
+	if params.MaxFeePerGas != nil {
+		v := params.MaxFeePerGas.String()
+		record.MaxFeePerGas = &v
+	}
+	if params.MaxPriorityFeePerGas != nil {
+		v := params.MaxPriorityFeePerGas.String()
+		record.MaxPriorityFeePerGas = &v
+	}
+	if params.GasPrice != nil {
+		v := params.GasPrice.String()
+		record.GasPrice = &v
+	}
+
+	if err := db.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to record execution transaction: %w", err)
+	}
+	return record, nil
+}
+
+// SpeedUpExecution 以更高费用重发同nonce的执行交易
+func (se *SafeExecutor) SpeedUpExecution(ctx context.Context, proposalID, userID uuid.UUID) (*models.ProposalExecutionTx, error) {
+	return se.replaceExecutionTx(ctx, proposalID, userID, models.ExecutionTxActionSpeedUp)
+}
+
+// CancelExecution 以更高费用发送同nonce的0值自转账，使执行交易失效
+// 取消交易上链后Safe nonce未被消耗，提案回到approved状态可重新执行
+func (se *SafeExecutor) CancelExecution(ctx context.Context, proposalID, userID uuid.UUID) (*models.ProposalExecutionTx, error) {
+	return se.replaceExecutionTx(ctx, proposalID, userID, models.ExecutionTxActionCancel)
+}
+
+// replaceExecutionTx 构建并广播同nonce的替换交易
+func (se *SafeExecutor) replaceExecutionTx(ctx context.Context, proposalID, userID uuid.UUID, action string) (*models.ProposalExecutionTx, error) {
+	var proposal models.Proposal
+	if err := se.db.Preload("Safe").First(&proposal, proposalID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get proposal: %w", err)
+	}
+	if proposal.Status != "executed" {
+		return nil, fmt.Errorf("%w: proposal status is %s", ErrExecutionTxNotPending, proposal.Status)
+	}
+	if int64(proposal.Safe.ChainID) != se.chainID.Int64() {
+		return nil, fmt.Errorf("safe %s is on chain %d, executor is connected to chain %s",
+			proposal.Safe.Address, proposal.Safe.ChainID, se.chainID.String())
+	}
+
+	// 同nonce下费用最高的交易（即最近一次广播的交易）
+	var latest models.ProposalExecutionTx
+	if err := se.db.Where("proposal_id = ? AND status = ?", proposalID, models.ExecutionTxStatusPending).
+		Order("created_at DESC").First(&latest).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExecutionTxNotTracked
+		}
+		return nil, fmt.Errorf("failed to load execution transactions: %w", err)
+	}
+
+	if action == models.ExecutionTxActionSpeedUp && latest.Action == models.ExecutionTxActionCancel {
+		return nil, fmt.Errorf("execution of proposal %s is being cancelled, speed up the cancellation instead", proposalID)
+	}
+
+	from := se.signer.Address()
+	if !strings.EqualFold(latest.FromAddress, from.Hex()) {
+		return nil, fmt.Errorf("execution transaction was sent by %s, current executor is %s", latest.FromAddress, from.Hex())
+	}
+
+	// 执行账户的已确认nonce超过交易nonce，说明同nonce的某笔交易已上链
+	confirmedNonce, err := se.client.NonceAt(ctx, from, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executor nonce: %w", err)
+	}
+	if confirmedNonce > uint64(latest.Nonce) {
+		return nil, ErrExecutionTxNotPending
+	}
+
+	// nonce被丢弃后可能已重新分配给其他提案或被自转账填补，此时不能再替换
+	owned, err := se.nonces.OwnedBy(ctx, se.chainID.Int64(), from, uint64(latest.Nonce), proposalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check nonce assignment: %w", err)
+	}
+	if !owned {
+		return nil, fmt.Errorf("%w: executor nonce %d has been reassigned", ErrExecutionTxNotPending, latest.Nonce)
+	}
+
+	params, err := se.bumpedFees(ctx, &latest)
+	if err != nil {
+		return nil, err
+	}
+
+	var to common.Address
+	var data []byte
+	if action == models.ExecutionTxActionCancel {
+		to = from
+		params.GasLimit = cancelTxGasLimit
+		params.GasEstimate = cancelTxGasLimit
+	} else {
+		original, err := se.findBroadcastExecutionTx(ctx, proposalID)
+		if err != nil {
+			return nil, err
+		}
+		to = *original.To()
+		data = original.Data()
+		params.GasLimit = original.Gas()
+		if proposal.TxGasEstimate != nil {
+			params.GasEstimate = uint64(*proposal.TxGasEstimate)
+		}
+	}
+
+	tx := params.buildTransaction(se.chainID, uint64(latest.Nonce), to, data)
+	signedTx, err := se.signer.SignTx(ctx, tx, se.chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign replacement transaction: %w", err)
+	}
+	if err := se.client.SendTransaction(ctx, signedTx); err != nil {
+		return nil, fmt.Errorf("failed to send replacement transaction: %w", err)
+	}
+
+	txHash := signedTx.Hash().Hex()
+	if err := se.nonces.MarkBroadcast(ctx, se.chainID.Int64(), from, uint64(latest.Nonce), txHash); err != nil {
+		log.Printf("⚠️ 记录nonce %d 广播状态失败: %v", latest.Nonce, err)
+	}
+	log.Printf("🔁 提案 %s 的执行交易已替换 (%s): %s -> %s, nonce=%d", proposalID, action, latest.TxHash, txHash, latest.Nonce)
+
+	var createdBy *uuid.UUID
+	if userID != uuid.Nil {
+		createdBy = &userID
+	}
+	record, err := recordExecutionTx(se.db, proposalID, se.chainID.Int64(), from, signedTx, params, action, &latest.TxHash, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// 加速交易替代原执行交易，提案上的交易哈希和费用参数随之更新；取消交易不改变提案的执行记录
+	if action == models.ExecutionTxActionSpeedUp {
+		updates := params.proposalUpdates()
+		updates["tx_hash"] = txHash
+		if err := se.db.Model(&models.Proposal{}).Where("id = ?", proposalID).Updates(updates).Error; err != nil {
+			log.Printf("⚠️ 更新提案 %s 的交易哈希失败: %v", proposalID, err)
+		}
+	}
+
+	return record, nil
+}
+
+// bumpedFees 在当前建议费用和上一笔交易费用的112.5%之间取较高者，受配置的费用上限约束
+func (se *SafeExecutor) bumpedFees(ctx context.Context, latest *models.ProposalExecutionTx) (*ExecutionTxParams, error) {
+	if latest.TxType == types.DynamicFeeTxType {
+		current, err := suggestFees(ctx, se.client, se.fees)
+		if err != nil {
+			return nil, err
+		}
+		if current.TxType != types.DynamicFeeTxType {
+			return nil, fmt.Errorf("chain %s no longer reports a base fee", se.chainID)
+		}
+
+		maxFee := maxBig(current.MaxFeePerGas, bumpFee(parseWei(latest.MaxFeePerGas)))
+		tip := maxBig(current.MaxPriorityFeePerGas, bumpFee(parseWei(latest.MaxPriorityFeePerGas)))
+		if tip.Cmp(maxFee) > 0 {
+			maxFee = new(big.Int).Set(tip)
+		}
+		if se.fees.MaxFeePerGas != nil && maxFee.Cmp(se.fees.MaxFeePerGas) > 0 {
+			return nil, fmt.Errorf("replacement requires max fee %s wei, above configured cap %s wei", maxFee, se.fees.MaxFeePerGas)
+		}
+
+		return &ExecutionTxParams{
+			TxType:               types.DynamicFeeTxType,
+			MaxFeePerGas:         maxFee,
+			MaxPriorityFeePerGas: tip,
+			BaseFee:              current.BaseFee,
+		}, nil
+	}
+
+	suggested, err := se.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	gasPrice := maxBig(suggested, bumpFee(parseWei(latest.GasPrice)))
+	if se.fees.MaxFeePerGas != nil && gasPrice.Cmp(se.fees.MaxFeePerGas) > 0 {
+		return nil, fmt.Errorf("replacement requires gas price %s wei, above configured cap %s wei", gasPrice, se.fees.MaxFeePerGas)
+	}
+	return &ExecutionTxParams{TxType: types.LegacyTxType, GasPrice: gasPrice}, nil
+}
+
+// findBroadcastExecutionTx 从节点取回提案最近一笔执行（非取消）交易，用于以相同calldata重发
+func (se *SafeExecutor) findBroadcastExecutionTx(ctx context.Context, proposalID uuid.UUID) (*types.Transaction, error) {
+	var records []models.ProposalExecutionTx
+	if err := se.db.Where("proposal_id = ? AND action <> ?", proposalID, models.ExecutionTxActionCancel).
+		Order("created_at DESC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load execution transactions: %w", err)
+	}
+
+	for _, record := range records {
+		tx, _, err := se.client.TransactionByHash(ctx, common.HexToHash(record.TxHash))
+		if err == nil && tx.To() != nil {
+			return tx, nil
+		}
+	}
+	return nil, fmt.Errorf("execution transaction for proposal %s was dropped by the node, cancel and re-execute instead", proposalID)
+}
+
+// bumpFee 按replacementFeeBumpPermille提高费用（向上取整）
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(replacementFeeBumpPermille))
+	bumped.Add(bumped, big.NewInt(999))
+	return bumped.Div(bumped, big.NewInt(1000))
+}
+
+// parseWei 解析数据库中的wei金额，空值视为0
+func parseWei(value *string) *big.Int {
+	if value == nil {
+		return new(big.Int)
+	}
+	wei, ok := new(big.Int).SetString(*value, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return wei
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return new(big.Int).Set(a)
+	}
+	return new(big.Int).Set(b)
+}
@@ -0,0 +1,283 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrSignerNotConfigured 未配置执行账户签名器
+var ErrSignerNotConfigured = errors.New("executor signer not configured")
+
+// 签名器类型（EXECUTOR_SIGNER）
+const (
+	SignerTypeKeystore   = "keystore"
+	SignerTypeRemote     = "remote"
+	SignerTypePrivateKey = "private_key"
+)
+
+// TxSigner 执行账户的交易签名器
+// SafeExecutor只依赖该接口，私钥可以保存在加密keystore或外部签名服务中
+type TxSigner interface {
+	// Address 执行账户地址（支付gas的发送方）
+	Address() common.Address
+	// SignTx 为指定链签名交易，支持legacy和EIP-1559交易
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// LocalKeySigner 使用内存中的私钥签名
+// 由加密keystore解密得到，或仅用于开发环境的明文PRIVATE_KEY
+type LocalKeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewLocalKeySigner 使用私钥创建签名器
+func NewLocalKeySigner(key *ecdsa.PrivateKey) *LocalKeySigner {
+	return &LocalKeySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+// NewKeystoreSigner 解密Web3 Secret Storage格式的keystore文件（geth/Clef导出的UTC--*文件）
+func NewKeystoreSigner(path, password string) (*LocalKeySigner, error) {
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore %s: %w", path, err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore %s: %w", path, err)
+	}
+	return NewLocalKeySigner(key.PrivateKey), nil
+}
+
+// Address 实现TxSigner
+func (s *LocalKeySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx 实现TxSigner
+func (s *LocalKeySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// RemoteSigner 通过JSON-RPC的eth_signTransaction签名（Clef、Web3Signer等）
+// 私钥不离开签名服务，返回的已签名交易会校验发送方和交易内容
+type RemoteSigner struct {
+	client  *rpc.Client
+	url     string
+	address common.Address
+	timeout time.Duration
+}
+
+// NewRemoteSigner 连接远程签名服务
+// address为空时通过eth_accounts读取，签名服务只能管理一个账户
+func NewRemoteSigner(ctx context.Context, url string, address string) (*RemoteSigner, error) {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to remote signer %s: %w", url, err)
+	}
+
+	signer := &RemoteSigner{client: client, url: url, timeout: 30 * time.Second}
+
+	if address != "" {
+		if !common.IsHexAddress(address) {
+			client.Close()
+			return nil, fmt.Errorf("invalid signer address: %s", address)
+		}
+		signer.address = common.HexToAddress(address)
+		return signer, nil
+	}
+
+	var accounts []common.Address
+	if err := client.CallContext(ctx, &accounts, "eth_accounts"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to list remote signer accounts: %w", err)
+	}
+	if len(accounts) != 1 {
+		client.Close()
+		return nil, fmt.Errorf("remote signer manages %d accounts, set EXECUTOR_SIGNER_ADDRESS to choose one", len(accounts))
+	}
+	signer.address = accounts[0]
+	return signer, nil
+}
+
+// Address 实现TxSigner
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// Close 关闭与签名服务的连接
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+// remoteSignArgs eth_signTransaction参数
+type remoteSignArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+}
+
+// SignTx 实现TxSigner
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := remoteSignArgs{
+		From:    s.address,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   (*hexutil.Big)(tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	} else {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var result json.RawMessage
+	if err := s.client.CallContext(ctx, &result, "eth_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("remote signer %s rejected transaction: %w", s.url, err)
+	}
+
+	raw, err := decodeSignTransactionResult(result)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("remote signer returned invalid transaction: %w", err)
+	}
+
+	// 不信任签名服务返回的内容：校验发送方和交易字段与请求一致
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover remote signed transaction sender: %w", err)
+	}
+	if sender != s.address {
+		return nil, fmt.Errorf("remote signer signed with %s, expected %s", sender.Hex(), s.address.Hex())
+	}
+	if !sameUnsignedTx(tx, signed) {
+		return nil, fmt.Errorf("remote signer returned a transaction that differs from the request")
+	}
+
+	return signed, nil
+}
+
+// decodeSignTransactionResult 兼容两种返回格式：
+// Clef/geth返回 {"raw": "0x...", "tx": {...}}，Web3Signer返回RLP编码的十六进制字符串
+func decodeSignTransactionResult(result json.RawMessage) ([]byte, error) {
+	var rawHex string
+	if err := json.Unmarshal(result, &rawHex); err != nil {
+		var envelope struct {
+			Raw string `json:"raw"`
+		}
+		if err := json.Unmarshal(result, &envelope); err != nil || envelope.Raw == "" {
+			return nil, fmt.Errorf("unexpected eth_signTransaction result: %s", string(result))
+		}
+		rawHex = envelope.Raw
+	}
+
+	raw, err := hexutil.Decode(rawHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid eth_signTransaction result: %w", err)
+	}
+	return raw, nil
+}
+
+// sameUnsignedTx 比较两笔交易除签名外的字段
+func sameUnsignedTx(a, b *types.Transaction) bool {
+	if a.Type() != b.Type() || a.Nonce() != b.Nonce() || a.Gas() != b.Gas() ||
+		a.Value().Cmp(b.Value()) != 0 || string(a.Data()) != string(b.Data()) {
+		return false
+	}
+	if (a.To() == nil) != (b.To() == nil) || (a.To() != nil && *a.To() != *b.To()) {
+		return false
+	}
+	return a.GasFeeCap().Cmp(b.GasFeeCap()) == 0 && a.GasTipCap().Cmp(b.GasTipCap()) == 0
+}
+
+// LoadTxSignerFromEnv 根据环境变量创建执行账户签名器
+//
+//	EXECUTOR_SIGNER=keystore    EXECUTOR_KEYSTORE_PATH, EXECUTOR_KEYSTORE_PASSWORD 或 EXECUTOR_KEYSTORE_PASSWORD_FILE
+//	EXECUTOR_SIGNER=remote      EXECUTOR_SIGNER_URL, 可选 EXECUTOR_SIGNER_ADDRESS
+//	EXECUTOR_SIGNER=private_key PRIVATE_KEY（明文私钥，仅用于本地开发）
+//
+// 未设置EXECUTOR_SIGNER时，存在PRIVATE_KEY则按private_key处理以兼容旧配置
+func LoadTxSignerFromEnv(ctx context.Context) (TxSigner, error) {
+	signerType := strings.ToLower(os.Getenv("EXECUTOR_SIGNER"))
+	if signerType == "" && os.Getenv("PRIVATE_KEY") != "" {
+		signerType = SignerTypePrivateKey
+	}
+
+	switch signerType {
+	case SignerTypeKeystore:
+		path := os.Getenv("EXECUTOR_KEYSTORE_PATH")
+		if path == "" {
+			return nil, fmt.Errorf("%w: EXECUTOR_KEYSTORE_PATH is required for keystore signer", ErrSignerNotConfigured)
+		}
+		password := os.Getenv("EXECUTOR_KEYSTORE_PASSWORD")
+		if passwordFile := os.Getenv("EXECUTOR_KEYSTORE_PASSWORD_FILE"); passwordFile != "" {
+			content, err := os.ReadFile(passwordFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read keystore password file: %w", err)
+			}
+			password = strings.TrimRight(string(content), "\r\n")
+		}
+		return NewKeystoreSigner(path, password)
+
+	case SignerTypeRemote:
+		url := os.Getenv("EXECUTOR_SIGNER_URL")
+		if url == "" {
+			return nil, fmt.Errorf("%w: EXECUTOR_SIGNER_URL is required for remote signer", ErrSignerNotConfigured)
+		}
+		return NewRemoteSigner(ctx, url, os.Getenv("EXECUTOR_SIGNER_ADDRESS"))
+
+	case SignerTypePrivateKey:
+		privateKey := os.Getenv("PRIVATE_KEY")
+		if privateKey == "" {
+			return nil, fmt.Errorf("%w: PRIVATE_KEY is required for private_key signer", ErrSignerNotConfigured)
+		}
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		log.Printf("⚠️ 执行账户使用明文PRIVATE_KEY签名，生产环境请改用 EXECUTOR_SIGNER=keystore 或 remote")
+		return NewLocalKeySigner(key), nil
+
+	case "":
+		return nil, ErrSignerNotConfigured
+
+	default:
+		return nil, fmt.Errorf("unknown EXECUTOR_SIGNER: %s", signerType)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"gorm.io/gorm"
 
 	"web3-enterprise-multisig/internal/auth"
+	"web3-enterprise-multisig/internal/blockchain"
 	"web3-enterprise-multisig/internal/database"
 	"web3-enterprise-multisig/internal/models"
 	"web3-enterprise-multisig/internal/services"
@@ -353,6 +354,49 @@ func RefreshToken(c *gin.Context) {
 	})
 }
 
+// GetSiweChallenge 签发SIWE(EIP-4361)登录挑战
+// 客户端需使用钱包对返回的message签名，再调用钱包登录/注册接口
+func GetSiweChallenge(c *gin.Context) {
+	var req validators.SiweChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	if err := validators.ValidateStruct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation failed",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	siweService := newSiweService()
+	challenge, err := siweService.IssueChallenge(c.Request.Context(), req.WalletAddress, req.ChainID)
+	if err != nil {
+		if errors.Is(err, services.ErrSiweChainNotAllowed) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Unsupported chain",
+				"code":    "UNSUPPORTED_CHAIN",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to issue SIWE challenge",
+			"code":    "SIWE_CHALLENGE_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, challenge)
+}
+
 // WalletRegister 钱包签名注册
 func WalletRegister(c *gin.Context) {
 	var req validators.WalletRegisterRequest
@@ -374,12 +418,8 @@ func WalletRegister(c *gin.Context) {
 		return
 	}
 
-	// 验证签名
-	if !verifyWalletSignature(req.Message, req.Signature, req.WalletAddress) {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid wallet signature",
-			"code":  "INVALID_SIGNATURE",
-		})
+	// 校验SIWE挑战并验证签名
+	if !verifySiweLogin(c, req.Message, req.Signature, req.WalletAddress) {
 		return
 	}
 
@@ -470,12 +510,8 @@ func WalletLogin(c *gin.Context) {
 		return
 	}
 
-	// 验证签名
-	if !verifyWalletSignature(req.Message, req.Signature, req.WalletAddress) {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid wallet signature",
-			"code":  "INVALID_SIGNATURE",
-		})
+	// 校验SIWE挑战并验证签名
+	if !verifySiweLogin(c, req.Message, req.Signature, req.WalletAddress) {
 		return
 	}
 
@@ -524,6 +560,60 @@ func WalletLogin(c *gin.Context) {
 	})
 }
 
+// newSiweService 创建SIWE服务，允许使用链注册表中配置的任意链登录
+func newSiweService() *services.SiweService {
+	chains := blockchain.DefaultChainRegistry().All()
+	chainIDs := make([]int64, 0, len(chains))
+	for _, chain := range chains {
+		chainIDs = append(chainIDs, chain.ChainID)
+	}
+	return services.NewSiweService(database.DB, chainIDs...)
+}
+
+// verifySiweLogin 校验SIWE消息字段、签名并消费nonce
+// 校验失败时直接写入错误响应并返回false
+func verifySiweLogin(c *gin.Context, message, signature, walletAddress string) bool {
+	siweMessage, err := services.ParseSiweMessage(message)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid SIWE message",
+			"code":    "INVALID_SIWE_MESSAGE",
+			"details": err.Error(),
+		})
+		return false
+	}
+
+	siweService := newSiweService()
+	if err := siweService.ValidateMessage(c.Request.Context(), siweMessage, walletAddress); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "SIWE message validation failed",
+			"code":    "SIWE_VALIDATION_FAILED",
+			"details": err.Error(),
+		})
+		return false
+	}
+
+	// 先验签再消费nonce，避免他人用伪造签名消耗合法nonce
+	if !verifyWalletSignature(message, signature, walletAddress) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid wallet signature",
+			"code":  "INVALID_SIGNATURE",
+		})
+		return false
+	}
+
+	if err := siweService.ConsumeNonce(c.Request.Context(), siweMessage.Nonce); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "SIWE nonce already used or expired",
+			"code":    "SIWE_NONCE_USED",
+			"details": err.Error(),
+		})
+		return false
+	}
+
+	return true
+}
+
 // verifyWalletSignature 验证钱包签名
 func verifyWalletSignature(message, signature, expectedAddress string) bool {
 	// 添加以太坊消息前缀
@@ -532,7 +622,7 @@ func verifyWalletSignature(message, signature, expectedAddress string) bool {
 
 	// 解码签名
 	signatureBytes, err := hexutil.Decode(signature)
-	if err != nil {
+	if err != nil || len(signatureBytes) != 65 {
 		return false
 	}
 
@@ -8,11 +8,10 @@ import (
 	"strings"
 	"time"
 
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"web3-enterprise-multisig/internal/blockchain"
 	"web3-enterprise-multisig/internal/database"
 	"web3-enterprise-multisig/internal/models"
 )
@@ -37,10 +36,11 @@ type ProposalCenterCard struct {
 }
 
 // AssetOverviewCard 资产概况卡片数据结构
-// 显示ETH总量和Safe数量
+// 显示ETH总量、各资产汇总和Safe数量
 type AssetOverviewCard struct {
-	TotalETH  string `json:"totalETH"`  // 所有Safe的ETH余额汇总（Wei单位转换为ETH显示）
-	SafeCount int    `json:"safeCount"` // 用户管理的Safe数量
+	TotalETH  string                  `json:"totalETH"`  // 所有Safe的ETH余额汇总（Wei单位转换为ETH显示）
+	Assets    []blockchain.AssetTotal `json:"assets"`    // 按链和资产汇总的余额（原生币及已配置的ERC-20代币）
+	SafeCount int                     `json:"safeCount"` // 用户管理的Safe数量
 }
 
 // GetDashboardCards 获取Dashboard卡片数据
@@ -324,17 +324,17 @@ func getAssetOverviewData(userUUID uuid.UUID) (*AssetOverviewCard, error) {
 	// 调试日志：Safe数量
 	fmt.Printf("Dashboard调试 - 用户Safe数量: %d\n", len(safes))
 
-	// 2. 获取ETH总余额
-	totalETH, err := getTotalETHBalance(safes)
+	// 2. 从余额快照获取各资产汇总（快照过期时自动从链上刷新）
+	balanceService := blockchain.NewBalanceService(database.DB, blockchain.DefaultChainRegistry())
+	assets, err := balanceService.GetAssetTotals(context.TODO(), safes)
 	if err != nil {
-		// TODO: 如果区块链调用失败，考虑使用缓存数据或返回默认值
-		// 当前先返回错误，后续可以优化为降级处理
-		return nil, fmt.Errorf("获取ETH余额失败: %v", err)
+		return nil, fmt.Errorf("获取资产余额失败: %v", err)
 	}
 
 	// 3. 构建返回数据
 	assetCard := &AssetOverviewCard{
-		TotalETH:  totalETH,
+		TotalETH:  getTotalETHBalance(assets),
+		Assets:    assets,
 		SafeCount: len(safes),
 	}
 
@@ -344,43 +344,21 @@ func getAssetOverviewData(userUUID uuid.UUID) (*AssetOverviewCard, error) {
 	return assetCard, nil
 }
 
-// getTotalETHBalance 获取所有Safe的ETH余额汇总
-// 参数: safes - Safe列表
-// 返回: ETH总余额（字符串格式）和错误信息
-func getTotalETHBalance(safes []models.Safe) (string, error) {
-	// TODO: 这里需要配置RPC URL，建议从环境变量读取
-	// 当前使用Sepolia测试网，生产环境需要配置为主网
-	rpcURL := "https://sepolia.infura.io/v3/2051fcdf4e21463aa06f161d13a0a5f4"
-	
-	// 1. 连接以太坊客户端
-	client, err := ethclient.Dial(rpcURL)
-	if err != nil {
-		return "0", fmt.Errorf("连接以太坊节点失败: %v", err)
-	}
-	defer client.Close()
-
-	// 2. 累计所有Safe的ETH余额
+// getTotalETHBalance 汇总各链原生ETH余额
+// 参数: assets - 按链和资产汇总的余额
+// 返回: ETH总余额（字符串格式）
+func getTotalETHBalance(assets []blockchain.AssetTotal) string {
 	totalBalance := big.NewInt(0)
-
-	for _, safe := range safes {
-		// 获取单个Safe的ETH余额
-		address := common.HexToAddress(safe.Address)
-		balance, err := client.BalanceAt(context.TODO(), address, nil)
-		if err != nil {
-			// TODO: 单个Safe余额获取失败时，可以记录日志但不中断整个流程
-			// 当前先跳过失败的Safe，继续处理其他Safe
-			fmt.Printf("获取Safe %s 余额失败: %v\n", safe.Address, err)
+	for _, asset := range assets {
+		// 只累计symbol为ETH的原生币，其他链的原生币（如POL）单独展示
+		if asset.TokenAddress != models.NativeTokenAddress || asset.Symbol != "ETH" {
 			continue
 		}
-
-		// 累加到总余额
-		totalBalance.Add(totalBalance, balance)
+		totalBalance.Add(totalBalance, parseStringToBigInt(asset.Balance))
 	}
 
-	// 3. 将Wei转换为ETH（保留4位小数）
-	ethBalance := weiToEthString(totalBalance)
-
-	return ethBalance, nil
+	// 将Wei转换为ETH（保留4位小数）
+	return weiToEthString(totalBalance)
 }
 
 // weiToEthString 将Wei转换为ETH字符串格式
@@ -495,6 +473,16 @@ func GetPendingProposals(c *gin.Context) {
 		var signatureCount int64
 		database.DB.Model(&models.Signature{}).Where("proposal_id = ?", proposal.ID).Count(&signatureCount)
 
+		// 代币转账按代币decimals展示数量，其余提案按ETH展示
+		displayValue := weiToEthString(parseStringToBigInt(proposal.Value))
+		assetSymbol := "ETH"
+		displayTo := proposal.ToAddress
+		if proposal.ProposalType == "token_transfer" && proposal.TokenAmount != nil && proposal.TokenDecimals != nil {
+			displayTo = proposal.TokenRecipient
+			displayValue = blockchain.FormatTokenAmount(parseStringToBigInt(*proposal.TokenAmount), uint8(*proposal.TokenDecimals))
+			assetSymbol = getStringValue(proposal.TokenSymbol)
+		}
+
 		pendingProposal := gin.H{
 			"id":                  proposal.ID,
 			"title":              proposal.Title,
@@ -504,8 +492,10 @@ func GetPendingProposals(c *gin.Context) {
 			"creator_name":       creatorName,
 			"signatures_required": proposal.RequiredSignatures,
 			"signatures_count":   int(signatureCount),
-			"to_address":         proposal.ToAddress,
-			"value":              weiToEthString(parseStringToBigInt(proposal.Value)),
+			"to_address":         displayTo,
+			"value":              displayValue,
+			"asset_symbol":       assetSymbol,
+			"token_address":      proposal.TokenAddress,
 			"created_at":         proposal.CreatedAt.Format(time.RFC3339),
 			"priority":           priority,
 		}
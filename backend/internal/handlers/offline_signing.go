@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"web3-enterprise-multisig/internal/database"
+	"web3-enterprise-multisig/internal/models"
+	"web3-enterprise-multisig/internal/validators"
+	"web3-enterprise-multisig/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// offlineSignatureResult 导入离线签名时单个提案的处理结果
+type offlineSignatureResult struct {
+	ProposalID         string      `json:"proposal_id"`
+	Status             string      `json:"status"` // signed 或 failed
+	Code               string      `json:"code,omitempty"`
+	Error              string      `json:"error,omitempty"`
+	Details            interface{} `json:"details,omitempty"`
+	SignatureID        *uuid.UUID  `json:"signature_id,omitempty"`
+	ProposalStatus     string      `json:"proposal_status,omitempty"`
+	CurrentSignatures  int         `json:"current_signatures,omitempty"`
+	RequiredSignatures int         `json:"required_signatures,omitempty"`
+}
+
+// ExportOfflineSigningBundle 导出提案的离线签名包（EIP-712数据、元数据和校验和）
+func ExportOfflineSigningBundle(c *gin.Context) {
+	var req validators.OfflineSigningExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+	if err := validators.ValidateStruct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation failed",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	proposalIDs := make([]uuid.UUID, 0, len(req.ProposalIDs))
+	for _, id := range req.ProposalIDs {
+		proposalIDs = append(proposalIDs, uuid.MustParse(id))
+	}
+
+	// 签名包包含提案的完整交易数据，每个提案都需要所属Safe的查看权限
+	for _, proposalID := range proposalIDs {
+		var proposal models.Proposal
+		if err := database.DB.Select("id", "safe_id", "status").First(&proposal, proposalID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{
+					"error":   "Proposal not found",
+					"code":    "PROPOSAL_NOT_FOUND",
+					"details": fmt.Sprintf("proposal %s", proposalID),
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get proposal",
+				"code":    "DATABASE_ERROR",
+				"details": err.Error(),
+			})
+			return
+		}
+		if !requireProposalViewPermission(c, &proposal) {
+			return
+		}
+	}
+
+	userID, _ := c.Get("userID")
+	bundle, err := workflow.BuildOfflineSigningBundle(userID.(uuid.UUID), proposalIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Proposal not found",
+				"code":    "PROPOSAL_NOT_FOUND",
+				"details": err.Error(),
+			})
+		case errors.Is(err, workflow.ErrProposalNotSignable):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Proposal is not in pending status",
+				"code":    "INVALID_STATUS",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to export offline signing bundle",
+				"code":    "EXPORT_ERROR",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	log.Printf("📤 用户 %s 导出离线签名包 %s，包含 %d 个提案", userID, bundle.BundleID, len(bundle.Proposals))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="offline-signing-%s.json"`, bundle.BundleID))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportOfflineSignatures 导入离线签署的签名文件
+// 每个签名按签名接口相同的流程校验并保存，逐个提案返回结果，单个失败不影响其他提案
+// 带bundle_checksum时必须是当前用户导出的签名包，不在签名包中的提案或safeTxHash不一致的签名被拒绝
+func ImportOfflineSignatures(c *gin.Context) {
+	var req validators.OfflineSignatureImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+	if err := validators.ValidateStruct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation failed",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+	if req.Format != "" && req.Format != workflow.OfflineSignaturesFormat {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Unsupported signature file format",
+			"code":    "INVALID_FORMAT",
+			"details": fmt.Sprintf("expected %s, got %s", workflow.OfflineSignaturesFormat, req.Format),
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user information",
+			"code":  "USER_ERROR",
+		})
+		return
+	}
+
+	// 引用了签名包时，只接受该签名包中提案按导出时safeTxHash的签名
+	var bundleHashes map[uuid.UUID]string
+	if req.BundleChecksum != "" {
+		var bundleID *uuid.UUID
+		if req.BundleID != "" {
+			id := uuid.MustParse(req.BundleID)
+			bundleID = &id
+		}
+		hashes, err := workflow.OfflineSigningBundleSafeTxHashes(userID.(uuid.UUID), bundleID, req.BundleChecksum)
+		if err != nil {
+			if errors.Is(err, workflow.ErrOfflineBundleNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "Offline signing bundle not found",
+					"code":    "BUNDLE_NOT_FOUND",
+					"details": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to load offline signing bundle",
+				"code":    "BUNDLE_ERROR",
+				"details": err.Error(),
+			})
+			return
+		}
+		bundleHashes = hashes
+	}
+
+	results := make([]offlineSignatureResult, 0, len(req.Signatures))
+	signed := 0
+	for _, entry := range req.Signatures {
+		result := offlineSignatureResult{ProposalID: entry.ProposalID, Status: "failed"}
+
+		// 签名文件声明的签名者必须是当前用户的钱包，签名本身仍由验证流程确认
+		if entry.Signer != "" && (user.WalletAddress == nil || !strings.EqualFold(entry.Signer, *user.WalletAddress)) {
+			result.Code = "SIGNER_MISMATCH"
+			result.Error = "Signature was not produced by your wallet"
+			result.Details = gin.H{"signer": entry.Signer}
+			results = append(results, result)
+			continue
+		}
+
+		if bundleHashes != nil {
+			expected, ok := bundleHashes[uuid.MustParse(entry.ProposalID)]
+			if !ok || !strings.EqualFold(expected, entry.SafeTxHash) {
+				result.Code = "NOT_IN_BUNDLE"
+				result.Error = "Signature does not belong to the referenced bundle"
+				result.Details = gin.H{"safe_tx_hash": entry.SafeTxHash, "bundle_checksum": req.BundleChecksum}
+				results = append(results, result)
+				continue
+			}
+		}
+
+		signatureType := entry.SignatureType
+		if signatureType == "" {
+			signatureType = "eth_signTypedData"
+		}
+
+		proposal, signature, signErr := signProposalAs(c.Request.Context(), uuid.MustParse(entry.ProposalID), userID.(uuid.UUID), validators.SignProposalRequest{
+			SignatureData: entry.Signature,
+			SignatureType: signatureType,
+			UsedNonce:     entry.Nonce,
+			SafeTxHash:    entry.SafeTxHash,
+		})
+		if signErr != nil {
+			result.Code, _ = signErr.Body["code"].(string)
+			result.Error, _ = signErr.Body["error"].(string)
+			result.Details = signErr.Body["details"]
+			results = append(results, result)
+			continue
+		}
+
+		signed++
+		result.Status = "signed"
+		result.SignatureID = &signature.ID
+		result.ProposalStatus = proposal.Status
+		result.CurrentSignatures = proposal.CurrentSignatures
+		result.RequiredSignatures = proposal.RequiredSignatures
+		results = append(results, result)
+	}
+
+	log.Printf("📥 用户 %s 导入离线签名（签名包 %s）：成功 %d 个，失败 %d 个", userID, req.BundleID, signed, len(results)-signed)
+	c.JSON(http.StatusOK, gin.H{
+		"bundle_id":       req.BundleID,
+		"bundle_checksum": req.BundleChecksum,
+		"signed":          signed,
+		"failed":          len(results) - signed,
+		"results":         results,
+	})
+}
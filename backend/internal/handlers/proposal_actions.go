@@ -1,23 +1,120 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
+	"web3-enterprise-multisig/internal/blockchain"
 	"web3-enterprise-multisig/internal/database"
 	"web3-enterprise-multisig/internal/models"
+	"web3-enterprise-multisig/internal/services"
+	"web3-enterprise-multisig/internal/validators"
 	"web3-enterprise-multisig/internal/workflow"
 )
 
-// ExecuteProposalByID 执行提案 (proposals路由版本)
+// ExecuteProposalByID 提交提案执行任务 (proposals路由版本)
+// 执行在后台队列中异步进行，通过任务状态接口或WebSocket获取进度
 func ExecuteProposalByID(c *gin.Context) {
-	proposalID := c.Param("id")
+	proposalUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid proposal ID",
+			"code":  "INVALID_PROPOSAL_ID",
+		})
+		return
+	}
+
+	enqueueProposalExecution(c, proposalUUID)
+}
+
+// enqueueProposalExecution 提交执行任务：新任务返回202，已有任务（幂等）返回200
+func enqueueProposalExecution(c *gin.Context, proposalUUID uuid.UUID) {
+	userID, _ := c.Get("userID")
+	job, created, err := workflow.EnqueueExecution(proposalUUID, userID.(uuid.UUID))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Proposal not found",
+				"code":  "PROPOSAL_NOT_FOUND",
+			})
+		case errors.Is(err, workflow.ErrProposalNotExecutable):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "PROPOSAL_NOT_EXECUTABLE",
+			})
+		case errors.Is(err, blockchain.ErrSignerNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Blockchain executor not configured. Please contact administrator.",
+				"code":  "EXECUTOR_NOT_CONFIGURED",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to enqueue proposal execution",
+				"code":    "EXECUTION_ENQUEUE_ERROR",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	if !created {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Proposal execution already submitted",
+			"job":     job,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Proposal execution queued",
+		"job":     job,
+	})
+}
+
+// GetExecutionJob 获取执行任务状态
+func GetExecutionJob(c *gin.Context) {
+	jobUUID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid job ID",
+			"code":  "INVALID_JOB_ID",
+		})
+		return
+	}
+
+	var job models.ProposalExecutionJob
+	if err := database.DB.First(&job, jobUUID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Execution job not found",
+			"code":  "JOB_NOT_FOUND",
+		})
+		return
+	}
+
+	// 任务按所属提案的Safe鉴权，与查看提案相同
+	var proposal models.Proposal
+	if err := database.DB.Select("id", "safe_id", "status").First(&proposal, job.ProposalID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Execution job not found",
+			"code":  "JOB_NOT_FOUND",
+		})
+		return
+	}
+	if !requireProposalViewPermission(c, &proposal) {
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
 
-	proposalUUID, err := uuid.Parse(proposalID)
+// GetProposalExecutionJobs 获取提案的执行任务记录（最新在前）
+func GetProposalExecutionJobs(c *gin.Context) {
+	proposalUUID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid proposal ID",
@@ -26,60 +123,371 @@ func ExecuteProposalByID(c *gin.Context) {
 		return
 	}
 
-	// 先获取提案信息
 	var proposal models.Proposal
-	if err := database.DB.Preload("Safe").First(&proposal, proposalUUID).Error; err != nil {
+	if err := database.DB.Select("id", "safe_id", "status").First(&proposal, proposalUUID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "Proposal not found",
 			"code":  "PROPOSAL_NOT_FOUND",
 		})
 		return
 	}
+	if !requireProposalViewPermission(c, &proposal) {
+		return
+	}
+
+	var jobs []models.ProposalExecutionJob
+	if err := database.DB.Where("proposal_id = ?", proposalUUID).Order("created_at DESC").Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch execution jobs",
+			"code":    "FETCH_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"proposal_id": proposalUUID,
+		"jobs":        jobs,
+	})
+}
+
+// requireProposalViewPermission 检查当前用户能否查看提案所属Safe的提案，无权限时返回403，返回是否通过
+func requireProposalViewPermission(c *gin.Context, proposal *models.Proposal) bool {
+	return requireProposalPermission(c, proposal, "safe.proposal.view", "没有权限查看此提案")
+}
 
-	// 检查提案是否可以执行
-	if !proposal.CanExecute() {
+// requireProposalPermission 按提案所属Safe检查当前用户的权限，无权限时返回403，返回是否通过
+func requireProposalPermission(c *gin.Context, proposal *models.Proposal, permissionCode string, deniedMessage string) bool {
+	userID, _ := c.Get("userID")
+	permissionService := services.NewPermissionService(database.DB)
+	hasPermission, err := permissionService.CheckPermission(c.Request.Context(), services.PermissionRequest{
+		UserID:         userID.(uuid.UUID),
+		SafeID:         proposal.SafeID,
+		PermissionCode: permissionCode,
+		Context: map[string]interface{}{
+			"proposal_id":     proposal.ID,
+			"proposal_status": proposal.Status,
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "权限检查失败",
+			"code":    "PERMISSION_CHECK_FAILED",
+			"details": err.Error(),
+		})
+		return false
+	}
+	if !hasPermission.Granted {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   deniedMessage,
+			"code":    "PERMISSION_DENIED",
+			"details": hasPermission.DenialReason,
+		})
+		return false
+	}
+	return true
+}
+
+// respondPolicyViolation 策略强制检查未通过时返回403及结构化原因，返回是否已响应
+func respondPolicyViolation(c *gin.Context, err error) bool {
+	status, body, ok := policyViolationResponse(err)
+	if !ok {
+		return false
+	}
+	c.JSON(status, body)
+	return true
+}
+
+// policyViolationResponse 策略违规对应的HTTP状态和响应体，err不是策略违规时ok为false
+func policyViolationResponse(err error) (int, gin.H, bool) {
+	var violation *services.PolicyViolationError
+	if !errors.As(err, &violation) {
+		return 0, nil, false
+	}
+	return http.StatusForbidden, gin.H{
+		"error":   "提案不符合策略要求",
+		"code":    "POLICY_VIOLATION",
+		"details": violation.Details(),
+	}, true
+}
+
+// respondSignatureVerificationError 签名验证失败时返回400，返回值表示是否已处理
+func respondSignatureVerificationError(c *gin.Context, err error) bool {
+	status, body, ok := signatureVerificationResponse(err)
+	if !ok {
+		return false
+	}
+	c.JSON(status, body)
+	return true
+}
+
+// signatureVerificationResponse 签名验证失败对应的HTTP状态和响应体，err不是签名验证错误时ok为false
+func signatureVerificationResponse(err error) (int, gin.H, bool) {
+	var verificationErr *blockchain.SignatureVerificationError
+	if !errors.As(err, &verificationErr) {
+		return 0, nil, false
+	}
+	return http.StatusBadRequest, gin.H{
+		"error":   "签名验证失败",
+		"code":    "INVALID_SIGNATURE",
+		"details": verificationErr,
+	}, true
+}
+
+// SimulateProposal 模拟提案执行，返回是否会成功、revert原因和gas用量
+func SimulateProposal(c *gin.Context) {
+	proposalUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Proposal cannot be executed. Status: %s, Signatures: %d/%d",
-				proposal.Status, proposal.CurrentSignatures, proposal.RequiredSignatures),
-			"code": "PROPOSAL_NOT_EXECUTABLE",
+			"error": "Invalid proposal ID",
+			"code":  "INVALID_PROPOSAL_ID",
 		})
 		return
 	}
 
-	// 调用工作流执行
-	if err := workflow.ExecuteProposal(proposalUUID); err != nil {
-		// 检查是否是配置错误
-		if strings.Contains(err.Error(), "executor private key not configured") {
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error": "Blockchain executor not configured. Please contact administrator.",
-				"code":  "EXECUTOR_NOT_CONFIGURED",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-				"code":  "EXECUTION_ERROR",
+	var proposal models.Proposal
+	if err := database.DB.Select("id", "safe_id", "status").First(&proposal, proposalUUID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Proposal not found",
+			"code":  "PROPOSAL_NOT_FOUND",
+		})
+		return
+	}
+	if !requireProposalViewPermission(c, &proposal) {
+		return
+	}
+
+	result, err := workflow.SimulateProposal(proposalUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Proposal not found",
+				"code":  "PROPOSAL_NOT_FOUND",
 			})
+			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to simulate proposal",
+			"code":    "SIMULATION_ERROR",
+			"details": err.Error(),
+		})
 		return
 	}
 
-	// 返回更新后的提案数据
-	if err := database.DB.Preload("Safe").Preload("Signatures.Signer").First(&proposal, proposalUUID).Error; err != nil {
+	c.JSON(http.StatusOK, gin.H{
+		"proposal_id": proposalUUID,
+		"simulation":  result,
+	})
+}
+
+// GetProposalTypedData 返回提案签名使用的EIP-712数据和safeTxHash
+func GetProposalTypedData(c *gin.Context) {
+	proposalUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid proposal ID",
+			"code":  "INVALID_PROPOSAL_ID",
+		})
+		return
+	}
+
+	var proposal models.Proposal
+	if err := database.DB.Select("id", "safe_id", "status").First(&proposal, proposalUUID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Proposal not found",
+			"code":  "PROPOSAL_NOT_FOUND",
+		})
+		return
+	}
+	if !requireProposalViewPermission(c, &proposal) {
+		return
+	}
+
+	typedData, err := workflow.GetProposalTypedData(proposalUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Proposal not found",
+				"code":  "PROPOSAL_NOT_FOUND",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch updated proposal",
-			"code":  "FETCH_ERROR",
+			"error":   "Failed to build typed data",
+			"code":    "TYPED_DATA_ERROR",
+			"details": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, proposal)
+	c.JSON(http.StatusOK, typedData)
+}
+
+// SyncOnChainApprovals 同步所有者在链上approveHash的审批并计入签名数
+func SyncOnChainApprovals(c *gin.Context) {
+	proposalUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid proposal ID",
+			"code":  "INVALID_PROPOSAL_ID",
+		})
+		return
+	}
+
+	// 同步可能使提案达到阈值并以当前用户身份提交自动执行，需要签名权限
+	var proposal models.Proposal
+	if err := database.DB.Select("id", "safe_id", "status").First(&proposal, proposalUUID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Proposal not found",
+			"code":  "PROPOSAL_NOT_FOUND",
+		})
+		return
+	}
+	if !requireProposalPermission(c, &proposal, "safe.proposal.sign", "没有权限同步此提案的链上审批") {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	approvals, err := workflow.SyncOnChainApprovals(proposalUUID, userID.(uuid.UUID))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Proposal not found",
+				"code":  "PROPOSAL_NOT_FOUND",
+			})
+		case errors.Is(err, workflow.ErrProposalNotExecutable):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_STATUS",
+			})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error":   "Failed to read on-chain approvals",
+				"code":    "APPROVAL_SYNC_ERROR",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"proposal_id": proposalUUID,
+		"approvals":   approvals,
+	})
 }
 
 // RejectProposal 拒绝提案
+// 已分配Safe nonce的提案创建同nonce的链上拒绝提案（0值自调用），拒绝交易执行后原提案转为rejected
 func RejectProposal(c *gin.Context) {
-	proposalID := c.Param("id")
+	proposalUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid proposal ID",
+			"code":  "INVALID_PROPOSAL_ID",
+		})
+		return
+	}
+
+	// 拒绝原因可选，允许空请求体
+	var req validators.RejectProposalRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request format",
+				"code":  "INVALID_REQUEST",
+			})
+			return
+		}
+		if err := validators.ValidateStruct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"code":    "VALIDATION_ERROR",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	// 路由上的权限检查不区分Safe，按提案所属Safe检查创建（拒绝）提案的权限
+	var proposal models.Proposal
+	if err := database.DB.First(&proposal, proposalUUID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Proposal not found",
+			"code":  "PROPOSAL_NOT_FOUND",
+		})
+		return
+	}
+	if !requireProposalPermission(c, &proposal, "safe.proposal.create", "没有权限拒绝此提案") {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	rejection, created, err := workflow.RejectProposal(proposalUUID, userID.(uuid.UUID), req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Proposal not found",
+				"code":  "PROPOSAL_NOT_FOUND",
+			})
+		case errors.Is(err, workflow.ErrSignerNotOwner):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Only safe owners can reject proposals",
+				"code":  "NOT_AUTHORIZED",
+			})
+		case errors.Is(err, workflow.ErrProposalNotRejectable):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "PROPOSAL_NOT_REJECTABLE",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to reject proposal",
+				"code":    "REJECT_ERROR",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	if rejection == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "Proposal rejected successfully",
+			"proposal_id": proposalUUID,
+		})
+		return
+	}
+
+	if !created {
+		c.JSON(http.StatusOK, gin.H{
+			"message":            "Rejection already proposed",
+			"proposal_id":        proposalUUID,
+			"rejection_proposal": rejection,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":            "Rejection proposal created, it must be signed and executed to reject the proposal on chain",
+		"proposal_id":        proposalUUID,
+		"rejection_proposal": rejection,
+	})
+}
+
+// SpeedUpProposalExecution 以更高费用重发卡住的执行交易
+func SpeedUpProposalExecution(c *gin.Context) {
+	replaceProposalExecution(c, models.ExecutionTxActionSpeedUp)
+}
 
-	_, err := uuid.Parse(proposalID)
+// CancelProposalExecution 发送同nonce的0值自转账取消执行交易
+func CancelProposalExecution(c *gin.Context) {
+	replaceProposalExecution(c, models.ExecutionTxActionCancel)
+}
+
+// replaceProposalExecution 加速/取消共用的处理逻辑
+func replaceProposalExecution(c *gin.Context, action string) {
+	proposalUUID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid proposal ID",
@@ -88,11 +496,83 @@ func RejectProposal(c *gin.Context) {
 		return
 	}
 
-	// TODO: 实现拒绝提案的逻辑
-	// 可以更新提案状态为"rejected"
-	// 或者调用工作流引擎的拒绝逻辑
+	userID, _ := c.Get("userID")
+
+	var record *models.ProposalExecutionTx
+	if action == models.ExecutionTxActionCancel {
+		record, err = workflow.CancelExecution(proposalUUID, userID.(uuid.UUID))
+	} else {
+		record, err = workflow.SpeedUpExecution(proposalUUID, userID.(uuid.UUID))
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Proposal not found",
+				"code":  "PROPOSAL_NOT_FOUND",
+			})
+		case errors.Is(err, blockchain.ErrSignerNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Blockchain executor not configured. Please contact administrator.",
+				"code":  "EXECUTOR_NOT_CONFIGURED",
+			})
+		case errors.Is(err, blockchain.ErrExecutionTxNotPending), errors.Is(err, blockchain.ErrExecutionTxNotTracked):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Execution transaction is not pending",
+				"code":    "EXECUTION_TX_NOT_PENDING",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to replace execution transaction",
+				"code":    "EXECUTION_REPLACE_ERROR",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Replacement transaction sent",
+		"execution_tx": record,
+	})
+}
+
+// GetProposalExecutionTxs 获取提案的执行交易及其加速/取消交易
+func GetProposalExecutionTxs(c *gin.Context) {
+	proposalUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid proposal ID",
+			"code":  "INVALID_PROPOSAL_ID",
+		})
+		return
+	}
+
+	var proposal models.Proposal
+	if err := database.DB.Select("id", "safe_id", "status").First(&proposal, proposalUUID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Proposal not found",
+			"code":  "PROPOSAL_NOT_FOUND",
+		})
+		return
+	}
+	if !requireProposalViewPermission(c, &proposal) {
+		return
+	}
+
+	var txs []models.ProposalExecutionTx
+	if err := database.DB.Where("proposal_id = ?", proposalUUID).Order("created_at ASC").Find(&txs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch execution transactions",
+			"code":    "FETCH_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Proposal rejected successfully",
+		"proposal_id":   proposalUUID,
+		"execution_txs": txs,
 	})
 }
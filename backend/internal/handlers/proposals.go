@@ -1,20 +1,28 @@
 package handlers
 
 import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"log"
 	"math/big"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"web3-enterprise-multisig/internal/blockchain"
 	"web3-enterprise-multisig/internal/database"
 	"web3-enterprise-multisig/internal/models"
 	"web3-enterprise-multisig/internal/services"
 	"web3-enterprise-multisig/internal/validators"
 	"web3-enterprise-multisig/internal/workflow"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // GetProposals 获取提案列表
@@ -133,6 +141,87 @@ func CreateProposal(c *gin.Context) {
 		return
 	}
 
+	// 批量交易：子交易编码为MultiSendCallOnly调用，由Safe以DELEGATECALL执行
+	var batchItems []models.ProposalBatchItem
+	if req.ProposalType == "batch" {
+		chain, err := blockchain.DefaultChainRegistry().Get(int64(safe.ChainID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Unsupported chain",
+				"code":    "UNSUPPORTED_CHAIN",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		var batchData []byte
+		var batchTotal *big.Int
+		batchItems, batchData, batchTotal, err = buildBatchItems(req.BatchItems)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid batch items",
+				"code":    "INVALID_BATCH_ITEMS",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		req.ToAddress = chain.MultiSendCallOnly
+		req.Data = "0x" + hex.EncodeToString(batchData)
+		req.Operation = models.OperationDelegateCall
+		// 外层交易不携带ETH，子交易金额由Safe余额支付；权限和策略按子交易总额校验
+		req.Value = batchTotal.String()
+	}
+
+	// 代币转账：读取代币decimals/symbol，编码transfer(to, amount)作为SafeTx调用数据
+	policyValue := req.Value
+	policyToAddress := req.ToAddress
+	var policyTokenAddress *string
+	var tokenInfo *blockchain.TokenInfo
+	var tokenAmount *big.Int
+	if req.ProposalType == "token_transfer" {
+		tokenInfo, err = readProposalTokenInfo(c, safe.ChainID, req.TokenAddress)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Failed to read token metadata",
+				"code":    "INVALID_TOKEN",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		tokenAmount, err = blockchain.ParseTokenAmount(req.TokenAmount, tokenInfo.Decimals)
+		if err != nil || tokenAmount.Sign() <= 0 {
+			details := "token amount must be greater than 0"
+			if err != nil {
+				details = err.Error()
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid token amount",
+				"code":    "INVALID_TOKEN_AMOUNT",
+				"details": details,
+			})
+			return
+		}
+
+		transferData, err := blockchain.EncodeERC20Transfer(common.HexToAddress(req.ToAddress), tokenAmount)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to encode token transfer",
+				"code":  "ENCODE_ERROR",
+			})
+			return
+		}
+
+		tokenAddress := tokenInfo.Address.Hex()
+		policyValue = tokenAmount.String()
+		policyTokenAddress = &tokenAddress
+		req.ToAddress = tokenAddress
+		req.Data = "0x" + hex.EncodeToString(transferData)
+		req.Operation = models.OperationCall
+		req.Value = "0"
+	}
+
 	// 使用权限服务检查用户是否有创建提案的权限
 	permissionService := services.NewPermissionService(database.DB)
 	hasPermission, err := permissionService.CheckPermission(c.Request.Context(), services.PermissionRequest{
@@ -141,8 +230,10 @@ func CreateProposal(c *gin.Context) {
 		PermissionCode: "safe.proposal.create",
 		Context: map[string]interface{}{
 			"proposal_type": req.ProposalType,
-			"value":         req.Value,
-			"to_address":    req.ToAddress,
+			"value":         policyValue,
+			"to_address":    policyToAddress,
+			"token_address": policyTokenAddress,
+			"operation":     req.Operation,
 		},
 	})
 	if err != nil {
@@ -168,12 +259,14 @@ func CreateProposal(c *gin.Context) {
 	policyValidationReq := services.PolicyValidationRequest{
 		SafeID:       safeUUID,
 		ProposalType: req.ProposalType,
-		ToAddress:    &req.ToAddress,
-		Value:        req.Value,
+		ToAddress:    &policyToAddress,
+		Value:        policyValue,
+		TokenAddress: policyTokenAddress,
 		Data:         &req.Data,
 		UserID:       userID.(uuid.UUID),
 		Context: map[string]interface{}{
-			"action": "create_proposal",
+			"action":    "create_proposal",
+			"operation": req.Operation,
 		},
 	}
 
@@ -225,6 +318,60 @@ func CreateProposal(c *gin.Context) {
 		return
 	}
 
+	if req.ProposalType == "batch" {
+		valueInWei = "0"
+	}
+
+	// 验证SafeTx参数：DELEGATECALL仅允许带调用数据的合约调用提案和批量提案（MultiSend）
+	if req.Operation == models.OperationDelegateCall &&
+		((req.ProposalType != "contract_call" && req.ProposalType != "batch") || strings.TrimPrefix(req.Data, "0x") == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "DELEGATECALL is only allowed for contract_call and batch proposals with call data",
+			"code":  "INVALID_OPERATION",
+		})
+		return
+	}
+	// DELEGATECALL以Safe自身存储执行目标合约代码，只允许MultiSendCallOnly和链配置的白名单合约
+	if req.Operation == models.OperationDelegateCall {
+		chain, err := blockchain.DefaultChainRegistry().Get(int64(safe.ChainID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Unsupported chain",
+				"code":    "UNSUPPORTED_CHAIN",
+				"details": err.Error(),
+			})
+			return
+		}
+		if !chain.AllowsDelegateCall(req.ToAddress) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "DELEGATECALL target is not allowed",
+				"code":    "INVALID_SAFE_TX",
+				"details": fmt.Sprintf("%s is neither MultiSendCallOnly nor in the DELEGATECALL allowlist of chain %d", req.ToAddress, safe.ChainID),
+			})
+			return
+		}
+	}
+
+	safeTxGas, ok1 := normalizeUintString(req.SafeTxGas)
+	baseGas, ok2 := normalizeUintString(req.BaseGas)
+	gasPrice, ok3 := normalizeUintString(req.GasPrice)
+	if !ok1 || !ok2 || !ok3 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "safe_tx_gas, base_gas and gas_price must be non-negative integers",
+			"code":  "INVALID_GAS_PARAMS",
+		})
+		return
+	}
+
+	gasToken := req.GasToken
+	if gasToken == "" {
+		gasToken = zeroAddress
+	}
+	refundReceiver := req.RefundReceiver
+	if refundReceiver == "" {
+		refundReceiver = zeroAddress
+	}
+
 	// 创建提案
 	proposal := models.Proposal{
 		SafeID:             safeUUID,
@@ -234,15 +381,61 @@ func CreateProposal(c *gin.Context) {
 		ToAddress:          &req.ToAddress,
 		Value:              valueInWei,
 		Data:               &req.Data,
+		Operation:          req.Operation,
+		SafeTxGas:          safeTxGas,
+		BaseGas:            baseGas,
+		GasPrice:           gasPrice,
+		GasToken:           gasToken,
+		RefundReceiver:     refundReceiver,
 		Status:             "pending",
 		RequiredSignatures: req.RequiredSignatures,
 		CreatedBy:          userID.(uuid.UUID),
 	}
 
-	if err := database.DB.Create(&proposal).Error; err != nil {
+	if tokenInfo != nil {
+		tokenAddress := tokenInfo.Address.Hex()
+		tokenSymbol := tokenInfo.Symbol
+		tokenDecimals := int(tokenInfo.Decimals)
+		tokenRecipient := common.HexToAddress(policyToAddress).Hex()
+		rawAmount := tokenAmount.String()
+		proposal.TokenAddress = &tokenAddress
+		proposal.TokenSymbol = &tokenSymbol
+		proposal.TokenDecimals = &tokenDecimals
+		proposal.TokenRecipient = &tokenRecipient
+		proposal.TokenAmount = &rawAmount
+	}
+
+	// 创建时分配Safe nonce，签名和执行都针对该nonce
+	// nonce分配与提案、批量子交易在同一事务中保存，并发创建不会分到相同nonce
+	err = workflow.CreateProposalWithNonce(&safe, req.Nonce, func(tx *gorm.DB, nonce *int64) error {
+		proposal.Nonce = nonce
+		if err := tx.Create(&proposal).Error; err != nil {
+			return fmt.Errorf("failed to create proposal: %w", err)
+		}
+
+		for i := range batchItems {
+			batchItems[i].ProposalID = proposal.ID
+		}
+		if len(batchItems) > 0 {
+			if err := tx.Create(&batchItems).Error; err != nil {
+				return fmt.Errorf("failed to create batch items: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, workflow.ErrInvalidProposalNonce) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid Safe nonce",
+				"code":    "INVALID_NONCE",
+				"details": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create proposal",
-			"code":  "CREATE_ERROR",
+			"error":   "Failed to create proposal",
+			"code":    "CREATE_ERROR",
+			"details": err.Error(),
 		})
 		return
 	}
@@ -250,6 +443,7 @@ func CreateProposal(c *gin.Context) {
 	// 重新查询提案以包含Safe关联数据
 	var createdProposal models.Proposal
 	if err := database.DB.Preload("Safe").Preload("Creator").Preload("Signatures.Signer").
+		Preload("BatchItems", orderBatchItems).
 		First(&createdProposal, proposal.ID).Error; err != nil {
 		log.Printf("Failed to reload proposal with associations: %v", err)
 		// 如果重新查询失败，仍然返回原始proposal
@@ -263,10 +457,103 @@ func CreateProposal(c *gin.Context) {
 		}
 	}()
 
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"message":  "Proposal created successfully",
 		"proposal": createdProposal,
-	})
+	}
+
+	// 与其他待处理提案共用nonce时，只有一个能执行，提示调用方
+	if proposal.Nonce != nil {
+		if conflicts, err := workflow.NonceConflicts(safeUUID, *proposal.Nonce, proposal.ID); err != nil {
+			log.Printf("Failed to check nonce conflicts for proposal %s: %v", proposal.ID, err)
+		} else if len(conflicts) > 0 {
+			response["nonce_conflicts"] = conflicts
+		}
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// buildBatchItems 校验批量子交易并编码为MultiSendCallOnly调用数据
+// 返回子交易模型、multiSend调用数据和子交易总金额(wei)
+func buildBatchItems(reqItems []validators.BatchItemRequest) ([]models.ProposalBatchItem, []byte, *big.Int, error) {
+	if len(reqItems) == 0 {
+		return nil, nil, nil, fmt.Errorf("batch proposal requires at least one item")
+	}
+
+	items := make([]models.ProposalBatchItem, 0, len(reqItems))
+	total := big.NewInt(0)
+	for i, reqItem := range reqItems {
+		value, ok := normalizeUintString(reqItem.Value)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("item %d: value must be a non-negative integer in wei", i)
+		}
+		itemValue, _ := new(big.Int).SetString(value, 10)
+		total.Add(total, itemValue)
+
+		item := models.ProposalBatchItem{
+			ItemIndex: i,
+			ToAddress: reqItem.ToAddress,
+			Value:     value,
+			Status:    "pending",
+		}
+		if data := strings.TrimPrefix(reqItem.Data, "0x"); data != "" {
+			if _, err := hex.DecodeString(data); err != nil {
+				return nil, nil, nil, fmt.Errorf("item %d: invalid call data", i)
+			}
+			hexData := "0x" + data
+			item.Data = &hexData
+		}
+		items = append(items, item)
+	}
+
+	calls, err := blockchain.MultiSendCallsFromBatchItems(items)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	data, err := blockchain.EncodeMultiSendCallOnly(calls)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return items, data, total, nil
+}
+
+// readProposalTokenInfo 通过Safe所在链的RPC读取代币元数据
+func readProposalTokenInfo(c *gin.Context, chainID int, tokenAddress string) (*blockchain.TokenInfo, error) {
+	chain, err := blockchain.DefaultChainRegistry().Get(int64(chainID))
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := chain.Dial(c.Request.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return blockchain.ReadTokenInfo(c.Request.Context(), client, common.HexToAddress(tokenAddress))
+}
+
+// orderBatchItems 批量子交易按执行顺序预加载
+func orderBatchItems(db *gorm.DB) *gorm.DB {
+	return db.Order("item_index ASC")
+}
+
+// zeroAddress 以太坊零地址
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// normalizeUintString 校验并规范化非负整数字符串，空字符串视为0
+func normalizeUintString(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "0", true
+	}
+	parsed, ok := new(big.Int).SetString(value, 10)
+	if !ok || parsed.Sign() < 0 {
+		return "", false
+	}
+	return parsed.String(), true
 }
 
 // convertEthToWei 将ETH金额转换为wei
@@ -322,6 +609,7 @@ func GetProposal(c *gin.Context) {
 
 	var proposal models.Proposal
 	if err := database.DB.Preload("Safe").Preload("Creator").Preload("Signatures.Signer").
+		Preload("BatchItems", orderBatchItems).
 		First(&proposal, proposalUUID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "Proposal not found",
@@ -452,6 +740,16 @@ func UpdateProposal(c *gin.Context) {
 		return
 	}
 
+	// 批量提案和代币转账提案的调用数据由服务端编码生成，不允许单独修改交易参数
+	if (proposal.ProposalType == "batch" || proposal.ProposalType == "token_transfer") &&
+		(req.ToAddress != "" || req.Value != "" || req.Data != "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Transaction fields of batch and token transfer proposals cannot be updated",
+			"code":  "TX_FIELDS_IMMUTABLE",
+		})
+		return
+	}
+
 	// 更新提案信息
 	updates := make(map[string]interface{})
 	if req.Title != "" {
@@ -602,42 +900,66 @@ func SignProposal(c *gin.Context) {
 		return
 	}
 
+	proposal, signature, signErr := signProposalAs(c.Request.Context(), proposalUUID, userID.(uuid.UUID), req)
+	if signErr != nil {
+		c.JSON(signErr.Status, signErr.Body)
+		return
+	}
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Proposal signed successfully",
+		"proposal":  proposal,
+		"signature": signature,
+	})
+}
+
+// proposalSignError 签名失败时返回的HTTP状态和响应体
+type proposalSignError struct {
+	Status int
+	Body   gin.H
+}
+
+// signProposalAs 以用户身份为提案添加签名，签名接口和离线签名导入共用
+// 检查提案状态和所有者身份、强制检查策略、按签名使用的nonce验证签名并核对SafeTxHash，
+// 保存后更新签名计数，达到阈值时提交自动执行任务
+func signProposalAs(ctx context.Context, proposalUUID, userID uuid.UUID, req validators.SignProposalRequest) (*models.Proposal, *models.Signature, *proposalSignError) {
+	fail := func(status int, body gin.H) (*models.Proposal, *models.Signature, *proposalSignError) {
+		return nil, nil, &proposalSignError{Status: status, Body: body}
+	}
+
 	var proposal models.Proposal
 	if err := database.DB.Preload("Safe").First(&proposal, proposalUUID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
+		return fail(http.StatusNotFound, gin.H{
 			"error": "Proposal not found",
 			"code":  "PROPOSAL_NOT_FOUND",
 		})
-		return
 	}
 
 	// 检查提案状态
 	if proposal.Status != "pending" {
-		c.JSON(http.StatusBadRequest, gin.H{
+		return fail(http.StatusBadRequest, gin.H{
 			"error": "Proposal is not in pending status",
 			"code":  "INVALID_STATUS",
 		})
-		return
 	}
 
 	// 检查用户是否为 Safe 的所有者
 	// 需要通过用户的钱包地址来检查，而不是用户ID
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		return fail(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get user information",
 			"code":  "USER_ERROR",
 		})
-		return
 	}
 
 	// 检查用户是否有钱包地址
 	if user.WalletAddress == nil || *user.WalletAddress == "" {
-		c.JSON(http.StatusForbidden, gin.H{
+		return fail(http.StatusForbidden, gin.H{
 			"error": "User must have a wallet address to sign proposals",
 			"code":  "NO_WALLET_ADDRESS",
 		})
-		return
 	}
 
 	// 检查用户的钱包地址是否在Safe的所有者列表中
@@ -661,7 +983,7 @@ func SignProposal(c *gin.Context) {
 
 	if !isOwner {
 		log.Printf("❌ 用户不是Safe所有者，拒绝签名")
-		c.JSON(http.StatusForbidden, gin.H{
+		return fail(http.StatusForbidden, gin.H{
 			"error": "Only safe owners can sign proposals",
 			"code":  "NOT_AUTHORIZED",
 			"debug": gin.H{
@@ -669,65 +991,87 @@ func SignProposal(c *gin.Context) {
 				"safe_owners": proposal.Safe.Owners,
 			},
 		})
-		return
 	}
 
 	log.Printf("✅ 用户验证通过，允许签名")
 
-	// 检查用户是否已经签名
+	// 检查用户是否已经签名（重新排队后失效的签名不计，重新签名时替换）
 	var existingSignature models.Signature
-	if err := database.DB.Where("proposal_id = ? AND signer_id = ?", proposalUUID, userID).
+	if err := database.DB.Where("proposal_id = ? AND signer_id = ? AND status = ?", proposalUUID, userID, "valid").
 		First(&existingSignature).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		return fail(http.StatusBadRequest, gin.H{
 			"error": "User has already signed this proposal",
+			"code":  "ALREADY_SIGNED",
 		})
-		return
 	}
 
-	// 获取签名时使用的nonce和交易哈希
-	var usedNonce *int64
-	var safeTxHash *string
+	// 签名前强制检查策略（如支出限额在创建后已被其他提案用尽）
+	policyService := services.NewPolicyService(database.DB)
+	if _, err := policyService.EnforcePolicies(ctx, proposalUUID, userID, services.PolicyStageSign); err != nil {
+		if status, body, ok := policyViolationResponse(err); ok {
+			return fail(status, body)
+		}
+		return fail(http.StatusInternalServerError, gin.H{
+			"error":   "策略验证失败",
+			"code":    "POLICY_VALIDATION_FAILED",
+			"details": err.Error(),
+		})
+	}
 
-	if req.UsedNonce != nil {
-		nonce := int64(*req.UsedNonce)
-		usedNonce = &nonce
+	// 按签名使用的nonce重建SafeTxHash，验证签名确由用户钱包签署（支持EIP-712、eth_sign和EIP-1271合约签名）
+	verified, err := workflow.VerifyProposalSignature(&proposal, *user.WalletAddress, req.SignatureType, req.SignatureData, req.UsedNonce)
+	if err != nil {
+		if status, body, ok := signatureVerificationResponse(err); ok {
+			log.Printf("❌ 提案 %s 的签名验证失败: %v", proposalUUID, err)
+			return fail(status, body)
+		}
+		return fail(http.StatusBadGateway, gin.H{
+			"error":   "Failed to verify signature",
+			"code":    "SIGNATURE_VERIFICATION_ERROR",
+			"details": err.Error(),
+		})
 	}
 
-	if req.SafeTxHash != "" {
-		safeTxHash = &req.SafeTxHash
+	// 客户端提交的SafeTxHash必须与服务端重建的一致
+	computedHash := verified.SafeTxHash.Hex()
+	if req.SafeTxHash != "" && !strings.EqualFold(req.SafeTxHash, computedHash) {
+		return fail(http.StatusBadRequest, gin.H{
+			"error": "SafeTxHash does not match proposal",
+			"code":  "SAFE_TX_HASH_MISMATCH",
+			"details": gin.H{
+				"submitted": req.SafeTxHash,
+				"expected":  computedHash,
+				"nonce":     verified.Nonce.String(),
+			},
+		})
 	}
+	log.Printf("✅ 签名验证通过: signer=%s, type=%s, nonce=%s", verified.Signer.Hex(), verified.SignatureType, verified.Nonce.String())
 
-	// 创建签名记录
+	usedNonce := verified.Nonce.Int64()
+
+	// 创建签名记录（保存规范化后的签名和实际签名类型）
 	signature := models.Signature{
 		ProposalID:    proposalUUID,
-		SignerID:      userID.(uuid.UUID),
-		SignatureData: req.SignatureData,
-		SignatureType: req.SignatureType,
+		SignerID:      userID,
+		SignatureData: "0x" + hex.EncodeToString(verified.Signature),
+		SignatureType: verified.SignatureType,
 		Status:        "valid",
-		UsedNonce:     usedNonce,
-		SafeTxHash:    safeTxHash,
-	}
-
-	// 验证签名数据格式
-	if strings.HasPrefix(req.SignatureData, "0x") && len(req.SignatureData) == 132 {
-		// 正确的EIP-712签名格式：0x + 130个十六进制字符 = 132个字符总长度
-		log.Printf("✅ 接收到有效的EIP-712签名: %s", req.SignatureData[:20]+"...")
-	} else {
-		log.Printf("⚠️  签名格式可能不正确: 长度=%d, 前缀=%s", len(req.SignatureData), req.SignatureData[:10])
+		UsedNonce:     &usedNonce,
+		SafeTxHash:    &computedHash,
 	}
 
-	if err := database.DB.Create(&signature).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+	if err := workflow.SaveProposalSignature(database.DB, &signature); err != nil {
+		return fail(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create signature",
 			"code":  "CREATE_ERROR",
 		})
-		return
 	}
 
 	// 更新提案签名计数
 	newSignatureCount := proposal.CurrentSignatures + 1
 	updates := map[string]interface{}{
 		"current_signatures": newSignatureCount,
+		"safe_tx_hash":       computedHash, // 供按safeTxHash查询提案（交易服务兼容接口、链上历史关联）
 	}
 
 	// 检查是否达到执行条件
@@ -736,38 +1080,31 @@ func SignProposal(c *gin.Context) {
 	}
 
 	if err := database.DB.Model(&proposal).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		return fail(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update proposal",
 			"code":  "UPDATE_ERROR",
 		})
-		return
 	}
 
 	// 重新获取更新后的提案信息
 	if err := database.DB.Preload("Safe").Preload("Signatures.Signer").First(&proposal, proposalUUID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		return fail(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch updated proposal",
 			"code":  "FETCH_ERROR",
 		})
-		return
 	}
 
-	// 如果达到签名阈值，触发工作流引擎进行下一步处理
+	// 达到签名阈值且Safe开启了自动执行时，提交执行任务（受时间锁定策略约束）
 	if newSignatureCount >= proposal.RequiredSignatures {
-		if err := workflow.ExecuteProposal(proposalUUID); err != nil {
-			log.Printf("Failed to execute proposal %s: %v", proposalUUID, err)
-			// 执行失败不影响签名成功的响应，但记录错误日志
-		} else {
-			log.Printf("Proposal %s automatically executed after reaching signature threshold", proposalUUID)
+		if job, err := workflow.ScheduleAutoExecution(proposalUUID, userID); err != nil {
+			log.Printf("Failed to schedule auto-execution for proposal %s: %v", proposalUUID, err)
+			// 提交失败不影响签名成功的响应，但记录错误日志
+		} else if job != nil {
+			log.Printf("Proposal %s scheduled for auto-execution at %s (job %s)", proposalUUID, job.NextRunAt.Format(time.RFC3339), job.ID)
 		}
 	}
 
-	// 返回成功响应
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "Proposal signed successfully",
-		"proposal":  proposal,
-		"signature": signature,
-	})
+	return &proposal, &signature, nil
 }
 
 // GetSignatures 获取提案的所有签名
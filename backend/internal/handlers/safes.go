@@ -8,7 +8,6 @@ import (
 	"log"
 	"math/big"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -16,14 +15,15 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"web3-enterprise-multisig/internal/blockchain"
 	"web3-enterprise-multisig/internal/database"
 	"web3-enterprise-multisig/internal/models"
 	"web3-enterprise-multisig/internal/validators"
+	"web3-enterprise-multisig/internal/workflow"
 )
 
 // GetSafes 获取用户的 Safe 钱包列表 - 简化版本，直接查询数据库
@@ -211,6 +211,16 @@ func CreateSafe(c *gin.Context) {
 		return
 	}
 
+	// 验证链ID已在链注册表中配置，否则无法监听和执行
+	if _, err := blockchain.DefaultChainRegistry().Get(int64(req.ChainID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "不支持的链ID",
+			"code":    "UNSUPPORTED_CHAIN",
+			"details": err.Error(),
+		})
+		return
+	}
+
 	// 验证所有者地址格式
 	for _, owner := range req.Owners {
 		if len(owner) != 42 {
@@ -423,6 +433,7 @@ func UpdateSafe(c *gin.Context) {
 		Name        string `json:"name" validate:"omitempty,min=1,max=255"`
 		Description string `json:"description" validate:"max=1000"`
 		Status      string `json:"status" validate:"omitempty,oneof=active inactive frozen"`
+		AutoExecute *bool  `json:"auto_execute"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -454,6 +465,9 @@ func UpdateSafe(c *gin.Context) {
 	if req.Status != "" {
 		updates["status"] = req.Status
 	}
+	if req.AutoExecute != nil {
+		updates["auto_execute"] = *req.AutoExecute
+	}
 
 	if len(updates) > 0 {
 		if err := database.DB.Model(&safe).Updates(updates).Error; err != nil {
@@ -493,17 +507,22 @@ func GetSafeNonce(c *gin.Context) {
 		return
 	}
 
-	// 连接到区块链 - 使用环境变量中的RPC URL
-	rpcURL := os.Getenv("ETHEREUM_RPC_URL")
-	if rpcURL == "" {
-		rpcURL = "https://sepolia.infura.io/v3/2051fcdf4e21463aa06f161d13a0a5f4" // fallback to .env value
+	// 连接到Safe所在链 - 从链注册表获取RPC配置
+	chain, err := blockchain.DefaultChainRegistry().Get(int64(safe.ChainID))
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "Chain not configured",
+			"code":    "CHAIN_NOT_CONFIGURED",
+			"details": err.Error(),
+		})
+		return
 	}
 	log.Printf("=== GetSafeNonce API调试 ===")
-	log.Printf("使用RPC URL: %s", rpcURL)
+	log.Printf("使用链: %s (%d)", chain.Name, chain.ChainID)
 	log.Printf("Safe ID: %s", safeUUID)
 	log.Printf("Safe地址: %s", safe.Address)
 	
-	client, err := ethclient.Dial(rpcURL)
+	client, err := chain.Dial(c.Request.Context())
 	if err != nil {
 		log.Printf("❌ 连接区块链失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -576,3 +595,166 @@ func GetSafeNonce(c *gin.Context) {
 		"chain_id": safe.ChainID,
 	})
 }
+
+// GetSafeNonceQueue 获取Safe按nonce排序的待执行提案队列
+// 路由: GET /api/v1/safes/:safeId/queue
+// 同一nonce上有多个提案时标记为冲突；nonce已被链上越过的提案标记为stale，由对账器处理
+func GetSafeNonceQueue(c *gin.Context) {
+	safeUUID, err := uuid.Parse(c.Param("safeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid safe ID",
+			"code":  "INVALID_SAFE_ID",
+		})
+		return
+	}
+
+	queue, err := workflow.GetSafeNonceQueue(safeUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Safe not found",
+				"code":  "SAFE_NOT_FOUND",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load Safe nonce queue",
+			"code":    "NONCE_QUEUE_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"queue": queue,
+	})
+}
+
+// GetSafeBalances 获取Safe的资产余额（原生币和已配置的ERC-20代币）
+// 路由: GET /api/v1/safes/:safeId/balances?refresh=true
+// 余额来自快照缓存，超过刷新间隔或refresh=true时从链上重新读取
+func GetSafeBalances(c *gin.Context) {
+	safeUUID, err := uuid.Parse(c.Param("safeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid safe ID",
+			"code":  "INVALID_SAFE_ID",
+		})
+		return
+	}
+
+	var safe models.Safe
+	if err := database.DB.First(&safe, safeUUID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Safe not found",
+			"code":  "SAFE_NOT_FOUND",
+		})
+		return
+	}
+
+	forceRefresh := c.Query("refresh") == "true"
+	balanceService := blockchain.NewBalanceService(database.DB, blockchain.DefaultChainRegistry())
+	snapshots, err := balanceService.GetSafeBalances(c.Request.Context(), &safe, forceRefresh)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "Failed to fetch balances",
+			"code":    "BALANCE_FETCH_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	balances := make([]gin.H, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		balances = append(balances, gin.H{
+			"token_address": snapshot.TokenAddress,
+			"symbol":        snapshot.Symbol,
+			"decimals":      snapshot.Decimals,
+			"balance":       snapshot.Balance,
+			"formatted":     blockchain.FormatTokenAmount(parseStringToBigInt(snapshot.Balance), uint8(snapshot.Decimals)),
+			"is_native":     snapshot.IsNative(),
+			"fetched_at":    snapshot.FetchedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"safe_id":  safe.ID,
+		"address":  safe.Address,
+		"chain_id": safe.ChainID,
+		"balances": balances,
+	})
+}
+
+// GetSafeHistory 获取Safe的链上交易历史
+// 路由: GET /api/v1/safes/:safeId/history?page=1&limit=20&event_type=execution_success
+// 数据由历史索引器写入，包括在其他客户端执行的交易、原生币入账和ERC-20转账
+func GetSafeHistory(c *gin.Context) {
+	safeUUID, err := uuid.Parse(c.Param("safeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid safe ID",
+			"code":  "INVALID_SAFE_ID",
+		})
+		return
+	}
+
+	var safe models.Safe
+	if err := database.DB.First(&safe, safeUUID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Safe not found",
+			"code":  "SAFE_NOT_FOUND",
+		})
+		return
+	}
+
+	// 分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.SafeHistoryEvent{}).Where("safe_id = ?", safe.ID)
+	if eventType := c.Query("event_type"); eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var events []models.SafeHistoryEvent
+	if err := query.Order("block_number DESC, log_index DESC").
+		Offset(offset).Limit(limit).
+		Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch safe history",
+			"code":  "FETCH_ERROR",
+		})
+		return
+	}
+
+	// 索引进度，便于前端提示历史数据是否已同步到最新
+	var lastIndexedBlock *uint64
+	var checkpoint models.SafeIndexCheckpoint
+	if err := database.DB.Where("safe_id = ?", safe.ID).First(&checkpoint).Error; err == nil {
+		lastIndexedBlock = &checkpoint.LastIndexedBlock
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"safe_id":            safe.ID,
+		"address":            safe.Address,
+		"chain_id":           safe.ChainID,
+		"history":            events,
+		"last_indexed_block": lastIndexedBlock,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
@@ -0,0 +1,660 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"web3-enterprise-multisig/internal/blockchain"
+	"web3-enterprise-multisig/internal/database"
+	"web3-enterprise-multisig/internal/models"
+	"web3-enterprise-multisig/internal/services"
+	"web3-enterprise-multisig/internal/validators"
+	"web3-enterprise-multisig/internal/workflow"
+)
+
+// Safe Transaction Service兼容接口
+// 路径和JSON结构与官方交易服务一致，Safe SDK脚本（api-kit）将txServiceUrl指向本服务即可提交和确认交易；
+// 认证仍使用JWT（api-kit的apiKey会作为Bearer token发送）
+
+const (
+	txServiceDefaultLimit = 20
+	txServiceMaxLimit     = 100
+)
+
+// txServicePage 交易服务的分页响应
+type txServicePage struct {
+	Count    int64       `json:"count"`
+	Next     *string     `json:"next"`
+	Previous *string     `json:"previous"`
+	Results  interface{} `json:"results"`
+}
+
+// txServiceSafeInfo GET /safes/{address}/ 响应
+type txServiceSafeInfo struct {
+	Address         string   `json:"address"`
+	Nonce           string   `json:"nonce"`
+	Threshold       int      `json:"threshold"`
+	Owners          []string `json:"owners"`
+	MasterCopy      string   `json:"masterCopy"`
+	Modules         []string `json:"modules"`
+	FallbackHandler string   `json:"fallbackHandler"`
+	Guard           string   `json:"guard"`
+	Version         string   `json:"version"`
+}
+
+// txServiceConfirmation 所有者确认
+type txServiceConfirmation struct {
+	Owner           string    `json:"owner"`
+	SubmissionDate  time.Time `json:"submissionDate"`
+	TransactionHash *string   `json:"transactionHash"`
+	Signature       string    `json:"signature"`
+	SignatureType   string    `json:"signatureType"`
+}
+
+// txServiceMultisigTransaction 多签交易
+type txServiceMultisigTransaction struct {
+	Safe                  string                  `json:"safe"`
+	To                    string                  `json:"to"`
+	Value                 string                  `json:"value"`
+	Data                  *string                 `json:"data"`
+	Operation             uint8                   `json:"operation"`
+	GasToken              string                  `json:"gasToken"`
+	SafeTxGas             *big.Int                `json:"safeTxGas"`
+	BaseGas               *big.Int                `json:"baseGas"`
+	GasPrice              string                  `json:"gasPrice"`
+	RefundReceiver        string                  `json:"refundReceiver"`
+	Nonce                 string                  `json:"nonce"`
+	ExecutionDate         *time.Time              `json:"executionDate"`
+	SubmissionDate        time.Time               `json:"submissionDate"`
+	Modified              time.Time               `json:"modified"`
+	BlockNumber           *int64                  `json:"blockNumber"`
+	TransactionHash       *string                 `json:"transactionHash"`
+	SafeTxHash            string                  `json:"safeTxHash"`
+	Proposer              *string                 `json:"proposer"`
+	Executor              *string                 `json:"executor"`
+	IsExecuted            bool                    `json:"isExecuted"`
+	IsSuccessful          *bool                   `json:"isSuccessful"`
+	EthGasPrice           *string                 `json:"ethGasPrice"`
+	MaxFeePerGas          *string                 `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas  *string                 `json:"maxPriorityFeePerGas"`
+	GasUsed               *int64                  `json:"gasUsed"`
+	Fee                   *string                 `json:"fee"`
+	Origin                *string                 `json:"origin"`
+	DataDecoded           interface{}             `json:"dataDecoded"`
+	ConfirmationsRequired int                     `json:"confirmationsRequired"`
+	Confirmations         []txServiceConfirmation `json:"confirmations"`
+	Trusted               bool                    `json:"trusted"`
+	Signatures            *string                 `json:"signatures"`
+}
+
+// GetTxServiceSafe Safe信息
+// 路由: GET /api/v1/safes/:safeId/ （:safeId为Safe地址）
+func GetTxServiceSafe(c *gin.Context) {
+	safe, ok := loadTxServiceSafe(c, "safe.info.view")
+	if !ok {
+		return
+	}
+
+	info := txServiceSafeInfo{
+		Address:         common.HexToAddress(safe.Address).Hex(),
+		Nonce:           "0",
+		Threshold:       safe.Threshold,
+		Owners:          checksumAddresses(safe.Owners),
+		MasterCopy:      common.Address{}.Hex(),
+		Modules:         []string{},
+		FallbackHandler: common.Address{}.Hex(),
+		Guard:           common.Address{}.Hex(),
+	}
+	if safe.Nonce != nil {
+		info.Nonce = strconv.FormatInt(*safe.Nonce, 10)
+	}
+	if safe.SafeVersion != nil {
+		info.Version = *safe.SafeVersion
+	}
+
+	// 链上读取失败时退回数据库中对账得到的配置
+	state, setup, err := workflow.ReadSafeChainInfo(safe)
+	if err != nil {
+		log.Printf("⚠️ [交易服务] 读取Safe %s 链上配置失败，使用数据库记录: %v", safe.Address, err)
+	} else {
+		info.Nonce = strconv.FormatUint(state.Nonce, 10)
+		info.Threshold = int(state.Threshold)
+		info.Owners = make([]string, 0, len(state.Owners))
+		for _, owner := range state.Owners {
+			info.Owners = append(info.Owners, owner.Hex())
+		}
+		info.MasterCopy = setup.MasterCopy.Hex()
+		info.FallbackHandler = setup.FallbackHandler.Hex()
+		info.Guard = setup.Guard.Hex()
+		for _, module := range setup.Modules {
+			info.Modules = append(info.Modules, module.Hex())
+		}
+		if setup.Version != "" {
+			info.Version = setup.Version
+		}
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// GetTxServiceMultisigTransactions Safe的多签交易列表，按nonce倒序
+// 路由: GET /api/v1/safes/:safeId/multisig-transactions/?limit=&offset=&nonce=&executed=
+func GetTxServiceMultisigTransactions(c *gin.Context) {
+	safe, ok := loadTxServiceSafe(c, "safe.proposal.view")
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(txServiceDefaultLimit)))
+	if limit <= 0 || limit > txServiceMaxLimit {
+		limit = txServiceDefaultLimit
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := database.DB.Model(&models.Proposal{}).Where("safe_id = ?", safe.ID)
+	if nonce := c.Query("nonce"); nonce != "" {
+		value, err := strconv.ParseInt(nonce, 10, 64)
+		if err != nil {
+			respondTxServiceError(c, http.StatusBadRequest, "INVALID_NONCE", "nonce must be an integer")
+			return
+		}
+		query = query.Where("nonce = ?", value)
+	}
+	switch c.Query("executed") {
+	case "true":
+		query = query.Where("status IN ? AND block_number IS NOT NULL", []string{"confirmed", "failed"})
+	case "false":
+		query = query.Where("NOT (status IN ? AND block_number IS NOT NULL)", []string{"confirmed", "failed"})
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		respondTxServiceError(c, http.StatusInternalServerError, "FETCH_ERROR", "Failed to count transactions")
+		return
+	}
+
+	var proposals []models.Proposal
+	if err := query.Preload("Creator").
+		Preload("Signatures", "status = ?", "valid").Preload("Signatures.Signer").
+		Order("nonce DESC NULLS LAST, created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&proposals).Error; err != nil {
+		respondTxServiceError(c, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch transactions")
+		return
+	}
+
+	resolved, err := workflow.ResolveProposalSafeTxs(safe, proposals)
+	if err != nil {
+		respondTxServiceError(c, http.StatusBadGateway, "CHAIN_ERROR", fmt.Sprintf("Failed to build Safe transactions: %v", err))
+		return
+	}
+
+	results := make([]txServiceMultisigTransaction, 0, len(resolved))
+	for _, item := range resolved {
+		results = append(results, toTxServiceTransaction(safe, item))
+	}
+
+	page := txServicePage{Count: total, Results: results}
+	if int64(offset+limit) < total {
+		next := txServicePageURL(c, limit, offset+limit)
+		page.Next = &next
+	}
+	if offset > 0 {
+		previous := txServicePageURL(c, limit, max(offset-limit, 0))
+		page.Previous = &previous
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ProposeTxServiceTransaction 提交多签交易，附带签名时记录为sender的确认
+// 路由: POST /api/v1/safes/:safeId/multisig-transactions/
+// 与创建提案接口一样检查safe.proposal.create权限和Safe策略
+func ProposeTxServiceTransaction(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req validators.TxServiceProposeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondTxServiceError(c, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("Invalid request format: %v", err))
+		return
+	}
+	if err := validators.ValidateStruct(req); err != nil {
+		respondTxServiceError(c, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	safe, ok := loadTxServiceSafe(c, "safe.proposal.view")
+	if !ok {
+		return
+	}
+
+	nonce, err := req.Nonce.Int64()
+	if err != nil {
+		respondTxServiceError(c, http.StatusBadRequest, "INVALID_NONCE", "nonce must be an integer")
+		return
+	}
+	tx := workflow.ExternalSafeTx{
+		To:                      req.To,
+		Value:                   req.Value.String(),
+		Operation:               req.Operation,
+		SafeTxGas:               req.SafeTxGas.String(),
+		BaseGas:                 req.BaseGas.String(),
+		GasPrice:                req.GasPrice.String(),
+		Nonce:                   nonce,
+		ContractTransactionHash: req.ContractTransactionHash,
+		Sender:                  req.Sender,
+	}
+	if req.Data != nil {
+		tx.Data = *req.Data
+	}
+	if req.GasToken != nil {
+		tx.GasToken = *req.GasToken
+	}
+	if req.RefundReceiver != nil {
+		tx.RefundReceiver = *req.RefundReceiver
+	}
+	if req.Signature != nil {
+		tx.Signature = *req.Signature
+	}
+	if req.Origin != nil {
+		tx.Origin = *req.Origin
+	}
+
+	proposal, err := workflow.BuildExternalProposal(safe, userID.(uuid.UUID), tx)
+	if err != nil {
+		respondTxServiceWorkflowError(c, err)
+		return
+	}
+
+	permissionService := services.NewPermissionService(database.DB)
+	hasPermission, err := permissionService.CheckPermission(c.Request.Context(), services.PermissionRequest{
+		UserID:         userID.(uuid.UUID),
+		SafeID:         safe.ID,
+		PermissionCode: "safe.proposal.create",
+		Context: map[string]interface{}{
+			"proposal_type": proposal.ProposalType,
+			"value":         proposal.Value,
+			"to_address":    *proposal.ToAddress,
+			"operation":     proposal.Operation,
+		},
+	})
+	if err != nil {
+		respondTxServiceError(c, http.StatusInternalServerError, "PERMISSION_CHECK_FAILED", err.Error())
+		return
+	}
+	if !hasPermission.Granted {
+		respondTxServiceError(c, http.StatusForbidden, "PERMISSION_DENIED", hasPermission.DenialReason)
+		return
+	}
+
+	policyService := services.NewPolicyService(database.DB)
+	policyResult, err := policyService.ValidatePolicies(c.Request.Context(), services.PolicyValidationRequest{
+		SafeID:       safe.ID,
+		ProposalType: proposal.ProposalType,
+		ToAddress:    proposal.ToAddress,
+		Value:        proposal.Value,
+		Data:         proposal.Data,
+		UserID:       userID.(uuid.UUID),
+		Context: map[string]interface{}{
+			"action":    "create_proposal",
+			"operation": proposal.Operation,
+			"source":    "tx_service",
+		},
+	})
+	if err != nil {
+		respondTxServiceError(c, http.StatusInternalServerError, "POLICY_VALIDATION_FAILED", err.Error())
+		return
+	}
+	if !policyResult.Passed {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":  "提案不符合策略要求",
+			"code":   "POLICY_VIOLATION",
+			"detail": strings.Join(append(policyResult.FailedPolicies, policyResult.ValidationErrors...), "; "),
+			"details": gin.H{
+				"failed_policies":   policyResult.FailedPolicies,
+				"required_actions":  policyResult.RequiredActions,
+				"validation_errors": policyResult.ValidationErrors,
+			},
+		})
+		return
+	}
+
+	saved, created, err := workflow.ProposeExternalTransaction(safe, proposal, tx.Sender, tx.Signature)
+	if err != nil {
+		respondTxServiceWorkflowError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, toTxServiceTransaction(safe, workflow.ProposalSafeTx{
+		Proposal:   saved,
+		SafeTx:     nil,
+		SafeTxHash: common.HexToHash(*saved.SafeTxHash),
+	}))
+}
+
+// GetTxServiceMultisigTransaction 按safeTxHash获取多签交易
+// 路由: GET /api/v1/multisig-transactions/:safeTxHash/
+func GetTxServiceMultisigTransaction(c *gin.Context) {
+	proposal, ok := loadTxServiceProposal(c, "safe.proposal.view")
+	if !ok {
+		return
+	}
+
+	resolved, err := workflow.ResolveProposalSafeTxs(&proposal.Safe, []models.Proposal{*proposal})
+	if err != nil {
+		respondTxServiceError(c, http.StatusBadGateway, "CHAIN_ERROR", fmt.Sprintf("Failed to build Safe transaction: %v", err))
+		return
+	}
+	if len(resolved) == 0 {
+		respondTxServiceError(c, http.StatusBadGateway, "CHAIN_ERROR", "Failed to build Safe transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, toTxServiceTransaction(&proposal.Safe, resolved[0]))
+}
+
+// GetTxServiceConfirmations 多签交易的所有者确认列表
+// 路由: GET /api/v1/multisig-transactions/:safeTxHash/confirmations/
+func GetTxServiceConfirmations(c *gin.Context) {
+	proposal, ok := loadTxServiceProposal(c, "safe.proposal.view")
+	if !ok {
+		return
+	}
+
+	confirmations := toTxServiceConfirmations(proposal.Signatures)
+	c.JSON(http.StatusOK, txServicePage{
+		Count:   int64(len(confirmations)),
+		Results: confirmations,
+	})
+}
+
+// ConfirmTxServiceTransaction 提交所有者确认，签名者从签名中解析
+// 路由: POST /api/v1/multisig-transactions/:safeTxHash/confirmations/
+func ConfirmTxServiceTransaction(c *gin.Context) {
+	var req validators.TxServiceConfirmationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondTxServiceError(c, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("Invalid request format: %v", err))
+		return
+	}
+	if err := validators.ValidateStruct(req); err != nil {
+		respondTxServiceError(c, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	proposal, ok := loadTxServiceProposal(c, "safe.proposal.sign")
+	if !ok {
+		return
+	}
+
+	confirmation, err := workflow.ConfirmExternalTransaction(proposal, req.Signature)
+	if err != nil {
+		respondTxServiceWorkflowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"signature": confirmation.SignatureData,
+	})
+}
+
+// loadTxServiceSafe 按路径中的Safe地址加载Safe并检查当前用户权限，失败时已写入响应
+func loadTxServiceSafe(c *gin.Context, permissionCode string) (*models.Safe, bool) {
+	address := c.Param("safeId")
+	if !common.IsHexAddress(address) {
+		respondTxServiceError(c, http.StatusBadRequest, "INVALID_SAFE_ADDRESS", fmt.Sprintf("Invalid Safe address %q", address))
+		return nil, false
+	}
+
+	var safe models.Safe
+	if err := database.DB.Where("LOWER(address) = ?", strings.ToLower(address)).First(&safe).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondTxServiceError(c, http.StatusNotFound, "SAFE_NOT_FOUND", "Safe not found")
+		} else {
+			respondTxServiceError(c, http.StatusInternalServerError, "DATABASE_ERROR", "Database error")
+		}
+		return nil, false
+	}
+
+	if !checkTxServicePermission(c, safe.ID, permissionCode) {
+		return nil, false
+	}
+	return &safe, true
+}
+
+// loadTxServiceProposal 按路径中的safeTxHash加载提案并检查当前用户权限，失败时已写入响应
+func loadTxServiceProposal(c *gin.Context, permissionCode string) (*models.Proposal, bool) {
+	safeTxHash := c.Param("safeTxHash")
+	if decoded, err := hexutil.Decode(safeTxHash); err != nil || len(decoded) != common.HashLength {
+		respondTxServiceError(c, http.StatusBadRequest, "INVALID_SAFE_TX_HASH", fmt.Sprintf("Invalid safeTxHash %q", safeTxHash))
+		return nil, false
+	}
+
+	proposal, err := workflow.FindProposalBySafeTxHash(safeTxHash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondTxServiceError(c, http.StatusNotFound, "TRANSACTION_NOT_FOUND", "No MultisigTransaction matches the given query.")
+		} else {
+			respondTxServiceError(c, http.StatusInternalServerError, "DATABASE_ERROR", "Database error")
+		}
+		return nil, false
+	}
+
+	if !checkTxServicePermission(c, proposal.SafeID, permissionCode) {
+		return nil, false
+	}
+	return proposal, true
+}
+
+func checkTxServicePermission(c *gin.Context, safeID uuid.UUID, permissionCode string) bool {
+	userID, _ := c.Get("userID")
+	permissionService := services.NewPermissionService(database.DB)
+	result, err := permissionService.CheckPermission(c.Request.Context(), services.PermissionRequest{
+		UserID:         userID.(uuid.UUID),
+		SafeID:         safeID,
+		PermissionCode: permissionCode,
+		Context:        map[string]interface{}{},
+	})
+	if err != nil {
+		respondTxServiceError(c, http.StatusInternalServerError, "PERMISSION_CHECK_FAILED", err.Error())
+		return false
+	}
+	if !result.Granted {
+		respondTxServiceError(c, http.StatusForbidden, "PERMISSION_DENIED", result.DenialReason)
+		return false
+	}
+	return true
+}
+
+// respondTxServiceError 返回错误，detail字段供Safe SDK（api-kit）读取错误信息
+func respondTxServiceError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error":  message,
+		"code":   code,
+		"detail": message,
+	})
+}
+
+// respondTxServiceWorkflowError 将提交/确认交易的错误映射为HTTP响应
+func respondTxServiceWorkflowError(c *gin.Context, err error) {
+	if respondPolicyViolation(c, err) {
+		return
+	}
+
+	var verificationErr *blockchain.SignatureVerificationError
+	switch {
+	case errors.As(err, &verificationErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "签名验证失败",
+			"code":    "INVALID_SIGNATURE",
+			"detail":  verificationErr.Error(),
+			"details": verificationErr,
+		})
+	case errors.Is(err, workflow.ErrDelegateCallNotAllowed):
+		respondTxServiceError(c, http.StatusBadRequest, "INVALID_SAFE_TX", err.Error())
+	case errors.Is(err, workflow.ErrInvalidSafeTx), errors.Is(err, workflow.ErrInvalidProposalNonce):
+		respondTxServiceError(c, http.StatusBadRequest, "INVALID_TRANSACTION", err.Error())
+	case errors.Is(err, workflow.ErrSafeTxHashMismatch):
+		respondTxServiceError(c, http.StatusUnprocessableEntity, "SAFE_TX_HASH_MISMATCH", err.Error())
+	case errors.Is(err, workflow.ErrSignerNotOwner):
+		respondTxServiceError(c, http.StatusUnprocessableEntity, "NOT_SAFE_OWNER", err.Error())
+	case errors.Is(err, workflow.ErrSignerNotRegistered):
+		respondTxServiceError(c, http.StatusUnprocessableEntity, "SIGNER_NOT_REGISTERED", err.Error())
+	case errors.Is(err, workflow.ErrAlreadyConfirmed):
+		respondTxServiceError(c, http.StatusUnprocessableEntity, "ALREADY_CONFIRMED", err.Error())
+	case errors.Is(err, workflow.ErrTransactionNotConfirmable):
+		respondTxServiceError(c, http.StatusUnprocessableEntity, "TRANSACTION_NOT_CONFIRMABLE", err.Error())
+	default:
+		respondTxServiceError(c, http.StatusInternalServerError, "TX_SERVICE_ERROR", err.Error())
+	}
+}
+
+// toTxServiceTransaction 将提案转换为交易服务格式，SafeTx为nil时按提案字段展示
+func toTxServiceTransaction(safe *models.Safe, item workflow.ProposalSafeTx) txServiceMultisigTransaction {
+	proposal := item.Proposal
+	result := txServiceMultisigTransaction{
+		Safe:                  common.HexToAddress(safe.Address).Hex(),
+		Value:                 proposal.Value,
+		Operation:             uint8(proposal.Operation),
+		GasToken:              common.HexToAddress(proposal.GasToken).Hex(),
+		SafeTxGas:             decimalOrZero(proposal.SafeTxGas),
+		BaseGas:               decimalOrZero(proposal.BaseGas),
+		GasPrice:              decimalOrZero(proposal.GasPrice).String(),
+		RefundReceiver:        common.HexToAddress(proposal.RefundReceiver).Hex(),
+		SubmissionDate:        proposal.CreatedAt,
+		Modified:              proposal.UpdatedAt,
+		BlockNumber:           proposal.BlockNumber,
+		TransactionHash:       proposal.TxHash,
+		SafeTxHash:            item.SafeTxHash.Hex(),
+		EthGasPrice:           proposal.TxGasPrice,
+		MaxFeePerGas:          proposal.TxMaxFeePerGas,
+		MaxPriorityFeePerGas:  proposal.TxMaxPriorityFeePerGas,
+		GasUsed:               proposal.GasUsed,
+		ConfirmationsRequired: proposal.RequiredSignatures,
+		Confirmations:         toTxServiceConfirmations(proposal.Signatures),
+		Trusted:               true,
+	}
+	if proposal.ToAddress != nil {
+		result.To = common.HexToAddress(*proposal.ToAddress).Hex()
+	}
+	if proposal.Data != nil && strings.TrimPrefix(*proposal.Data, "0x") != "" {
+		data := *proposal.Data
+		result.Data = &data
+	}
+	if proposal.Nonce != nil {
+		result.Nonce = strconv.FormatInt(*proposal.Nonce, 10)
+	}
+	if proposal.Creator.WalletAddress != nil && common.IsHexAddress(*proposal.Creator.WalletAddress) {
+		proposer := common.HexToAddress(*proposal.Creator.WalletAddress).Hex()
+		result.Proposer = &proposer
+	}
+
+	// 以实际构建的SafeTx为准（如添加所有者等管理操作的调用数据在执行时编码）
+	if safeTx := item.SafeTx; safeTx != nil {
+		result.To = safeTx.To.Hex()
+		result.Value = safeTx.Value.String()
+		result.Data = nil
+		if len(safeTx.Data) > 0 {
+			data := hexutil.Encode(safeTx.Data)
+			result.Data = &data
+		}
+		result.Operation = safeTx.Operation
+		result.Nonce = safeTx.Nonce.String()
+	}
+
+	// 交易上链（成功或revert）才视为已执行；executed状态仅表示已广播
+	if proposal.BlockNumber != nil && (proposal.Status == "confirmed" || proposal.Status == "failed") {
+		result.IsExecuted = true
+		successful := proposal.Status == "confirmed"
+		result.IsSuccessful = &successful
+		result.ExecutionDate = proposal.ConfirmedAt
+		if result.ExecutionDate == nil {
+			result.ExecutionDate = proposal.FailedAt
+		}
+	}
+	if proposal.Description != nil {
+		if _, origin, found := strings.Cut(*proposal.Description, "\norigin: "); found {
+			result.Origin = &origin
+		}
+	}
+	return result
+}
+
+// toTxServiceConfirmations 将有效签名转换为所有者确认，签名类型按交易服务的命名
+func toTxServiceConfirmations(signatures []models.Signature) []txServiceConfirmation {
+	confirmations := make([]txServiceConfirmation, 0, len(signatures))
+	for _, signature := range signatures {
+		if signature.Status != "valid" {
+			continue
+		}
+		owner := ""
+		if signature.Signer.WalletAddress != nil {
+			owner = common.HexToAddress(*signature.Signer.WalletAddress).Hex()
+		}
+		signatureType := "EOA"
+		switch signature.SignatureType {
+		case blockchain.SafeSignatureEthSign:
+			signatureType = "ETH_SIGN"
+		case blockchain.SafeSignatureContract:
+			signatureType = "CONTRACT_SIGNATURE"
+		}
+		confirmations = append(confirmations, txServiceConfirmation{
+			Owner:          owner,
+			SubmissionDate: signature.SignedAt,
+			Signature:      signature.SignatureData,
+			SignatureType:  signatureType,
+		})
+	}
+	return confirmations
+}
+
+// txServicePageURL 生成分页链接
+func txServicePageURL(c *gin.Context, limit, offset int) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := c.GetHeader("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+
+	query := c.Request.URL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	pageURL := url.URL{Scheme: scheme, Host: c.Request.Host, Path: c.Request.URL.Path, RawQuery: query.Encode()}
+	return pageURL.String()
+}
+
+func checksumAddresses(addresses []string) []string {
+	result := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		result = append(result, common.HexToAddress(address).Hex())
+	}
+	return result
+}
+
+func decimalOrZero(value string) *big.Int {
+	parsed, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return big.NewInt(0)
+	}
+	return parsed
+}
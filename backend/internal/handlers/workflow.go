@@ -70,6 +70,9 @@ func ApproveProposal(c *gin.Context) {
 	}
 
 	if err := workflow.ApproveProposal(proposalUUID, userID.(uuid.UUID), req.SignatureData); err != nil {
+		if respondPolicyViolation(c, err) || respondSignatureVerificationError(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 			"code":  "APPROVAL_ERROR",
@@ -82,11 +85,9 @@ func ApproveProposal(c *gin.Context) {
 	})
 }
 
-// ExecuteProposal 执行提案
+// ExecuteProposal 提交提案执行任务
 func ExecuteProposal(c *gin.Context) {
-	proposalID := c.Param("proposalId")
-
-	proposalUUID, err := uuid.Parse(proposalID)
+	proposalUUID, err := uuid.Parse(c.Param("proposalId"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid proposal ID",
@@ -95,15 +96,5 @@ func ExecuteProposal(c *gin.Context) {
 		return
 	}
 
-	if err := workflow.ExecuteProposal(proposalUUID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-			"code":  "EXECUTION_ERROR",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Proposal executed successfully",
-	})
+	enqueueProposalExecution(c, proposalUUID)
 }
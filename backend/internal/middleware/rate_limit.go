@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateWindow 单个客户端在当前时间窗口内的请求计数
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimit 按客户端IP限制请求频率，每个window内最多limit次，超出返回429
+// 计数保存在进程内存中，多实例部署时每个实例单独计数
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	var (
+		mu        sync.Mutex
+		clients   = make(map[string]*rateWindow)
+		lastSweep = time.Now()
+	)
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		// 定期清理已过期的窗口，避免记录随客户端数量增长
+		if now.Sub(lastSweep) > window {
+			for key, w := range clients {
+				if now.Sub(w.start) >= window {
+					delete(clients, key)
+				}
+			}
+			lastSweep = now
+		}
+
+		w, ok := clients[ip]
+		if !ok || now.Sub(w.start) >= window {
+			w = &rateWindow{start: now}
+			clients[ip] = w
+		}
+		w.count++
+		allowed := w.count <= limit
+		retryAfter := w.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "Too many requests, please try again later",
+				"code":  "RATE_LIMITED",
+			})
+			return
+		}
+		c.Next()
+	}
+}
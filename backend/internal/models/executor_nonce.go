@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 执行账户nonce状态
+const (
+	ExecutorNonceReserved  = "reserved"
+	ExecutorNonceBroadcast = "broadcast"
+	ExecutorNonceConfirmed = "confirmed"
+	ExecutorNonceReleased  = "released"
+)
+
+// ExecutorNonce 执行账户nonce分配记录
+type ExecutorNonce struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ChainID    int64      `json:"chain_id" gorm:"not null"`
+	Address    string     `json:"address" gorm:"size:42;not null"`
+	Nonce      int64      `json:"nonce" gorm:"not null"`
+	Status     string     `json:"status" gorm:"size:20;not null;default:'reserved'"`
+	ProposalID *uuid.UUID `json:"proposal_id,omitempty" gorm:"type:uuid"`
+	TxHash     *string    `json:"tx_hash,omitempty" gorm:"size:66"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate GORM钩子：创建前生成UUID
+func (n *ExecutorNonce) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (ExecutorNonce) TableName() string {
+	return "executor_nonces"
+}
@@ -34,13 +34,28 @@ type Proposal struct {
 	// 提案基本信息
 	Title        string  `json:"title" gorm:"size:255;not null"`
 	Description  *string `json:"description" gorm:"type:text"`
-	ProposalType string  `json:"proposal_type" gorm:"size:50;not null;check:proposal_type IN ('transfer','contract_call','add_owner','remove_owner','change_threshold')"`
+	ProposalType string  `json:"proposal_type" gorm:"size:50;not null;check:proposal_type IN ('transfer','contract_call','add_owner','remove_owner','change_threshold','batch','token_transfer','rejection')"`
 
 	// 交易参数
 	ToAddress *string `json:"to_address" gorm:"size:42"` // 可选，某些操作类型不需要
 	Value     string  `json:"value" gorm:"type:decimal(78,0);default:0"`
 	Data      *string `json:"data" gorm:"type:text"`
 
+	// SafeTx参数（参与safeTxHash计算，需与签名时一致）
+	Operation      int    `json:"operation" gorm:"default:0;check:operation IN (0,1)"` // 0=CALL, 1=DELEGATECALL
+	SafeTxGas      string `json:"safe_tx_gas" gorm:"type:decimal(78,0);default:0"`
+	BaseGas        string `json:"base_gas" gorm:"type:decimal(78,0);default:0"`
+	GasPrice       string `json:"gas_price" gorm:"type:decimal(78,0);default:0"`
+	GasToken       string `json:"gas_token" gorm:"size:42;default:0x0000000000000000000000000000000000000000"`
+	RefundReceiver string `json:"refund_receiver" gorm:"size:42;default:0x0000000000000000000000000000000000000000"`
+
+	// ERC-20代币转账参数（仅token_transfer提案，ToAddress为代币合约地址）
+	TokenAddress   *string `json:"token_address,omitempty" gorm:"size:42"`
+	TokenSymbol    *string `json:"token_symbol,omitempty" gorm:"size:32"`
+	TokenDecimals  *int    `json:"token_decimals,omitempty"`
+	TokenRecipient *string `json:"token_recipient,omitempty" gorm:"size:42"`
+	TokenAmount    *string `json:"token_amount,omitempty" gorm:"type:decimal(78,0)"` // 代币最小单位
+
 	// 签名管理
 	RequiredSignatures int `json:"required_signatures" gorm:"not null"`
 	CurrentSignatures  int `json:"current_signatures" gorm:"default:0"`
@@ -53,6 +68,16 @@ type Proposal struct {
 	BlockNumber *int64  `json:"block_number"`           // 执行区块号
 	GasUsed     *int64  `json:"gas_used"`               // 消耗的Gas
 
+	// 执行交易参数（执行账户提交execTransaction的外层交易）
+	TxType                 *int    `json:"tx_type,omitempty"`                                               // 0=legacy, 2=EIP-1559
+	TxGasEstimate          *int64  `json:"tx_gas_estimate,omitempty"`                                       // eth_estimateGas估算值
+	TxGasLimit             *int64  `json:"tx_gas_limit,omitempty"`                                          // 加缓冲后的gas上限
+	TxMaxFeePerGas         *string `json:"tx_max_fee_per_gas,omitempty" gorm:"type:decimal(78,0)"`          // wei
+	TxMaxPriorityFeePerGas *string `json:"tx_max_priority_fee_per_gas,omitempty" gorm:"type:decimal(78,0)"` // wei
+	TxGasPrice             *string `json:"tx_gas_price,omitempty" gorm:"type:decimal(78,0)"`                // legacy交易，wei
+	TxBaseFee              *string `json:"tx_base_fee,omitempty" gorm:"type:decimal(78,0)"`                 // 提交时的baseFee，wei
+	TxNonce                *int64  `json:"tx_nonce,omitempty"`                                              // 执行账户nonce，加速/取消时复用
+
 	// 时间戳
 	CreatedAt   time.Time  `json:"created_at" gorm:"default:now()"`
 	ApprovedAt  *time.Time `json:"approved_at"`  // 获得足够签名的时间
@@ -64,6 +89,12 @@ type Proposal struct {
 	// 失败信息
 	FailureReason *string `json:"failure_reason" gorm:"type:text"` // 失败原因描述
 
+	// 拒绝信息（链上拒绝：同nonce的0值自调用交易上链后原提案转为rejected）
+	RejectsProposalID *uuid.UUID `json:"rejects_proposal_id,omitempty" gorm:"type:uuid"` // 拒绝提案指向的原提案
+	RejectionReason   *string    `json:"rejection_reason,omitempty" gorm:"type:text"`    // 拒绝原因
+	RejectedBy        *uuid.UUID `json:"rejected_by,omitempty" gorm:"type:uuid"`         // 发起拒绝的用户
+	RejectedAt        *time.Time `json:"rejected_at,omitempty"`                          // 被拒绝的时间
+
 	// 审计字段
 	Nonce      *int64  `json:"nonce"`                       // Safe nonce (签名时使用的nonce)
 	SafeTxHash *string `json:"safe_tx_hash" gorm:"size:66"` // Safe交易哈希
@@ -72,6 +103,27 @@ type Proposal struct {
 	Safe       Safe        `json:"Safe" gorm:"foreignKey:SafeID"`
 	Creator    User        `json:"creator" gorm:"foreignKey:CreatedBy"`
 	Signatures []Signature `json:"signatures"`
+	BatchItems []ProposalBatchItem `json:"batch_items,omitempty" gorm:"foreignKey:ProposalID"` // 批量提案的子交易
+}
+
+// Safe操作类型
+const (
+	OperationCall         = 0
+	OperationDelegateCall = 1
+)
+
+// ProposalBatchItem 批量提案子交易模型
+// 子交易按ItemIndex顺序编码进MultiSendCallOnly调用，整体原子执行，状态与父提案同步
+type ProposalBatchItem struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProposalID uuid.UUID `json:"proposal_id" gorm:"not null;index"`
+	ItemIndex  int       `json:"item_index" gorm:"not null"`
+	ToAddress  string    `json:"to_address" gorm:"size:42;not null"`
+	Value      string    `json:"value" gorm:"type:decimal(78,0);default:0"` // 以wei为单位
+	Data       *string   `json:"data" gorm:"type:text"`
+	Status     string    `json:"status" gorm:"size:20;not null;default:pending;check:status IN ('pending','executed','confirmed','failed')"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 // Signature 签名模型
@@ -95,6 +147,7 @@ type Signature struct {
 func (Policy) TableName() string    { return "policies" }
 func (Proposal) TableName() string  { return "proposals" }
 func (Signature) TableName() string { return "signatures" }
+func (ProposalBatchItem) TableName() string { return "proposal_batch_items" }
 
 // BeforeCreate hooks
 func (p *Policy) BeforeCreate(tx *gorm.DB) error {
@@ -118,6 +171,13 @@ func (s *Signature) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (i *ProposalBatchItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
 // Proposal 业务方法
 func (p *Proposal) IsApproved() bool {
 	return p.CurrentSignatures >= p.RequiredSignatures
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OfflineSigningBundle 导出的离线签名包记录
+// 导入签名文件时按checksum找到导出时的提案和safeTxHash，拒绝不属于该签名包的签名
+type OfflineSigningBundle struct {
+	ID         uuid.UUID             `json:"id" gorm:"type:uuid;primary_key"`
+	Checksum   string                `json:"checksum" gorm:"size:66;not null"`
+	ExportedBy uuid.UUID             `json:"exported_by" gorm:"type:uuid;not null"`
+	Signer     *string               `json:"signer" gorm:"size:42"`
+	Entries    PostgreSQLStringArray `json:"entries" gorm:"type:text[];not null"` // 排序后的 "proposal_id:safe_tx_hash"（小写）
+	CreatedAt  time.Time             `json:"created_at"`
+}
+
+// TableName 指定表名
+func (OfflineSigningBundle) TableName() string {
+	return "offline_signing_bundles"
+}
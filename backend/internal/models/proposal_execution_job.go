@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 提案执行任务状态
+const (
+	ExecutionJobQueued    = "queued"
+	ExecutionJobRunning   = "running"
+	ExecutionJobSucceeded = "succeeded"
+	ExecutionJobFailed    = "failed"
+)
+
+// 提案执行任务来源
+const (
+	ExecutionJobSourceManual = "manual"
+	ExecutionJobSourceAuto   = "auto"
+)
+
+// ProposalExecutionJob 提案异步执行任务
+// 执行请求先持久化为任务，由后台worker领取执行，瞬时RPC错误按重试配置重试
+type ProposalExecutionJob struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProposalID   uuid.UUID  `json:"proposal_id" gorm:"type:uuid;not null"`
+	RequestedBy  *uuid.UUID `json:"requested_by,omitempty" gorm:"type:uuid"`
+	Status       string     `json:"status" gorm:"size:20;not null;default:'queued'"`
+	Source       string     `json:"source" gorm:"size:20;not null;default:'manual'"`
+	Attempts     int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts  int        `json:"max_attempts" gorm:"not null;default:4"`
+	TxHash       *string    `json:"tx_hash,omitempty" gorm:"size:66"`
+	ErrorCode    *string    `json:"error_code,omitempty" gorm:"size:50"`
+	LastError    *string    `json:"last_error,omitempty" gorm:"type:text"`
+	ErrorDetails JSONMap    `json:"error_details,omitempty" gorm:"type:jsonb"`
+	NextRunAt    time.Time  `json:"next_run_at"`
+	LockedAt     *time.Time `json:"locked_at,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate GORM钩子：创建前生成UUID
+func (j *ProposalExecutionJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (ProposalExecutionJob) TableName() string {
+	return "proposal_execution_jobs"
+}
+
+// IsFinished 任务是否已结束
+func (j *ProposalExecutionJob) IsFinished() bool {
+	return j.Status == ExecutionJobSucceeded || j.Status == ExecutionJobFailed
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 执行交易动作
+const (
+	ExecutionTxActionExecute = "execute"
+	ExecutionTxActionSpeedUp = "speed_up"
+	ExecutionTxActionCancel  = "cancel"
+)
+
+// 执行交易状态
+const (
+	ExecutionTxStatusPending  = "pending"
+	ExecutionTxStatusMined    = "mined"
+	ExecutionTxStatusReplaced = "replaced"
+)
+
+// ProposalExecutionTx 提案执行交易记录
+// 原始执行交易与其加速/取消交易共用同一个执行账户nonce，最终只有一笔上链
+type ProposalExecutionTx struct {
+	ID                   uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProposalID           uuid.UUID  `json:"proposal_id" gorm:"type:uuid;not null"`
+	ChainID              int64      `json:"chain_id" gorm:"not null"`
+	TxHash               string     `json:"tx_hash" gorm:"size:66;not null;uniqueIndex"`
+	FromAddress          string     `json:"from_address" gorm:"size:42;not null"`
+	Nonce                int64      `json:"nonce" gorm:"not null"`
+	Action               string     `json:"action" gorm:"size:20;not null"`
+	ReplacesTxHash       *string    `json:"replaces_tx_hash,omitempty" gorm:"size:66"`
+	TxType               int        `json:"tx_type" gorm:"not null"`
+	GasLimit             int64      `json:"gas_limit" gorm:"not null"`
+	MaxFeePerGas         *string    `json:"max_fee_per_gas,omitempty" gorm:"type:decimal(78,0)"`
+	MaxPriorityFeePerGas *string    `json:"max_priority_fee_per_gas,omitempty" gorm:"type:decimal(78,0)"`
+	GasPrice             *string    `json:"gas_price,omitempty" gorm:"type:decimal(78,0)"`
+	Status               string     `json:"status" gorm:"size:20;not null;default:'pending'"`
+	CreatedBy            *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt            time.Time  `json:"created_at"`
+	MinedAt              *time.Time `json:"mined_at,omitempty"`
+}
+
+// BeforeCreate GORM钩子：创建前生成UUID
+func (t *ProposalExecutionTx) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (ProposalExecutionTx) TableName() string {
+	return "proposal_execution_txs"
+}
@@ -18,6 +18,9 @@ type Safe struct {
     Status      string         `json:"status" gorm:"default:active;check:status IN ('active','inactive','frozen')"`
     CreatedBy     uuid.UUID  `json:"created_by" gorm:"not null"`
     TransactionID *uuid.UUID `json:"transaction_id,omitempty" gorm:"type:uuid"` // 关联的交易记录ID
+    Nonce         *int64     `json:"nonce,omitempty"`          // 最近一次对账读取的链上nonce
+    LastSyncedAt  *time.Time `json:"last_synced_at,omitempty"` // 最近一次与链上状态对账的时间
+    AutoExecute   bool       `json:"auto_execute" gorm:"not null;default:false"` // 签名达到阈值后自动提交执行任务
     CreatedAt     time.Time  `json:"created_at"`
     UpdatedAt     time.Time  `json:"updated_at"`
 
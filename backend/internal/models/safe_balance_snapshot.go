@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NativeTokenAddress 原生币在余额快照中使用的地址
+const NativeTokenAddress = "0x0000000000000000000000000000000000000000"
+
+// SafeBalanceSnapshot Safe资产余额快照
+// 每个Safe每种资产一行，超过刷新间隔后由余额服务重新读取链上余额
+type SafeBalanceSnapshot struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SafeID       uuid.UUID `json:"safe_id" gorm:"type:uuid;not null;uniqueIndex:safe_balance_snapshots_unique_token"`
+	ChainID      int64     `json:"chain_id" gorm:"not null"`
+	TokenAddress string    `json:"token_address" gorm:"size:42;not null;uniqueIndex:safe_balance_snapshots_unique_token"`
+	Symbol       string    `json:"symbol" gorm:"size:32;not null"`
+	Decimals     int       `json:"decimals" gorm:"not null;default:18"`
+	Balance      string    `json:"balance" gorm:"type:decimal(78,0);not null;default:0"` // 最小单位
+	FetchedAt    time.Time `json:"fetched_at" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// BeforeCreate GORM钩子：创建前生成UUID
+func (s *SafeBalanceSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (SafeBalanceSnapshot) TableName() string {
+	return "safe_balance_snapshots"
+}
+
+// IsNative 是否为原生币余额
+func (s *SafeBalanceSnapshot) IsNative() bool {
+	return s.TokenAddress == NativeTokenAddress
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Safe历史事件类型
+const (
+	HistoryEventExecutionSuccess = "execution_success"
+	HistoryEventExecutionFailure = "execution_failure"
+	HistoryEventSafeReceived     = "safe_received"
+	HistoryEventERC20TransferIn  = "erc20_transfer_in"
+	HistoryEventERC20TransferOut = "erc20_transfer_out"
+)
+
+// SafeHistoryEvent Safe链上历史事件
+// 由历史索引器从链上日志写入，包括在其他客户端执行的交易和入账
+type SafeHistoryEvent struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SafeID         uuid.UUID  `json:"safe_id" gorm:"type:uuid;not null"`
+	ChainID        int64      `json:"chain_id" gorm:"not null"`
+	EventType      string     `json:"event_type" gorm:"size:30;not null"`
+	TxHash         string     `json:"tx_hash" gorm:"size:66;not null"`
+	LogIndex       uint       `json:"log_index" gorm:"not null"`
+	BlockNumber    uint64     `json:"block_number" gorm:"not null"`
+	BlockTimestamp *time.Time `json:"block_timestamp"`
+	SafeTxHash     *string    `json:"safe_tx_hash" gorm:"size:66"`
+	ProposalID     *uuid.UUID `json:"proposal_id" gorm:"type:uuid"`
+	TokenAddress   *string    `json:"token_address" gorm:"size:42"`
+	FromAddress    *string    `json:"from_address" gorm:"size:42"`
+	ToAddress      *string    `json:"to_address" gorm:"size:42"`
+	Value          string     `json:"value" gorm:"type:decimal(78,0);not null;default:0"`
+	Payment        *string    `json:"payment" gorm:"type:decimal(78,0)"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// BeforeCreate GORM钩子：创建前生成UUID
+func (e *SafeHistoryEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (SafeHistoryEvent) TableName() string {
+	return "safe_history_events"
+}
+
+// SafeIndexCheckpoint Safe历史索引进度
+type SafeIndexCheckpoint struct {
+	SafeID           uuid.UUID `json:"safe_id" gorm:"type:uuid;primary_key"`
+	ChainID          int64     `json:"chain_id" gorm:"not null"`
+	LastIndexedBlock uint64    `json:"last_indexed_block" gorm:"not null"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SafeIndexCheckpoint) TableName() string {
+	return "safe_index_checkpoints"
+}
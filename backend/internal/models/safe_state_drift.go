@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Safe状态对账触发来源
+const (
+	DriftSourceExecution = "execution"
+	DriftSourceScheduled = "scheduled"
+)
+
+// SafeStateDrift Safe owners/threshold漂移审计记录
+// 对账发现数据库与链上状态不一致时写入，保存变更前后的值
+type SafeStateDrift struct {
+	ID           uuid.UUID             `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SafeID       uuid.UUID             `json:"safe_id" gorm:"type:uuid;not null"`
+	ChainID      int64                 `json:"chain_id" gorm:"not null"`
+	Source       string                `json:"source" gorm:"size:20;not null"`
+	ProposalID   *uuid.UUID            `json:"proposal_id" gorm:"type:uuid"`
+	OldOwners    PostgreSQLStringArray `json:"old_owners" gorm:"type:text[];not null"`
+	NewOwners    PostgreSQLStringArray `json:"new_owners" gorm:"type:text[];not null"`
+	OldThreshold int                   `json:"old_threshold" gorm:"not null"`
+	NewThreshold int                   `json:"new_threshold" gorm:"not null"`
+	OldNonce     *int64                `json:"old_nonce"`
+	NewNonce     int64                 `json:"new_nonce" gorm:"not null"`
+	DetectedAt   time.Time             `json:"detected_at"`
+}
+
+// BeforeCreate GORM钩子：创建前生成UUID
+func (d *SafeStateDrift) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (SafeStateDrift) TableName() string {
+	return "safe_state_drifts"
+}
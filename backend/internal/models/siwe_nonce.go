@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SiweNonce SIWE(EIP-4361)登录挑战nonce
+// 由服务端签发，单次使用，过期后失效
+type SiweNonce struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Nonce         string     `json:"nonce" gorm:"uniqueIndex;not null;size:64"`
+	WalletAddress string     `json:"wallet_address" gorm:"not null;size:42"`
+	Domain        string     `json:"domain" gorm:"not null;size:255"`
+	URI           string     `json:"uri" gorm:"column:uri;not null;size:500"`
+	ChainID       int64      `json:"chain_id" gorm:"not null"`
+	IssuedAt      time.Time  `json:"issued_at" gorm:"not null"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt        *time.Time `json:"used_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// BeforeCreate GORM钩子：创建前生成UUID
+func (n *SiweNonce) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (SiweNonce) TableName() string {
+	return "siwe_nonces"
+}
+
+// IsExpired 检查nonce是否已过期
+func (n *SiweNonce) IsExpired(now time.Time) bool {
+	return now.After(n.ExpiresAt)
+}
@@ -13,6 +13,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -60,10 +62,13 @@ type PolicyValidationRequest struct {
 	ProposalID   *uuid.UUID             `json:"proposal_id,omitempty"`
 	ProposalType string                 `json:"proposal_type"`
 	ToAddress    *string                `json:"to_address,omitempty"`
-	Value        string                 `json:"value"`
+	Value        string                 `json:"value"`                   // ETH为wei，代币转账为代币最小单位
+	TokenAddress *string                `json:"token_address,omitempty"` // 代币转账的代币合约地址，nil表示ETH
 	Data         *string                `json:"data,omitempty"`
 	UserID       uuid.UUID              `json:"user_id"`
 	Context      map[string]interface{} `json:"context"`
+	// ExecutionType 写入policy_execution_logs的执行类型，为空时按validation记录
+	ExecutionType string `json:"execution_type,omitempty"`
 }
 
 // PolicyValidationResult 策略验证结果
@@ -109,6 +114,58 @@ type PolicyExecutionLog struct {
 	ExecutionDurationMs int                    `json:"execution_duration_ms"`
 }
 
+// 策略执行日志类型
+const (
+	PolicyExecutionValidation  = "validation"  // 创建提案时的验证
+	PolicyExecutionEnforcement = "enforcement" // 签名和执行前的强制检查
+)
+
+// 策略强制检查阶段
+const (
+	PolicyStageSign    = "sign"
+	PolicyStageExecute = "execute"
+)
+
+// PolicyViolationError 策略强制检查未通过
+type PolicyViolationError struct {
+	ProposalID uuid.UUID
+	Stage      string
+	Result     *PolicyValidationResult
+}
+
+func (e *PolicyViolationError) Error() string {
+	reasons := make([]string, 0, len(e.Result.FailedPolicies)+len(e.Result.ValidationErrors))
+	for _, policyResult := range e.Result.PolicyResults {
+		if !policyResult.Passed {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", policyResult.PolicyName, policyResult.FailureReason))
+		}
+	}
+	reasons = append(reasons, e.Result.ValidationErrors...)
+	return fmt.Sprintf("提案 %s 未通过策略检查 (%s): %s", e.ProposalID, e.Stage, strings.Join(reasons, "; "))
+}
+
+// Details 返回给客户端的结构化失败原因
+func (e *PolicyViolationError) Details() map[string]interface{} {
+	failedResults := []SinglePolicyResult{}
+	for _, policyResult := range e.Result.PolicyResults {
+		if !policyResult.Passed {
+			failedResults = append(failedResults, policyResult)
+		}
+	}
+
+	details := map[string]interface{}{
+		"stage":             e.Stage,
+		"failed_policies":   e.Result.FailedPolicies,
+		"required_actions":  e.Result.RequiredActions,
+		"validation_errors": e.Result.ValidationErrors,
+		"policy_results":    failedResults,
+	}
+	if e.Result.EstimatedDelay != nil {
+		details["estimated_delay_seconds"] = int64(e.Result.EstimatedDelay.Seconds())
+	}
+	return details
+}
+
 // =====================================================
 // 核心策略验证方法
 // =====================================================
@@ -172,6 +229,173 @@ func (s *PolicyService) ValidatePolicies(ctx context.Context, req PolicyValidati
 	return result, nil
 }
 
+// EnforcePolicies 在签名或执行前按提案ID重新运行所有激活策略
+// 时间锁定和依赖签名数量的审批阈值只有带提案ID时才能判断，因此不能只在创建时验证。
+// 签名阶段签名数和等待时间本身尚未满足，审批阈值、角色审批和时间锁定的失败不阻止签名；
+// 策略解析出错时按未通过处理。未通过时返回 *PolicyViolationError，结果以enforcement类型记录日志
+func (s *PolicyService) EnforcePolicies(ctx context.Context, proposalID uuid.UUID, triggeredBy uuid.UUID, stage string) (*PolicyValidationResult, error) {
+	var proposal models.Proposal
+	if err := s.db.WithContext(ctx).First(&proposal, proposalID).Error; err != nil {
+		return nil, fmt.Errorf("获取提案失败: %w", err)
+	}
+
+	// 链上拒绝提案是0值自调用，只用于使同nonce的原提案失效，不受支出/白名单/时间锁定等策略约束
+	if proposal.ProposalType == "rejection" {
+		return &PolicyValidationResult{
+			SafeID:           proposal.SafeID,
+			ProposalID:       &proposal.ID,
+			Passed:           true,
+			PolicyResults:    []SinglePolicyResult{},
+			FailedPolicies:   []string{},
+			RequiredActions:  []string{},
+			ValidationErrors: []string{},
+		}, nil
+	}
+
+	req, err := s.policyRequestFromProposal(ctx, &proposal)
+	if err != nil {
+		return nil, err
+	}
+	req.UserID = triggeredBy
+	req.ExecutionType = PolicyExecutionEnforcement
+	req.Context = map[string]interface{}{
+		"action":    stage + "_proposal",
+		"stage":     stage,
+		"operation": proposal.Operation,
+	}
+
+	result, err := s.ValidatePolicies(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if stage == PolicyStageSign {
+		// 重新计算签名阶段的结果，只保留签名时即可判断的策略
+		result.Passed = true
+		result.FailedPolicies = []string{}
+		result.RequiredActions = []string{}
+		for _, policyResult := range result.PolicyResults {
+			if policyResult.Passed || isSignatureGatePolicy(policyResult.PolicyType) {
+				continue
+			}
+			result.Passed = false
+			result.FailedPolicies = append(result.FailedPolicies, policyResult.PolicyName)
+			if policyResult.RequiredAction != "" {
+				result.RequiredActions = append(result.RequiredActions, policyResult.RequiredAction)
+			}
+		}
+	}
+
+	if len(result.ValidationErrors) > 0 {
+		result.Passed = false
+	}
+
+	if !result.Passed {
+		return result, &PolicyViolationError{ProposalID: proposalID, Stage: stage, Result: result}
+	}
+	return result, nil
+}
+
+// EarliestExecutionTime 根据激活的时间锁定策略计算提案最早允许执行的时间
+// 只采用time_lock策略的结果且不记录策略日志，没有未满足的时间锁定时返回当前时间
+func (s *PolicyService) EarliestExecutionTime(ctx context.Context, proposalID uuid.UUID) (time.Time, error) {
+	var proposal models.Proposal
+	if err := s.db.WithContext(ctx).First(&proposal, proposalID).Error; err != nil {
+		return time.Time{}, fmt.Errorf("获取提案失败: %w", err)
+	}
+
+	req, err := s.policyRequestFromProposal(ctx, &proposal)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	policies, err := s.getActivePoliciesForSafe(ctx, proposal.SafeID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("获取Safe策略失败: %w", err)
+	}
+
+	now := time.Now()
+	earliest := now
+	for _, policy := range policies {
+		policyResult, err := s.validateSinglePolicy(ctx, policy, req)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("策略 %s 验证失败: %w", policy.Name, err)
+		}
+		if policyResult.PolicyType != "time_lock" {
+			continue
+		}
+		if !policyResult.Passed && policyResult.EstimatedDelay != nil {
+			if allowedAt := now.Add(*policyResult.EstimatedDelay); allowedAt.After(earliest) {
+				earliest = allowedAt
+			}
+		}
+	}
+
+	return earliest, nil
+}
+
+// TimeLockDelay 违规全部来自时间锁定策略时返回剩余等待时间
+func (e *PolicyViolationError) TimeLockDelay() (time.Duration, bool) {
+	if len(e.Result.ValidationErrors) > 0 {
+		return 0, false
+	}
+
+	var delay time.Duration
+	for _, policyResult := range e.Result.PolicyResults {
+		if policyResult.Passed {
+			continue
+		}
+		if policyResult.PolicyType != "time_lock" || policyResult.EstimatedDelay == nil {
+			return 0, false
+		}
+		if *policyResult.EstimatedDelay > delay {
+			delay = *policyResult.EstimatedDelay
+		}
+	}
+	return delay, delay > 0
+}
+
+// isSignatureGatePolicy 依赖签名收集或等待时间的策略，只在执行前阻止
+func isSignatureGatePolicy(policyType string) bool {
+	return policyType == "approval_threshold" || policyType == "role_based_approval" || policyType == "time_lock"
+}
+
+// policyRequestFromProposal 按创建时的口径从已保存的提案构建策略验证请求
+// 代币转账按代币数量和接收方校验，批量交易按子交易总额校验
+func (s *PolicyService) policyRequestFromProposal(ctx context.Context, proposal *models.Proposal) (PolicyValidationRequest, error) {
+	req := PolicyValidationRequest{
+		SafeID:       proposal.SafeID,
+		ProposalID:   &proposal.ID,
+		ProposalType: proposal.ProposalType,
+		ToAddress:    proposal.ToAddress,
+		Value:        proposal.Value,
+		Data:         proposal.Data,
+	}
+
+	switch proposal.ProposalType {
+	case "token_transfer":
+		if proposal.TokenAmount != nil {
+			req.Value = *proposal.TokenAmount
+		}
+		req.ToAddress = proposal.TokenRecipient
+		req.TokenAddress = proposal.TokenAddress
+	case "batch":
+		var items []models.ProposalBatchItem
+		if err := s.db.WithContext(ctx).Where("proposal_id = ?", proposal.ID).Find(&items).Error; err != nil {
+			return req, fmt.Errorf("获取批量子交易失败: %w", err)
+		}
+		total := big.NewInt(0)
+		for _, item := range items {
+			if value, ok := new(big.Int).SetString(item.Value, 10); ok {
+				total.Add(total, value)
+			}
+		}
+		req.Value = total.String()
+	}
+
+	return req, nil
+}
+
 // =====================================================
 // 策略模板管理方法
 // =====================================================
@@ -533,7 +757,12 @@ func (s *PolicyService) validateTimeLockPolicy(ctx context.Context, params map[s
 }
 
 // validateSpendingLimitPolicy 验证支出限额策略
+// daily_limit 限制ETH（wei），批量交易按子交易总额计入；代币按 token_limits[代币地址].daily_limit 以代币最小单位分别限额
 func (s *PolicyService) validateSpendingLimitPolicy(ctx context.Context, params map[string]interface{}, req PolicyValidationRequest, result *SinglePolicyResult) (*SinglePolicyResult, error) {
+	if req.TokenAddress != nil {
+		return s.validateTokenSpendingLimit(ctx, params, req, result)
+	}
+
 	// 解析策略参数
 	dailyLimitStr, ok := params["daily_limit"].(string)
 	if !ok {
@@ -583,14 +812,269 @@ func (s *PolicyService) validateSpendingLimitPolicy(ctx context.Context, params
 	return result, nil
 }
 
+// validateTokenSpendingLimit 按代币验证支出限额
+// 代币金额与ETH单位不同，不计入ETH的daily_limit；未配置该代币限额时放行并记录说明
+func (s *PolicyService) validateTokenSpendingLimit(ctx context.Context, params map[string]interface{}, req PolicyValidationRequest, result *SinglePolicyResult) (*SinglePolicyResult, error) {
+	tokenAddress := strings.ToLower(*req.TokenAddress)
+	result.ValidationDetails["token_address"] = tokenAddress
+
+	var tokenLimit map[string]interface{}
+	if tokenLimits, ok := params["token_limits"].(map[string]interface{}); ok {
+		for address, limit := range tokenLimits {
+			if strings.EqualFold(address, tokenAddress) {
+				tokenLimit, _ = limit.(map[string]interface{})
+				break
+			}
+		}
+	}
+	if tokenLimit == nil {
+		result.ValidationDetails["note"] = "未配置该代币的支出限额"
+		return result, nil
+	}
+
+	dailyLimitStr, ok := tokenLimit["daily_limit"].(string)
+	if !ok {
+		result.Passed = false
+		result.FailureReason = "代币支出限额参数格式错误"
+		return result, nil
+	}
+
+	dailyLimit, ok := new(big.Int).SetString(dailyLimitStr, 10)
+	if !ok {
+		result.Passed = false
+		result.FailureReason = "无效的代币日限额参数"
+		return result, nil
+	}
+
+	// 解析代币数量
+	amount, ok := new(big.Int).SetString(req.Value, 10)
+	if !ok {
+		result.Passed = false
+		result.FailureReason = "无效的代币数量"
+		return result, nil
+	}
+
+	// 获取该代币今日已支出数量
+	todaySpent, err := s.getTodayTokenSpentAmount(ctx, req.SafeID, tokenAddress)
+	if err != nil {
+		result.Passed = false
+		result.FailureReason = fmt.Sprintf("获取今日代币支出失败: %v", err)
+		return result, nil
+	}
+
+	totalAfterSpending := new(big.Int).Add(todaySpent, amount)
+	if totalAfterSpending.Cmp(dailyLimit) > 0 {
+		result.Passed = false
+		result.FailureReason = fmt.Sprintf("超出代币日支出限额，代币: %s，限额: %s，已支出: %s，本次: %s",
+			tokenAddress, dailyLimitStr, todaySpent.String(), req.Value)
+		result.RequiredAction = "等待明日重置或申请临时提额"
+	}
+
+	result.ValidationDetails["daily_limit"] = dailyLimitStr
+	result.ValidationDetails["today_spent"] = todaySpent.String()
+	result.ValidationDetails["transaction_value"] = req.Value
+	result.ValidationDetails["total_after_spending"] = totalAfterSpending.String()
+
+	return result, nil
+}
+
 // validateRoleBasedApprovalPolicy 验证基于角色的审批策略
+// 规则示例: {"condition": {"proposal_type": "transfer", "amount": {"gte": "10000000000000000000"}}, "required_roles": ["safe_admin", "safe_treasurer"]}
+// condition中的各项需同时满足，proposal_type为governance时匹配add_owner/remove_owner/change_threshold；
+// amount按ETH（wei）比较，批量交易取子交易总额，代币转账不匹配；带token时只匹配该代币的转账，按代币最小单位比较，
+// 如 {"amount": {"token": "0xA0b8...", "gte": "1000000000"}}；
+// required_roles中每出现一次表示需要一个该角色成员的有效签名，也可写为 {"role": "safe_admin", "count": 2}
 func (s *PolicyService) validateRoleBasedApprovalPolicy(ctx context.Context, params map[string]interface{}, req PolicyValidationRequest, result *SinglePolicyResult) (*SinglePolicyResult, error) {
-	// TODO: 实现基于角色的审批策略验证
-	// 这需要与权限服务集成，检查用户角色和权限
-	result.ValidationDetails["note"] = "基于角色的审批策略验证待实现"
+	rules, ok := params["rules"].([]interface{})
+	if !ok {
+		result.Passed = false
+		result.FailureReason = "角色审批策略参数格式错误"
+		return result, nil
+	}
+
+	// 解析交易金额
+	value, ok := new(big.Int).SetString(req.Value, 10)
+	if !ok {
+		result.Passed = false
+		result.FailureReason = "无效的交易金额"
+		return result, nil
+	}
+
+	// 查找匹配的规则，默认规则仅在没有其他规则匹配时使用
+	var matchedRule, defaultRule map[string]interface{}
+	for _, ruleInterface := range rules {
+		rule, ok := ruleInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		condition, ok := rule["condition"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, isDefault := condition["default"]; isDefault {
+			defaultRule = rule
+			continue
+		}
+		if matchRoleApprovalCondition(condition, req.ProposalType, value, req.TokenAddress) {
+			matchedRule = rule
+			break
+		}
+	}
+	if matchedRule == nil {
+		matchedRule = defaultRule
+	}
+	if matchedRule == nil {
+		// 没有规则适用于该提案，不需要角色审批
+		result.ValidationDetails["note"] = "没有适用于该提案的角色审批规则"
+		return result, nil
+	}
+
+	requiredRoles, err := parseRequiredRoles(matchedRule["required_roles"])
+	if err != nil {
+		result.Passed = false
+		result.FailureReason = err.Error()
+		return result, nil
+	}
+
+	result.ValidationDetails["matched_rule"] = matchedRule
+	result.ValidationDetails["required_roles"] = requiredRoles
+	result.ValidationDetails["transaction_value"] = req.Value
+	if req.TokenAddress != nil {
+		result.ValidationDetails["token_address"] = strings.ToLower(*req.TokenAddress)
+	}
+
+	// 创建时还没有签名，只提示需要的角色
+	if req.ProposalID == nil {
+		result.RequiredAction = fmt.Sprintf("需要以下角色签名: %s", formatRoleCounts(requiredRoles))
+		return result, nil
+	}
+
+	signedRoles, err := s.countSignaturesByRole(ctx, *req.ProposalID, req.SafeID)
+	if err != nil {
+		result.Passed = false
+		result.FailureReason = "统计签名者角色失败"
+		return result, nil
+	}
+	result.ValidationDetails["signed_roles"] = signedRoles
+
+	missingRoles := make(map[string]int)
+	for role, required := range requiredRoles {
+		if signedRoles[role] < required {
+			missingRoles[role] = required - signedRoles[role]
+		}
+	}
+
+	if len(missingRoles) > 0 {
+		result.Passed = false
+		result.FailureReason = fmt.Sprintf("缺少以下角色的签名: %s", formatRoleCounts(missingRoles))
+		result.RequiredAction = fmt.Sprintf("需要以下角色补充签名: %s", formatRoleCounts(missingRoles))
+		result.ValidationDetails["missing_roles"] = missingRoles
+	}
+
 	return result, nil
 }
 
+// countSignaturesByRole 按签名者在Safe中的角色统计提案的有效签名
+// 角色来自safe_member_roles，未分配角色或角色已过期的签名者不计入
+func (s *PolicyService) countSignaturesByRole(ctx context.Context, proposalID, safeID uuid.UUID) (map[string]int, error) {
+	var signerIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&models.Signature{}).
+		Where("proposal_id = ? AND status = ?", proposalID, "valid").
+		Distinct().Pluck("signer_id", &signerIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询提案签名失败: %w", err)
+	}
+
+	permissionService := NewPermissionService(s.db)
+	counts := make(map[string]int)
+	for _, signerID := range signerIDs {
+		role, err := permissionService.GetUserSafeRole(ctx, signerID, safeID)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil || (role.ExpiresAt != nil && role.ExpiresAt.Before(time.Now())) {
+			continue
+		}
+		counts[role.Role]++
+	}
+
+	return counts, nil
+}
+
+// matchRoleApprovalCondition 检查角色审批规则条件，所有给出的条件都需满足
+// tokenAddress为nil时value是ETH金额（wei），否则是该代币的数量
+func matchRoleApprovalCondition(condition map[string]interface{}, proposalType string, value *big.Int, tokenAddress *string) bool {
+	if expectedType, exists := condition["proposal_type"].(string); exists {
+		if expectedType == "governance" {
+			if proposalType != "add_owner" && proposalType != "remove_owner" && proposalType != "change_threshold" {
+				return false
+			}
+		} else if expectedType != proposalType {
+			return false
+		}
+	}
+
+	if amountCondition, exists := condition["amount"].(map[string]interface{}); exists {
+		// 代币数量与wei单位不同，金额条件只比较同一种资产
+		conditionToken, _ := amountCondition["token"].(string)
+		if conditionToken == "" {
+			if tokenAddress != nil {
+				return false
+			}
+		} else if tokenAddress == nil || !strings.EqualFold(conditionToken, *tokenAddress) {
+			return false
+		}
+
+		gteStr, _ := amountCondition["gte"].(string)
+		gte, ok := new(big.Int).SetString(gteStr, 10)
+		if !ok || value.Cmp(gte) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseRequiredRoles 解析required_roles为 角色 -> 所需签名数
+func parseRequiredRoles(raw interface{}) (map[string]int, error) {
+	items, ok := raw.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("角色审批规则缺少required_roles")
+	}
+
+	required := make(map[string]int)
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			required[v]++
+		case map[string]interface{}:
+			role, _ := v["role"].(string)
+			count, _ := v["count"].(float64)
+			if role == "" || count < 1 {
+				return nil, fmt.Errorf("无效的required_roles配置: %v", v)
+			}
+			required[role] += int(count)
+		default:
+			return nil, fmt.Errorf("无效的required_roles配置: %v", v)
+		}
+	}
+	return required, nil
+}
+
+// formatRoleCounts 格式化角色签名数，如 "safe_admin x1, safe_treasurer x1"
+func formatRoleCounts(roles map[string]int) string {
+	names := make([]string, 0, len(roles))
+	for role := range roles {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, role := range names {
+		parts = append(parts, fmt.Sprintf("%s x%d", role, roles[role]))
+	}
+	return strings.Join(parts, ", ")
+}
+
 // 辅助方法
 func (s *PolicyService) getPolicyTemplate(ctx context.Context, templateID uuid.UUID) (*PolicyTemplate, error) {
 	var template struct {
@@ -644,15 +1128,16 @@ func (s *PolicyService) validatePolicyParameters(schema map[string]interface{},
 	return nil
 }
 
+// getTodaySpentAmount 获取今日已确认提案支出的ETH总额（wei）
+// 转账和合约调用按提案携带的ETH计算，批量交易按子交易金额之和计算（外层MultiSend交易的value为0）；代币转账不携带ETH
 func (s *PolicyService) getTodaySpentAmount(ctx context.Context, safeID uuid.UUID) (*big.Int, error) {
-	// 获取今日已确认的转账提案总金额
 	today := time.Now().Truncate(24 * time.Hour)
 	tomorrow := today.Add(24 * time.Hour)
 
 	var proposals []models.Proposal
 	err := s.db.WithContext(ctx).
-		Where("safe_id = ? AND proposal_type = ? AND status IN (?, ?) AND confirmed_at >= ? AND confirmed_at < ?", 
-			safeID, "transfer", "executed", "confirmed", today, tomorrow).
+		Where("safe_id = ? AND proposal_type <> ? AND status IN (?, ?) AND confirmed_at >= ? AND confirmed_at < ?",
+			safeID, "token_transfer", "executed", "confirmed", today, tomorrow).
 		Find(&proposals).Error
 
 	if err != nil {
@@ -660,12 +1145,57 @@ func (s *PolicyService) getTodaySpentAmount(ctx context.Context, safeID uuid.UUI
 	}
 
 	totalSpent := big.NewInt(0)
+	var batchIDs []uuid.UUID
 	for _, proposal := range proposals {
+		if proposal.ProposalType == "batch" {
+			batchIDs = append(batchIDs, proposal.ID)
+			continue
+		}
 		if value, ok := new(big.Int).SetString(proposal.Value, 10); ok {
 			totalSpent.Add(totalSpent, value)
 		}
 	}
 
+	if len(batchIDs) > 0 {
+		var items []models.ProposalBatchItem
+		if err := s.db.WithContext(ctx).Where("proposal_id IN ?", batchIDs).Find(&items).Error; err != nil {
+			return nil, fmt.Errorf("查询今日批量子交易失败: %w", err)
+		}
+		for _, item := range items {
+			if value, ok := new(big.Int).SetString(item.Value, 10); ok {
+				totalSpent.Add(totalSpent, value)
+			}
+		}
+	}
+
+	return totalSpent, nil
+}
+
+func (s *PolicyService) getTodayTokenSpentAmount(ctx context.Context, safeID uuid.UUID, tokenAddress string) (*big.Int, error) {
+	// 获取今日已确认的该代币转账提案总数量
+	today := time.Now().Truncate(24 * time.Hour)
+	tomorrow := today.Add(24 * time.Hour)
+
+	var proposals []models.Proposal
+	err := s.db.WithContext(ctx).
+		Where("safe_id = ? AND proposal_type = ? AND LOWER(token_address) = ? AND status IN (?, ?) AND confirmed_at >= ? AND confirmed_at < ?",
+			safeID, "token_transfer", tokenAddress, "executed", "confirmed", today, tomorrow).
+		Find(&proposals).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("查询今日代币支出记录失败: %w", err)
+	}
+
+	totalSpent := big.NewInt(0)
+	for _, proposal := range proposals {
+		if proposal.TokenAmount == nil {
+			continue
+		}
+		if amount, ok := new(big.Int).SetString(*proposal.TokenAmount, 10); ok {
+			totalSpent.Add(totalSpent, amount)
+		}
+	}
+
 	return totalSpent, nil
 }
 
@@ -696,19 +1226,30 @@ func (s *PolicyService) logPolicyExecution(ctx context.Context, policy models.Po
 		executionResult = "failed"
 	}
 
+	executionType := req.ExecutionType
+	if executionType == "" {
+		executionType = PolicyExecutionValidation
+	}
+
+	// 系统自动触发（如自动执行）时没有操作用户
+	var triggeredBy interface{}
+	if req.UserID != uuid.Nil {
+		triggeredBy = req.UserID
+	}
+
 	// 插入执行日志
 	logRecord := map[string]interface{}{
 		"id":                    uuid.New(),
 		"policy_id":             policy.ID,
 		"proposal_id":           req.ProposalID,
 		"safe_id":               req.SafeID,
-		"execution_type":        "validation",
+		"execution_type":        executionType,
 		"execution_result":      executionResult,
 		"input_parameters":      string(inputParamsJSON),
 		"policy_parameters":     string(policyParamsJSON),
 		"validation_details":    string(validationDetailsJSON),
 		"failure_reason":        result.FailureReason,
-		"triggered_by":          req.UserID,
+		"triggered_by":          triggeredBy,
 		"execution_context":     string(executionContextJSON),
 		"executed_at":           time.Now(),
 		"execution_duration_ms": int(duration.Milliseconds()),
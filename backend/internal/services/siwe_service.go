@@ -0,0 +1,458 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+
+	"web3-enterprise-multisig/internal/models"
+)
+
+const (
+	// siweVersion EIP-4361 当前唯一合法的消息版本
+	siweVersion = "1"
+	// siweNonceTTL 挑战nonce有效期
+	siweNonceTTL = 5 * time.Minute
+	// siweClockSkew 允许的客户端时钟偏差
+	siweClockSkew = 1 * time.Minute
+	// defaultSiweNonceCleanupInterval 过期nonce的默认清理间隔
+	defaultSiweNonceCleanupInterval = time.Hour
+)
+
+// siweFieldKeys EIP-4361 消息中可识别的字段名
+var siweFieldKeys = map[string]bool{
+	"URI":             true,
+	"Version":         true,
+	"Chain ID":        true,
+	"Nonce":           true,
+	"Issued At":       true,
+	"Expiration Time": true,
+	"Not Before":      true,
+	"Request ID":      true,
+}
+
+// SIWE校验错误定义
+var (
+	ErrSiweMalformedMessage = fmt.Errorf("malformed SIWE message")
+	ErrSiweNonceNotFound    = fmt.Errorf("SIWE nonce not found")
+	ErrSiweNonceUsed        = fmt.Errorf("SIWE nonce already used or expired")
+	ErrSiweChainNotAllowed  = fmt.Errorf("SIWE chain ID not supported")
+)
+
+// SiweService Sign-In With Ethereum (EIP-4361) 挑战服务
+// 负责签发一次性nonce、解析SIWE消息并校验domain/URI/链ID/nonce/时间字段
+type SiweService struct {
+	db       *gorm.DB
+	domain   string
+	uri      string
+	chainIDs []int64 // 允许登录的链ID，第一个为未指定链时的默认链
+}
+
+// SiweChallenge 签发给客户端的登录挑战
+type SiweChallenge struct {
+	Nonce     string    `json:"nonce"`
+	Message   string    `json:"message"`
+	Domain    string    `json:"domain"`
+	URI       string    `json:"uri"`
+	ChainID   int64     `json:"chain_id"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SiweMessage 解析后的EIP-4361消息
+type SiweMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// NewSiweService 创建SIWE服务实例
+// domain/URI 从环境变量 SIWE_DOMAIN、SIWE_URI 读取；chainIDs为允许登录的链（通常是链注册表中的所有链），
+// 未传入时从环境变量 CHAIN_ID 读取
+func NewSiweService(db *gorm.DB, chainIDs ...int64) *SiweService {
+	if len(chainIDs) == 0 {
+		chainID := int64(11155111) // 默认Sepolia
+		if v := os.Getenv("CHAIN_ID"); v != "" {
+			if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+				chainID = parsed
+			}
+		}
+		chainIDs = []int64{chainID}
+	}
+
+	domain := os.Getenv("SIWE_DOMAIN")
+	if domain == "" {
+		domain = "localhost:5173"
+	}
+	uri := os.Getenv("SIWE_URI")
+	if uri == "" {
+		uri = "http://" + domain
+	}
+
+	return &SiweService{
+		db:       db,
+		domain:   domain,
+		uri:      uri,
+		chainIDs: chainIDs,
+	}
+}
+
+// chainAllowed 链ID是否允许用于登录
+func (s *SiweService) chainAllowed(chainID int64) bool {
+	for _, allowed := range s.chainIDs {
+		if allowed == chainID {
+			return true
+		}
+	}
+	return false
+}
+
+// IssueChallenge 为钱包地址签发SIWE挑战
+// 生成随机nonce并持久化，返回待签名的完整消息；chainID为0时使用默认链
+func (s *SiweService) IssueChallenge(ctx context.Context, walletAddress string, chainID int64) (*SiweChallenge, error) {
+	if !common.IsHexAddress(walletAddress) {
+		return nil, fmt.Errorf("无效的钱包地址: %s", walletAddress)
+	}
+	if chainID == 0 {
+		chainID = s.chainIDs[0]
+	}
+	if !s.chainAllowed(chainID) {
+		return nil, fmt.Errorf("%w: %d", ErrSiweChainNotAllowed, chainID)
+	}
+
+	nonce, err := generateSiweNonce()
+	if err != nil {
+		return nil, fmt.Errorf("生成nonce失败: %w", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	expiresAt := now.Add(siweNonceTTL)
+	address := common.HexToAddress(walletAddress).Hex()
+
+	record := &models.SiweNonce{
+		Nonce:         nonce,
+		WalletAddress: address,
+		Domain:        s.domain,
+		URI:           s.uri,
+		ChainID:       chainID,
+		IssuedAt:      now,
+		ExpiresAt:     expiresAt,
+	}
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		return nil, fmt.Errorf("保存SIWE nonce失败: %w", err)
+	}
+
+	msg := &SiweMessage{
+		Domain:         s.domain,
+		Address:        address,
+		Statement:      "Sign in to Web3 Enterprise Multisig",
+		URI:            s.uri,
+		Version:        siweVersion,
+		ChainID:        chainID,
+		Nonce:          nonce,
+		IssuedAt:       now,
+		ExpirationTime: &expiresAt,
+	}
+
+	return &SiweChallenge{
+		Nonce:     nonce,
+		Message:   msg.String(),
+		Domain:    s.domain,
+		URI:       s.uri,
+		ChainID:   chainID,
+		IssuedAt:  now,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ValidateMessage 校验SIWE消息字段
+// 检查domain、URI、链ID、地址、版本、时间字段，以及nonce是否由本服务签发且未使用
+func (s *SiweService) ValidateMessage(ctx context.Context, msg *SiweMessage, walletAddress string) error {
+	if msg.Domain != s.domain {
+		return fmt.Errorf("domain不匹配: 期望 %s, 实际 %s", s.domain, msg.Domain)
+	}
+	if msg.URI != s.uri {
+		return fmt.Errorf("URI不匹配: 期望 %s, 实际 %s", s.uri, msg.URI)
+	}
+	if !s.chainAllowed(msg.ChainID) {
+		return fmt.Errorf("%w: %d", ErrSiweChainNotAllowed, msg.ChainID)
+	}
+	if msg.Version != siweVersion {
+		return fmt.Errorf("不支持的SIWE版本: %s", msg.Version)
+	}
+	if !strings.EqualFold(msg.Address, walletAddress) {
+		return fmt.Errorf("消息地址与钱包地址不一致")
+	}
+
+	now := time.Now().UTC()
+	if msg.IssuedAt.After(now.Add(siweClockSkew)) {
+		return fmt.Errorf("issued-at 时间在未来: %s", msg.IssuedAt.Format(time.RFC3339))
+	}
+	if msg.ExpirationTime != nil && now.After(*msg.ExpirationTime) {
+		return fmt.Errorf("消息已过期: %s", msg.ExpirationTime.Format(time.RFC3339))
+	}
+	if msg.NotBefore != nil && now.Add(siweClockSkew).Before(*msg.NotBefore) {
+		return fmt.Errorf("消息尚未生效: %s", msg.NotBefore.Format(time.RFC3339))
+	}
+
+	var record models.SiweNonce
+	if err := s.db.WithContext(ctx).Where("nonce = ?", msg.Nonce).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrSiweNonceNotFound
+		}
+		return fmt.Errorf("查询SIWE nonce失败: %w", err)
+	}
+	if record.UsedAt != nil || record.IsExpired(now) {
+		return ErrSiweNonceUsed
+	}
+	if !strings.EqualFold(record.WalletAddress, walletAddress) {
+		return fmt.Errorf("nonce不属于该钱包地址")
+	}
+	if record.Domain != msg.Domain || record.URI != msg.URI || record.ChainID != msg.ChainID {
+		return fmt.Errorf("消息字段与签发的挑战不一致")
+	}
+	if msg.IssuedAt.Before(record.IssuedAt.Add(-siweClockSkew)) {
+		return fmt.Errorf("issued-at 早于挑战签发时间")
+	}
+
+	return nil
+}
+
+// ConsumeNonce 原子消费nonce，保证每个挑战只能登录一次
+func (s *SiweService) ConsumeNonce(ctx context.Context, nonce string) error {
+	result := s.db.WithContext(ctx).Model(&models.SiweNonce{}).
+		Where("nonce = ? AND used_at IS NULL AND expires_at > ?", nonce, time.Now().UTC()).
+		Update("used_at", time.Now().UTC())
+	if result.Error != nil {
+		return fmt.Errorf("消费SIWE nonce失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSiweNonceUsed
+	}
+	return nil
+}
+
+// CleanupExpiredNonces 清理过期的nonce记录
+func (s *SiweService) CleanupExpiredNonces(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Where("expires_at < ?", time.Now().UTC().Add(-24*time.Hour)).
+		Delete(&models.SiweNonce{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("清理过期SIWE nonce失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// SiweNonceCleaner 定时清理过期的SIWE nonce，挑战接口无需登录，记录不能无限增长
+type SiweNonceCleaner struct {
+	service  *SiweService
+	interval time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewSiweNonceCleaner 创建nonce清理器，间隔读取环境变量 SIWE_NONCE_CLEANUP_INTERVAL（默认1小时）
+func NewSiweNonceCleaner(db *gorm.DB) *SiweNonceCleaner {
+	interval := defaultSiweNonceCleanupInterval
+	if v := os.Getenv("SIWE_NONCE_CLEANUP_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			interval = parsed
+		} else {
+			log.Printf("⚠️ 无效的SIWE_NONCE_CLEANUP_INTERVAL: %s，使用默认值 %s", v, defaultSiweNonceCleanupInterval)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SiweNonceCleaner{
+		service:  NewSiweService(db),
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start 启动定时清理，启动时先清理一次
+func (c *SiweNonceCleaner) Start() {
+	log.Printf("🧹 启动SIWE nonce清理 (间隔: %v)", c.interval)
+
+	go func() {
+		c.cleanup()
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.cleanup()
+			case <-c.ctx.Done():
+				log.Printf("🛑 SIWE nonce清理收到停止信号")
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止清理
+func (c *SiweNonceCleaner) Stop() {
+	c.cancel()
+}
+
+func (c *SiweNonceCleaner) cleanup() {
+	deleted, err := c.service.CleanupExpiredNonces(c.ctx)
+	if err != nil {
+		log.Printf("❌ [SIWE] %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("🧹 [SIWE] 清理了 %d 个过期nonce", deleted)
+	}
+}
+
+// ParseSiweMessage 解析EIP-4361格式的消息
+func ParseSiweMessage(raw string) (*SiweMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("%w: 消息行数不足", ErrSiweMalformedMessage)
+	}
+
+	const header = " wants you to sign in with your Ethereum account:"
+	if !strings.HasSuffix(lines[0], header) {
+		return nil, fmt.Errorf("%w: 缺少SIWE消息头", ErrSiweMalformedMessage)
+	}
+
+	msg := &SiweMessage{
+		Domain:  strings.TrimSuffix(lines[0], header),
+		Address: strings.TrimSpace(lines[1]),
+	}
+	if msg.Domain == "" {
+		return nil, fmt.Errorf("%w: domain为空", ErrSiweMalformedMessage)
+	}
+	if !common.IsHexAddress(msg.Address) {
+		return nil, fmt.Errorf("%w: 无效地址 %s", ErrSiweMalformedMessage, msg.Address)
+	}
+
+	var statement []string
+	inResources := false
+	seenFields := false
+	for _, line := range lines[2:] {
+		if inResources {
+			if strings.HasPrefix(line, "- ") {
+				msg.Resources = append(msg.Resources, strings.TrimPrefix(line, "- "))
+				continue
+			}
+			inResources = false
+		}
+
+		if line == "Resources:" {
+			inResources = true
+			seenFields = true
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ": ")
+		if !found || !siweFieldKeys[key] {
+			// 字段区之前的非空行属于statement
+			if !seenFields && line != "" {
+				statement = append(statement, line)
+			}
+			continue
+		}
+
+		seenFields = true
+		var err error
+		switch key {
+		case "URI":
+			msg.URI = value
+		case "Version":
+			msg.Version = value
+		case "Chain ID":
+			msg.ChainID, err = strconv.ParseInt(value, 10, 64)
+		case "Nonce":
+			msg.Nonce = value
+		case "Issued At":
+			msg.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.ExpirationTime = &t
+		case "Not Before":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.NotBefore = &t
+		case "Request ID":
+			msg.RequestID = value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: 字段 %s 解析失败: %v", ErrSiweMalformedMessage, key, err)
+		}
+	}
+	msg.Statement = strings.Join(statement, "\n")
+
+	if msg.URI == "" || msg.Version == "" || msg.ChainID == 0 || msg.Nonce == "" || msg.IssuedAt.IsZero() {
+		return nil, fmt.Errorf("%w: 缺少必填字段(URI/Version/Chain ID/Nonce/Issued At)", ErrSiweMalformedMessage)
+	}
+	if len(msg.Nonce) < 8 {
+		return nil, fmt.Errorf("%w: nonce长度不足", ErrSiweMalformedMessage)
+	}
+
+	return msg, nil
+}
+
+// String 按EIP-4361格式生成待签名消息
+func (m *SiweMessage) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s wants you to sign in with your Ethereum account:\n%s\n\n", m.Domain, m.Address)
+	if m.Statement != "" {
+		fmt.Fprintf(&b, "%s\n\n", m.Statement)
+	}
+	fmt.Fprintf(&b, "URI: %s\n", m.URI)
+	fmt.Fprintf(&b, "Version: %s\n", m.Version)
+	fmt.Fprintf(&b, "Chain ID: %d\n", m.ChainID)
+	fmt.Fprintf(&b, "Nonce: %s\n", m.Nonce)
+	fmt.Fprintf(&b, "Issued At: %s", m.IssuedAt.UTC().Format(time.RFC3339))
+	if m.ExpirationTime != nil {
+		fmt.Fprintf(&b, "\nExpiration Time: %s", m.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+	if m.NotBefore != nil {
+		fmt.Fprintf(&b, "\nNot Before: %s", m.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if m.RequestID != "" {
+		fmt.Fprintf(&b, "\nRequest ID: %s", m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, r := range m.Resources {
+			fmt.Fprintf(&b, "\n- %s", r)
+		}
+	}
+	return b.String()
+}
+
+// generateSiweNonce 生成32位十六进制随机nonce（满足EIP-4361字母数字要求）
+func generateSiweNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
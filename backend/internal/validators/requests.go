@@ -1,5 +1,7 @@
 package validators
 
+import "encoding/json"
+
 // 请求结构体定义
 type RegisterRequest struct {
     Email         string  `json:"email" validate:"required,email"`
@@ -13,6 +15,11 @@ type LoginRequest struct {
     Password string `json:"password" validate:"required"`
 }
 
+type SiweChallengeRequest struct {
+    WalletAddress string `json:"wallet_address" validate:"required,ethereum_address"`
+    ChainID       int64  `json:"chain_id" validate:"omitempty,gt=0"` // 钱包当前所在链，不传时使用默认链
+}
+
 type WalletLoginRequest struct {
     WalletAddress string `json:"wallet_address" validate:"required,ethereum_address"`
     Signature     string `json:"signature" validate:"required"`
@@ -40,11 +47,39 @@ type CreateProposalRequest struct {
     SafeID             string `json:"safe_id" validate:"required,uuid"`
     Title              string `json:"title" validate:"required,min=1,max=255"`
     Description        string `json:"description" validate:"max=1000"`
-    ProposalType       string `json:"proposal_type" validate:"required,oneof=transfer contract_call add_owner remove_owner change_threshold"`
-    ToAddress          string `json:"to_address" validate:"required,ethereum_address"`
-    Value              string `json:"value" validate:"required"`
+    ProposalType       string `json:"proposal_type" validate:"required,oneof=transfer contract_call add_owner remove_owner change_threshold batch token_transfer"`
+    ToAddress          string `json:"to_address" validate:"required_unless=ProposalType batch,omitempty,ethereum_address"`
+    Value              string `json:"value" validate:"required_without_all=BatchItems TokenAmount"`
     Data               string `json:"data"`
     RequiredSignatures int    `json:"required_signatures" validate:"required,min=1"`
+
+    // SafeTx参数（可选，默认CALL且无退款）
+    Operation      int    `json:"operation" validate:"oneof=0 1"`
+    SafeTxGas      string `json:"safe_tx_gas" validate:"omitempty,numeric"`
+    BaseGas        string `json:"base_gas" validate:"omitempty,numeric"`
+    GasPrice       string `json:"gas_price" validate:"omitempty,numeric"`
+    GasToken       string `json:"gas_token" validate:"omitempty,ethereum_address"`
+    RefundReceiver string `json:"refund_receiver" validate:"omitempty,ethereum_address"`
+
+    // ERC-20代币转账（仅proposal_type=token_transfer时使用，to_address为接收方）
+    TokenAddress string `json:"token_address" validate:"required_if=ProposalType token_transfer,omitempty,ethereum_address"`
+    TokenAmount  string `json:"token_amount" validate:"required_if=ProposalType token_transfer"` // 人类可读数量，如 "12.5"
+
+    // 批量交易子项（仅proposal_type=batch时使用）
+    BatchItems []BatchItemRequest `json:"batch_items" validate:"required_if=ProposalType batch,omitempty,max=100,dive"`
+
+    // Safe nonce（可选，默认排在队尾；指定已排队的nonce表示替换该nonce上的提案）
+    Nonce *int64 `json:"nonce" validate:"omitempty,min=0"`
+}
+
+type BatchItemRequest struct {
+    ToAddress string `json:"to_address" validate:"required,ethereum_address"`
+    Value     string `json:"value" validate:"omitempty,numeric"`
+    Data      string `json:"data" validate:"omitempty,hexadecimal"`
+}
+
+type RejectProposalRequest struct {
+    Reason string `json:"reason" validate:"max=1000"` // 拒绝原因（可选）
 }
 
 type SignProposalRequest struct {
@@ -54,6 +89,53 @@ type SignProposalRequest struct {
     SafeTxHash    string `json:"safe_tx_hash"`   // 签名对应的Safe交易哈希
 }
 
+// TxServiceProposeRequest Safe Transaction Service兼容的提交交易请求
+// 字段名与官方服务一致，数值字段兼容数字和字符串两种写法
+type TxServiceProposeRequest struct {
+    To                      string      `json:"to" validate:"required,ethereum_address"`
+    Value                   json.Number `json:"value"`
+    Data                    *string     `json:"data"`
+    Operation               int         `json:"operation" validate:"min=0,max=1"`
+    GasToken                *string     `json:"gasToken"`
+    SafeTxGas               json.Number `json:"safeTxGas"`
+    BaseGas                 json.Number `json:"baseGas"`
+    GasPrice                json.Number `json:"gasPrice"`
+    RefundReceiver          *string     `json:"refundReceiver"`
+    Nonce                   json.Number `json:"nonce" validate:"required"`
+    ContractTransactionHash string      `json:"contractTransactionHash" validate:"required"`
+    Sender                  string      `json:"sender" validate:"required,ethereum_address"`
+    Signature               *string     `json:"signature"`
+    Origin                  *string     `json:"origin" validate:"omitempty,max=200"`
+}
+
+// TxServiceConfirmationRequest Safe Transaction Service兼容的确认请求
+type TxServiceConfirmationRequest struct {
+    Signature string `json:"signature" validate:"required"`
+}
+
+// OfflineSigningExportRequest 导出离线签名包的请求
+type OfflineSigningExportRequest struct {
+    ProposalIDs []string `json:"proposal_ids" validate:"required,min=1,max=50,dive,uuid"`
+}
+
+// OfflineSignatureEntry 离线签名文件中的单个签名
+type OfflineSignatureEntry struct {
+    ProposalID    string `json:"proposal_id" validate:"required,uuid"`
+    SafeTxHash    string `json:"safe_tx_hash" validate:"required"`   // 签名包中提案的safeTxHash，与服务端重建的不一致时拒绝
+    Signer        string `json:"signer" validate:"omitempty,ethereum_address"`
+    Signature     string `json:"signature" validate:"required"`
+    SignatureType string `json:"signature_type" validate:"omitempty,oneof=eth_sign eth_signTypedData contract"` // 默认eth_signTypedData
+    Nonce         *int64 `json:"nonce"`
+}
+
+// OfflineSignatureImportRequest 导入离线签名文件的请求
+type OfflineSignatureImportRequest struct {
+    Format         string                  `json:"format"`
+    BundleID       string                  `json:"bundle_id" validate:"omitempty,uuid"`
+    BundleChecksum string                  `json:"bundle_checksum"`
+    Signatures     []OfflineSignatureEntry `json:"signatures" validate:"required,min=1,max=50,dive"`
+}
+
 type UpdateProfileRequest struct {
     FullName      string `json:"full_name" validate:"max=255"`
     AvatarURL     string `json:"avatar_url" validate:"omitempty,url"`
@@ -1,21 +1,23 @@
 package workflow
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math/big"
-	"os"
-	"strconv"
-	"strings"
+	"sync"
 
 	"web3-enterprise-multisig/internal/blockchain"
 	"web3-enterprise-multisig/internal/database"
 	"web3-enterprise-multisig/internal/models"
+	"web3-enterprise-multisig/internal/services"
 	"web3-enterprise-multisig/internal/websocket"
 
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // WorkflowStatus 工作流状态
@@ -89,93 +91,118 @@ func ApproveProposal(proposalID uuid.UUID, userID uuid.UUID, signatureData strin
 		return err
 	}
 
-	// 检查用户是否已经签名
+	// 检查用户是否已经签名（重新排队后失效的签名不计）
 	var existingSignature models.Signature
-	if err := database.DB.Where("proposal_id = ? AND signer_id = ?", proposalID, userID).
+	if err := database.DB.Where("proposal_id = ? AND signer_id = ? AND status = ?", proposalID, userID, "valid").
 		First(&existingSignature).Error; err == nil {
 		return fmt.Errorf("user has already signed this proposal")
 	}
 
+	// 签名前强制检查策略
+	policyService := services.NewPolicyService(database.DB)
+	if _, err := policyService.EnforcePolicies(context.Background(), proposalID, userID, services.PolicyStageSign); err != nil {
+		return err
+	}
+
+	// 保存前验证签名确由用户钱包针对提案的SafeTxHash签署
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.WalletAddress == nil || *user.WalletAddress == "" {
+		return fmt.Errorf("user must have a wallet address to sign proposals")
+	}
+	verified, err := VerifyProposalSignature(&proposal, *user.WalletAddress, "", signatureData, nil)
+	if err != nil {
+		return err
+	}
+	usedNonce := verified.Nonce.Int64()
+	safeTxHash := verified.SafeTxHash.Hex()
+
 	// 创建签名记录
 	signature := models.Signature{
 		ProposalID:    proposalID,
 		SignerID:      userID,
-		SignatureData: signatureData,
-		SignatureType: "eth_sign",
+		SignatureData: hexutil.Encode(verified.Signature),
+		SignatureType: verified.SignatureType,
 		Status:        "valid",
+		UsedNonce:     &usedNonce,
+		SafeTxHash:    &safeTxHash,
 	}
 
-	if err := database.DB.Create(&signature).Error; err != nil {
+	if err := SaveProposalSignature(database.DB, &signature); err != nil {
 		return err
 	}
 
 	// 更新提案签名计数
 	if err := database.DB.Model(&proposal).
-		Update("signatures_count", proposal.CurrentSignatures+1).Error; err != nil {
+		Update("current_signatures", proposal.CurrentSignatures+1).Error; err != nil {
 		return err
 	}
 
 	// 检查是否达到执行条件
 	if proposal.CurrentSignatures+1 >= proposal.RequiredSignatures {
 		database.DB.Model(&proposal).Update("status", "approved")
+
+		// Safe开启了自动执行时提交执行任务
+		if _, err := ScheduleAutoExecution(proposalID, userID); err != nil {
+			log.Printf("⚠️ 提案 %s 自动执行提交失败: %v", proposalID, err)
+		}
 	}
 
 	log.Printf("Proposal %s approved by user %s", proposalID, userID)
 	return nil
 }
 
-// ExecuteProposal 执行提案
+// SaveProposalSignature 保存所有者对提案的签名
+// signatures表每个所有者对每个提案只有一行，提案重新排队后留下的失效签名在重新签名时被替换
+func SaveProposalSignature(db *gorm.DB, signature *models.Signature) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("proposal_id = ? AND signer_id = ? AND status <> ?", signature.ProposalID, signature.SignerID, "valid").
+			Delete(&models.Signature{}).Error; err != nil {
+			return fmt.Errorf("failed to replace stale signature: %w", err)
+		}
+		if err := tx.Omit(clause.Associations).Create(signature).Error; err != nil {
+			return fmt.Errorf("failed to create signature: %w", err)
+		}
+		return nil
+	})
+}
+
+// ExecuteProposal 执行提案（系统触发，策略日志不记录操作用户）
 func ExecuteProposal(proposalID uuid.UUID) error {
+	return ExecuteProposalBy(proposalID, uuid.Nil)
+}
+
+// ExecuteProposalBy 执行提案，执行前按提案ID重新运行所有激活策略
+// 策略未通过时返回 *services.PolicyViolationError
+func ExecuteProposalBy(proposalID uuid.UUID, userID uuid.UUID) error {
 	var proposal models.Proposal
 	if err := database.DB.Preload("Safe").First(&proposal, proposalID).Error; err != nil {
 		return err
 	}
 
 	if !proposal.CanExecute() {
-		return fmt.Errorf("proposal cannot be executed")
-	}
-
-	// 调用区块链执行逻辑
-	rpcURL := os.Getenv("ETHEREUM_RPC_URL")
-	if rpcURL == "" {
-		rpcURL = "https://sepolia.infura.io/v3/your-project-id" // 默认值
-	}
-
-	privateKey := os.Getenv("PRIVATE_KEY")
-	if privateKey == "" {
-		log.Printf("Warning: No executor private key configured")
-		return fmt.Errorf("executor private key not configured")
+		return fmt.Errorf("%w: status %s", ErrProposalNotExecutable, proposal.Status)
 	}
 
-	chainIDStr := os.Getenv("CHAIN_ID")
-	if chainIDStr == "" {
-		chainIDStr = "11155111" // 默认Sepolia
-	}
-	chainIDInt, err := strconv.ParseInt(chainIDStr, 10, 64)
-	if err != nil {
-		return fmt.Errorf("invalid chain ID: %v", err)
-	}
-	chainID := big.NewInt(chainIDInt)
-
-	// 连接以太坊客户端
-	client, err := ethclient.Dial(rpcURL)
-	if err != nil {
-		return fmt.Errorf("failed to connect to Ethereum client: %v", err)
+	// 执行前强制检查策略（时间锁定、审批阈值等）
+	policyService := services.NewPolicyService(database.DB)
+	if _, err := policyService.EnforcePolicies(context.Background(), proposalID, userID, services.PolicyStageExecute); err != nil {
+		log.Printf("🚫 提案 %s 执行被策略阻止: %v", proposalID, err)
+		return err
 	}
 
-	// 解析私钥
-	privateKeyECDSA, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	executor, err := newExecutorForProposal(&proposal)
 	if err != nil {
-		return fmt.Errorf("failed to parse private key: %v", err)
+		return err
 	}
-
-	// 创建SafeExecutor实例
-	executor := blockchain.NewSafeExecutor(client, privateKeyECDSA, chainID, database.DB)
+	chainID := int64(proposal.Safe.ChainID)
 
 	// 执行区块链交易
 	if err := executor.ExecuteProposal(proposalID); err != nil {
 		log.Printf("Failed to execute proposal on blockchain: %v", err)
-		return fmt.Errorf("failed to execute proposal on blockchain: %v", err)
+		return fmt.Errorf("failed to execute proposal on blockchain: %w", err)
 	}
 
 	// 🔥 关键修复：获取交易哈希并添加到提案执行监控
@@ -185,7 +212,7 @@ func ExecuteProposal(proposalID uuid.UUID) error {
 		log.Printf("⚠️ 无法获取更新后的提案信息: %v", err)
 	} else if updatedProposal.TxHash != nil && *updatedProposal.TxHash != "" {
 		// 获取监控器实例并添加提案执行监控
-		monitor := getSafeMonitor()
+		monitor := getSafeMonitor(chainID)
 		if monitor != nil {
 			log.Printf("📋 [工作流] 添加提案执行监控: 提案ID=%s, 交易哈希=%s, Safe地址=%s", 
 				proposalID.String(), *updatedProposal.TxHash, proposal.Safe.Address)
@@ -202,6 +229,163 @@ func ExecuteProposal(proposalID uuid.UUID) error {
 	return nil
 }
 
+// SpeedUpExecution 以更高费用重发提案的执行交易（同nonce替换）
+func SpeedUpExecution(proposalID uuid.UUID, userID uuid.UUID) (*models.ProposalExecutionTx, error) {
+	return replaceExecution(proposalID, userID, models.ExecutionTxActionSpeedUp)
+}
+
+// CancelExecution 发送同nonce的0值自转账取消提案的执行交易
+func CancelExecution(proposalID uuid.UUID, userID uuid.UUID) (*models.ProposalExecutionTx, error) {
+	return replaceExecution(proposalID, userID, models.ExecutionTxActionCancel)
+}
+
+// replaceExecution 广播替换交易并交给监控器跟踪
+func replaceExecution(proposalID uuid.UUID, userID uuid.UUID, action string) (*models.ProposalExecutionTx, error) {
+	var proposal models.Proposal
+	if err := database.DB.Preload("Safe").First(&proposal, proposalID).Error; err != nil {
+		return nil, err
+	}
+
+	executor, err := newExecutorForProposal(&proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	var record *models.ProposalExecutionTx
+	if action == models.ExecutionTxActionCancel {
+		record, err = executor.CancelExecution(context.Background(), proposalID, userID)
+	} else {
+		record, err = executor.SpeedUpExecution(context.Background(), proposalID, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if monitor := getSafeMonitor(int64(proposal.Safe.ChainID)); monitor != nil {
+		monitor.TrackProposalReplacement(proposalID, record.TxHash, proposal.Safe.Address)
+	} else {
+		log.Printf("⚠️ [工作流] Safe监控器未初始化，跳过替换交易监控")
+	}
+
+	return record, nil
+}
+
+// ProposalTypedData 提案签名使用的EIP-712数据，签名UI和第三方签名工具都以此为准
+type ProposalTypedData struct {
+	ProposalID    uuid.UUID                   `json:"proposal_id"`
+	SafeAddress   string                      `json:"safe_address"`
+	ChainID       int64                       `json:"chain_id"`
+	Nonce         int64                       `json:"nonce"`
+	NonceAssigned bool                        `json:"nonce_assigned"` // false表示提案未分配nonce，按链上当前nonce生成
+	SafeTxHash    string                      `json:"safe_tx_hash"`
+	TypedData     *blockchain.SafeTxTypedData `json:"typed_data"`
+}
+
+// GetProposalTypedData 按与签名验证和执行相同的规则构建提案的SafeTx，返回其EIP-712数据和safeTxHash
+func GetProposalTypedData(proposalID uuid.UUID) (*ProposalTypedData, error) {
+	var proposal models.Proposal
+	if err := database.DB.Preload("Safe").First(&proposal, proposalID).Error; err != nil {
+		return nil, err
+	}
+
+	executor, err := dialExecutor(&proposal, nil)
+	if err != nil {
+		return nil, err
+	}
+	return buildProposalTypedData(executor, &proposal)
+}
+
+// buildProposalTypedData 用已连接的执行器构建提案的EIP-712数据（需预加载Safe）
+func buildProposalTypedData(executor *blockchain.SafeExecutor, proposal *models.Proposal) (*ProposalTypedData, error) {
+	safeTx, safeTxHash, err := executor.ProposalSafeTx(proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID := big.NewInt(int64(proposal.Safe.ChainID))
+	safeAddress := common.HexToAddress(proposal.Safe.Address)
+	return &ProposalTypedData{
+		ProposalID:    proposal.ID,
+		SafeAddress:   safeAddress.Hex(),
+		ChainID:       chainID.Int64(),
+		Nonce:         safeTx.Nonce.Int64(),
+		NonceAssigned: proposal.Nonce != nil,
+		SafeTxHash:    safeTxHash.Hex(),
+		TypedData:     blockchain.NewSafeTxTypedData(chainID, safeAddress, safeTx),
+	}, nil
+}
+
+// SimulateProposal 在pending区块上模拟提案执行，不需要配置执行账户签名器
+func SimulateProposal(proposalID uuid.UUID) (*blockchain.SimulationResult, error) {
+	var proposal models.Proposal
+	if err := database.DB.Preload("Safe").First(&proposal, proposalID).Error; err != nil {
+		return nil, err
+	}
+
+	executor, err := dialExecutor(&proposal, getExecutorSigner())
+	if err != nil {
+		return nil, err
+	}
+
+	return executor.SimulateProposal(context.Background(), proposalID)
+}
+
+// VerifyProposalSignature 按签名使用的Safe nonce重建SafeTxHash并验证签名者，签名保存前调用
+// usedNonce为nil时使用链上当前nonce；signatureType为空时自动识别EIP-712/eth_sign签名
+func VerifyProposalSignature(proposal *models.Proposal, signerAddress string, signatureType string, signatureData string, usedNonce *int64) (*blockchain.VerifiedSignature, error) {
+	if !common.IsHexAddress(signerAddress) {
+		return nil, &blockchain.SignatureVerificationError{Reason: fmt.Sprintf("invalid signer address %q", signerAddress)}
+	}
+	signature, err := hexutil.Decode(signatureData)
+	if err != nil {
+		return nil, &blockchain.SignatureVerificationError{Reason: fmt.Sprintf("signature is not valid hex: %v", err)}
+	}
+
+	var nonce *big.Int
+	if usedNonce != nil {
+		if *usedNonce < 0 {
+			return nil, &blockchain.SignatureVerificationError{Reason: fmt.Sprintf("invalid Safe nonce %d", *usedNonce)}
+		}
+		nonce = big.NewInt(*usedNonce)
+	}
+
+	// 验证只需读取链上状态（nonce、合约签名），不需要执行账户签名器
+	executor, err := dialExecutor(proposal, getExecutorSigner())
+	if err != nil {
+		return nil, err
+	}
+
+	return executor.VerifyProposalSignature(context.Background(), proposal, common.HexToAddress(signerAddress), signatureType, signature, nonce)
+}
+
+// newExecutorForProposal 根据Safe所在链创建SafeExecutor
+func newExecutorForProposal(proposal *models.Proposal) (*blockchain.SafeExecutor, error) {
+	signer := getExecutorSigner()
+	if signer == nil {
+		log.Printf("Warning: No executor signer configured")
+		return nil, blockchain.ErrSignerNotConfigured
+	}
+	return dialExecutor(proposal, signer)
+}
+
+// dialExecutor 使用Safe所在链的共享连接创建SafeExecutor，signer可为nil（仅模拟）
+func dialExecutor(proposal *models.Proposal, signer blockchain.TxSigner) (*blockchain.SafeExecutor, error) {
+	// 根据Safe所在链选择网络配置
+	chainID := int64(proposal.Safe.ChainID)
+	registry := blockchain.DefaultChainRegistry()
+	chain, err := registry.Get(chainID)
+	if err != nil {
+		return nil, fmt.Errorf("chain %d is not configured for safe %s: %v", chainID, proposal.Safe.Address, err)
+	}
+
+	// 复用该链共享的RPC客户端创建SafeExecutor实例
+	client, err := registry.Client(context.Background(), chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to chain %d: %v", chainID, err)
+	}
+	return blockchain.NewSafeExecutorForChain(chain, client, signer, database.DB), nil
+}
+
 // notifyOnlineOwners 通知在线的Safe所有者新提案创建
 func notifyOnlineOwners(proposal *models.Proposal) error {
 	// 获取WebSocket Hub实例 (需要从main.go传递或使用全局变量)
@@ -318,8 +502,25 @@ func handleOfflineOwnerNotifications(proposal *models.Proposal) error {
 // 全局WebSocket Hub实例
 var globalWebSocketHub *websocket.Hub
 
-// 全局监控器实例
-var globalSafeMonitor *blockchain.SafeCreationMonitor
+// 全局监控器实例（按链ID索引）
+var (
+	globalSafeMonitors     = make(map[int64]*blockchain.SafeCreationMonitor)
+	globalSafeMonitorsLock sync.RWMutex
+)
+
+// 全局执行账户签名器
+var globalExecutorSigner blockchain.TxSigner
+
+// SetExecutorSigner 设置执行账户签名器（启动时由配置加载）
+func SetExecutorSigner(signer blockchain.TxSigner) {
+	globalExecutorSigner = signer
+	log.Printf("✅ 执行账户签名器已设置到workflow引擎 (地址: %s)", signer.Address().Hex())
+}
+
+// getExecutorSigner 获取执行账户签名器
+func getExecutorSigner() blockchain.TxSigner {
+	return globalExecutorSigner
+}
 
 // SetWebSocketHub 设置全局WebSocket Hub实例
 func SetWebSocketHub(hub *websocket.Hub) {
@@ -327,10 +528,12 @@ func SetWebSocketHub(hub *websocket.Hub) {
 	log.Printf("✅ WebSocket Hub已设置到workflow引擎")
 }
 
-// SetSafeMonitor 设置全局Safe监控器实例
+// SetSafeMonitor 设置全局Safe监控器实例（每条链一个）
 func SetSafeMonitor(monitor *blockchain.SafeCreationMonitor) {
-	globalSafeMonitor = monitor
-	log.Printf("✅ Safe监控器已设置到workflow引擎")
+	globalSafeMonitorsLock.Lock()
+	globalSafeMonitors[monitor.ChainID()] = monitor
+	globalSafeMonitorsLock.Unlock()
+	log.Printf("✅ Safe监控器已设置到workflow引擎 (链ID: %d)", monitor.ChainID())
 }
 
 // getWebSocketHub 获取WebSocket Hub实例
@@ -342,13 +545,17 @@ func getWebSocketHub() *websocket.Hub {
 	return globalWebSocketHub
 }
 
-// getSafeMonitor 获取Safe监控器实例
-func getSafeMonitor() *blockchain.SafeCreationMonitor {
-	if globalSafeMonitor == nil {
-		log.Printf("⚠️ Safe监控器未设置，无法监控提案执行")
+// getSafeMonitor 获取指定链的Safe监控器实例
+func getSafeMonitor(chainID int64) *blockchain.SafeCreationMonitor {
+	globalSafeMonitorsLock.RLock()
+	monitor := globalSafeMonitors[chainID]
+	globalSafeMonitorsLock.RUnlock()
+
+	if monitor == nil {
+		log.Printf("⚠️ 链 %d 的Safe监控器未设置，无法监控提案执行", chainID)
 		return nil
 	}
-	return globalSafeMonitor
+	return monitor
 }
 
 // getNextActions 获取下一步可执行的操作
@@ -0,0 +1,592 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"web3-enterprise-multisig/internal/blockchain"
+	"web3-enterprise-multisig/internal/database"
+	"web3-enterprise-multisig/internal/models"
+	"web3-enterprise-multisig/internal/services"
+	"web3-enterprise-multisig/internal/websocket"
+)
+
+const (
+	defaultExecutionQueueWorkers      = 2
+	defaultExecutionQueuePollInterval = 2 * time.Second
+	defaultExecutionJobStaleAfter     = 10 * time.Minute
+
+	// nonceWaitInterval 自动执行任务等待前序Safe nonce被消耗时的重新检查间隔
+	nonceWaitInterval = 30 * time.Second
+)
+
+// ErrProposalNotExecutable 提案状态或签名数不满足执行条件
+var ErrProposalNotExecutable = errors.New("proposal cannot be executed")
+
+// executionRetryableErrors 执行任务可重试的瞬时错误（节点连接、限流、nonce竞争）
+// 策略违规、模拟revert等确定性错误不在此列，直接失败
+var executionRetryableErrors = []string{
+	"connection refused",
+	"connection reset",
+	"timeout",
+	"deadline exceeded",
+	"network error",
+	"EOF",
+	"429",
+	"Too Many Requests",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"nonce too low",
+	"replacement transaction underpriced",
+}
+
+// ExecutionQueue 提案执行任务队列
+// 任务持久化在proposal_execution_jobs表中，多实例部署时通过 FOR UPDATE SKIP LOCKED 领取，
+// running状态超过staleAfter未完成的任务（进程中断）会被重新排队
+type ExecutionQueue struct {
+	db           *gorm.DB
+	workers      int
+	pollInterval time.Duration
+	staleAfter   time.Duration
+	retry        blockchain.RetryConfig
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewExecutionQueue 创建执行队列，读取环境变量：
+//
+//	EXECUTION_QUEUE_WORKERS        并发worker数（默认2）
+//	EXECUTION_QUEUE_POLL_INTERVAL  轮询间隔（默认2s）
+//	EXECUTION_JOB_MAX_RETRIES      瞬时错误最大重试次数（默认同DefaultRetryConfig）
+//	EXECUTION_JOB_STALE_AFTER      running任务视为中断的时间（默认10m）
+func NewExecutionQueue(db *gorm.DB) *ExecutionQueue {
+	retry := blockchain.DefaultRetryConfig
+	retry.RetryableErrors = executionRetryableErrors
+	if v := os.Getenv("EXECUTION_JOB_MAX_RETRIES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			retry.MaxRetries = parsed
+		} else {
+			log.Printf("⚠️ 无效的EXECUTION_JOB_MAX_RETRIES: %s，使用默认值 %d", v, retry.MaxRetries)
+		}
+	}
+
+	workers := defaultExecutionQueueWorkers
+	if v := os.Getenv("EXECUTION_QUEUE_WORKERS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			workers = parsed
+		} else {
+			log.Printf("⚠️ 无效的EXECUTION_QUEUE_WORKERS: %s，使用默认值 %d", v, defaultExecutionQueueWorkers)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ExecutionQueue{
+		db:           db,
+		workers:      workers,
+		pollInterval: parseQueueDuration("EXECUTION_QUEUE_POLL_INTERVAL", defaultExecutionQueuePollInterval),
+		staleAfter:   parseQueueDuration("EXECUTION_JOB_STALE_AFTER", defaultExecutionJobStaleAfter),
+		retry:        retry,
+		wake:         make(chan struct{}, 1),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+func parseQueueDuration(name string, fallback time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(v)
+	if err != nil || parsed <= 0 {
+		log.Printf("⚠️ 无效的%s: %s，使用默认值 %s", name, v, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// Start 恢复中断的任务并启动worker
+func (q *ExecutionQueue) Start() {
+	log.Printf("🧵 启动提案执行队列 (worker: %d, 轮询间隔: %v, 最大重试: %d)", q.workers, q.pollInterval, q.retry.MaxRetries)
+
+	q.requeueStaleJobs()
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.runWorker()
+	}
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		ticker := time.NewTicker(q.staleAfter / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				q.requeueStaleJobs()
+			case <-q.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止worker，正在执行的任务重新排队
+func (q *ExecutionQueue) Stop() {
+	q.cancel()
+	q.wg.Wait()
+	log.Printf("🛑 提案执行队列已停止")
+}
+
+// notify 唤醒空闲worker立即领取新任务
+func (q *ExecutionQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *ExecutionQueue) runWorker() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := q.claim()
+		if err != nil {
+			log.Printf("❌ [执行队列] 领取任务失败: %v", err)
+		}
+		if job != nil {
+			q.process(job)
+			continue
+		}
+
+		select {
+		case <-q.wake:
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+// claim 领取一个到期的排队任务，没有任务时返回nil
+func (q *ExecutionQueue) claim() (*models.ProposalExecutionJob, error) {
+	var job models.ProposalExecutionJob
+	claimed := false
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_run_at <= ?", models.ExecutionJobQueued, time.Now()).
+			Order("next_run_at").Limit(1).Find(&job)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":    models.ExecutionJobRunning,
+			"locked_at": now,
+		}
+		if job.StartedAt == nil {
+			updates["started_at"] = now
+		}
+		if err := tx.Model(&job).Updates(updates).Error; err != nil {
+			return err
+		}
+		job.Status = models.ExecutionJobRunning
+		job.LockedAt = &now
+		if job.StartedAt == nil {
+			job.StartedAt = &now
+		}
+		claimed = true
+		return nil
+	})
+	if err != nil || !claimed {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// requeueStaleJobs 进程中断后遗留的running任务重新排队
+func (q *ExecutionQueue) requeueStaleJobs() {
+	result := q.db.Model(&models.ProposalExecutionJob{}).
+		Where("status = ? AND locked_at < ?", models.ExecutionJobRunning, time.Now().Add(-q.staleAfter)).
+		Updates(map[string]interface{}{
+			"status":      models.ExecutionJobQueued,
+			"locked_at":   nil,
+			"next_run_at": time.Now(),
+		})
+	if result.Error != nil {
+		log.Printf("❌ [执行队列] 恢复中断任务失败: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("♻️ [执行队列] %d 个中断的执行任务已重新排队", result.RowsAffected)
+		q.notify()
+	}
+}
+
+// process 执行任务，瞬时错误按重试配置重试；重试次数跨进程重启累计，不超过MaxAttempts
+func (q *ExecutionQueue) process(job *models.ProposalExecutionJob) {
+	log.Printf("⚙️ [执行队列] 开始执行任务 %s (提案: %s, 已尝试: %d/%d)", job.ID, job.ProposalID, job.Attempts, job.MaxAttempts)
+
+	// 上次执行已广播但进程在更新任务前中断，直接视为成功
+	if txHash, executed := proposalExecutedTxHash(q.db, job.ProposalID); executed {
+		q.succeed(job, txHash)
+		return
+	}
+
+	retry := q.retry
+	retry.MaxRetries = job.MaxAttempts - job.Attempts - 1
+	if retry.MaxRetries < 0 {
+		q.fail(job, fmt.Errorf("execution job exhausted %d attempts", job.MaxAttempts))
+		return
+	}
+
+	var requestedBy uuid.UUID
+	if job.RequestedBy != nil {
+		requestedBy = *job.RequestedBy
+	}
+
+	err := blockchain.WithRetry(q.ctx, retry, func() error {
+		job.Attempts++
+		now := time.Now()
+		if err := q.db.Model(job).Updates(map[string]interface{}{"attempts": job.Attempts, "locked_at": now}).Error; err != nil {
+			log.Printf("⚠️ [执行队列] 更新任务 %s 尝试次数失败: %v", job.ID, err)
+		}
+		stage := "running"
+		if job.Attempts > 1 {
+			stage = "retrying"
+		}
+		notifyExecutionJob(job, stage)
+
+		return ExecuteProposalBy(job.ProposalID, requestedBy)
+	})
+
+	if err == nil {
+		txHash, _ := proposalExecutedTxHash(q.db, job.ProposalID)
+		q.succeed(job, txHash)
+		return
+	}
+
+	// 自动执行任务遇到时间锁定（策略在排队后调整）时，推迟到允许执行的时间，不计入尝试次数
+	var violation *services.PolicyViolationError
+	if job.Source == models.ExecutionJobSourceAuto && errors.As(err, &violation) {
+		if delay, ok := violation.TimeLockDelay(); ok {
+			q.reschedule(job, time.Now().Add(delay), "受时间锁定限制")
+			return
+		}
+	}
+
+	// 自动执行任务排在其他Safe交易之后时，等待前面的nonce被消耗
+	if job.Source == models.ExecutionJobSourceAuto && errors.Is(err, blockchain.ErrSafeNonceNotReady) {
+		q.reschedule(job, time.Now().Add(nonceWaitInterval), "等待前序Safe nonce执行")
+		return
+	}
+
+	// 队列停止时任务回到排队状态，由下次启动或其他实例继续
+	if errors.Is(err, context.Canceled) {
+		q.db.Model(job).Updates(map[string]interface{}{
+			"status":      models.ExecutionJobQueued,
+			"locked_at":   nil,
+			"next_run_at": time.Now(),
+		})
+		return
+	}
+
+	q.fail(job, err)
+}
+
+func (q *ExecutionQueue) reschedule(job *models.ProposalExecutionJob, runAt time.Time, reason string) {
+	job.Attempts--
+	job.Status = models.ExecutionJobQueued
+	job.NextRunAt = runAt
+	if err := q.db.Model(job).Updates(map[string]interface{}{
+		"status":      models.ExecutionJobQueued,
+		"attempts":    job.Attempts,
+		"next_run_at": runAt,
+		"locked_at":   nil,
+	}).Error; err != nil {
+		log.Printf("❌ [执行队列] 更新任务 %s 状态失败: %v", job.ID, err)
+	}
+
+	log.Printf("⏳ [执行队列] 任务 %s %s，推迟到 %s 执行", job.ID, reason, runAt.Format(time.RFC3339))
+	notifyExecutionJob(job, "scheduled")
+}
+
+func (q *ExecutionQueue) succeed(job *models.ProposalExecutionJob, txHash string) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      models.ExecutionJobSucceeded,
+		"finished_at": now,
+		"locked_at":   nil,
+		"error_code":  nil,
+		"last_error":  nil,
+	}
+	if txHash != "" {
+		updates["tx_hash"] = txHash
+		job.TxHash = &txHash
+	}
+	if err := q.db.Model(job).Updates(updates).Error; err != nil {
+		log.Printf("❌ [执行队列] 更新任务 %s 状态失败: %v", job.ID, err)
+	}
+	job.Status = models.ExecutionJobSucceeded
+	job.FinishedAt = &now
+
+	log.Printf("✅ [执行队列] 任务 %s 执行成功 (提案: %s, 交易: %s)", job.ID, job.ProposalID, txHash)
+	notifyExecutionJob(job, job.Status)
+}
+
+func (q *ExecutionQueue) fail(job *models.ProposalExecutionJob, err error) {
+	code, details := classifyExecutionError(err)
+	message := err.Error()
+
+	now := time.Now()
+	if dbErr := q.db.Model(job).Updates(map[string]interface{}{
+		"status":        models.ExecutionJobFailed,
+		"finished_at":   now,
+		"locked_at":     nil,
+		"error_code":    code,
+		"last_error":    message,
+		"error_details": details,
+	}).Error; dbErr != nil {
+		log.Printf("❌ [执行队列] 更新任务 %s 状态失败: %v", job.ID, dbErr)
+	}
+	job.Status = models.ExecutionJobFailed
+	job.FinishedAt = &now
+	job.ErrorCode = &code
+	job.LastError = &message
+	job.ErrorDetails = details
+
+	log.Printf("❌ [执行队列] 任务 %s 执行失败 (提案: %s, %s): %v", job.ID, job.ProposalID, code, err)
+	notifyExecutionJob(job, job.Status)
+}
+
+// classifyExecutionError 转换为与同步接口一致的错误码和结构化详情
+func classifyExecutionError(err error) (string, models.JSONMap) {
+	var violation *services.PolicyViolationError
+	if errors.As(err, &violation) {
+		return "POLICY_VIOLATION", violation.Details()
+	}
+
+	var simulationErr *blockchain.SimulationError
+	if errors.As(err, &simulationErr) {
+		details := models.JSONMap{}
+		if raw, marshalErr := json.Marshal(simulationErr.Result); marshalErr == nil {
+			json.Unmarshal(raw, &details)
+		}
+		return "SIMULATION_FAILED", details
+	}
+
+	if errors.Is(err, blockchain.ErrSignerNotConfigured) {
+		return "EXECUTOR_NOT_CONFIGURED", nil
+	}
+	if errors.Is(err, ErrProposalNotExecutable) {
+		return "PROPOSAL_NOT_EXECUTABLE", nil
+	}
+	if errors.Is(err, blockchain.ErrSafeNonceNotReady) {
+		return "NONCE_NOT_READY", nil
+	}
+	if errors.Is(err, blockchain.ErrSafeNonceConsumed) {
+		return "NONCE_CONSUMED", nil
+	}
+	return "EXECUTION_ERROR", nil
+}
+
+// proposalExecutedTxHash 提案已广播执行交易时返回交易哈希
+func proposalExecutedTxHash(db *gorm.DB, proposalID uuid.UUID) (string, bool) {
+	var proposal models.Proposal
+	if err := db.Select("id", "status", "tx_hash").First(&proposal, proposalID).Error; err != nil {
+		return "", false
+	}
+	if proposal.Status != "executed" && proposal.Status != "confirmed" {
+		return "", false
+	}
+	if proposal.TxHash == nil || *proposal.TxHash == "" {
+		return "", false
+	}
+	return *proposal.TxHash, true
+}
+
+// notifyExecutionJob 向提交任务的用户推送执行进度
+func notifyExecutionJob(job *models.ProposalExecutionJob, stage string) {
+	if job.RequestedBy == nil {
+		return
+	}
+	hub := getWebSocketHub()
+	if hub == nil {
+		return
+	}
+
+	hub.SendToUser(*job.RequestedBy, websocket.WebSocketMessage{
+		Type: "proposal_execution_job",
+		Data: map[string]interface{}{
+			"job_id":       job.ID,
+			"proposal_id":  job.ProposalID,
+			"stage":        stage,
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"max_attempts": job.MaxAttempts,
+			"source":       job.Source,
+			"next_run_at":  job.NextRunAt,
+			"tx_hash":      job.TxHash,
+			"error_code":   job.ErrorCode,
+			"error":        job.LastError,
+		},
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// 全局执行队列
+var globalExecutionQueue *ExecutionQueue
+
+// StartExecutionQueue 创建并启动全局执行队列
+func StartExecutionQueue() *ExecutionQueue {
+	queue := NewExecutionQueue(database.DB)
+	queue.Start()
+	globalExecutionQueue = queue
+	return queue
+}
+
+// EnqueueExecution 提交提案执行任务
+// 幂等：提案已有排队/执行中的任务，或已执行且有成功任务时，返回该任务且created为false
+func EnqueueExecution(proposalID uuid.UUID, userID uuid.UUID) (job *models.ProposalExecutionJob, created bool, err error) {
+	return enqueueExecution(proposalID, userID, models.ExecutionJobSourceManual, time.Now())
+}
+
+// ScheduleAutoExecution 签名达到阈值后为开启自动执行的Safe提交执行任务
+// 存在未满足的时间锁定策略时，任务安排在最早允许执行的时间；Safe未开启自动执行时返回nil
+func ScheduleAutoExecution(proposalID uuid.UUID, userID uuid.UUID) (*models.ProposalExecutionJob, error) {
+	var proposal models.Proposal
+	if err := database.DB.Preload("Safe").First(&proposal, proposalID).Error; err != nil {
+		return nil, err
+	}
+	if !proposal.Safe.AutoExecute {
+		return nil, nil
+	}
+
+	policyService := services.NewPolicyService(database.DB)
+	runAt, err := policyService.EarliestExecutionTime(context.Background(), proposalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve time-lock policies: %w", err)
+	}
+
+	job, created, err := enqueueExecution(proposalID, userID, models.ExecutionJobSourceAuto, runAt)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		log.Printf("🤖 [自动执行] 提案 %s 已达到签名阈值，执行任务安排在 %s", proposalID, runAt.Format(time.RFC3339))
+	}
+	return job, nil
+}
+
+// enqueueExecution 创建执行任务，runAt晚于当前时间时任务在到期后才会被领取
+func enqueueExecution(proposalID uuid.UUID, userID uuid.UUID, source string, runAt time.Time) (job *models.ProposalExecutionJob, created bool, err error) {
+	var proposal models.Proposal
+	if err := database.DB.First(&proposal, proposalID).Error; err != nil {
+		return nil, false, err
+	}
+
+	if existing, err := findActiveExecutionJob(proposalID); err != nil || existing != nil {
+		return existing, false, err
+	}
+
+	// 链下签名不足时，检查所有者是否已在链上approveHash
+	if proposal.Status == "pending" {
+		if approvals, err := SyncOnChainApprovals(proposalID, userID); err != nil {
+			log.Printf("⚠️ [执行队列] 同步提案 %s 的链上审批失败: %v", proposalID, err)
+		} else if approvals.Status == "approved" {
+			if err := database.DB.First(&proposal, proposalID).Error; err != nil {
+				return nil, false, err
+			}
+			if existing, err := findActiveExecutionJob(proposalID); err != nil || existing != nil {
+				return existing, false, err
+			}
+		}
+	}
+
+	if !proposal.CanExecute() {
+		if _, executed := proposalExecutedTxHash(database.DB, proposalID); executed {
+			var latest models.ProposalExecutionJob
+			result := database.DB.Where("proposal_id = ? AND status = ?", proposalID, models.ExecutionJobSucceeded).
+				Order("created_at DESC").Limit(1).Find(&latest)
+			if result.Error == nil && result.RowsAffected > 0 {
+				return &latest, false, nil
+			}
+		}
+		return nil, false, fmt.Errorf("%w: status %s, signatures %d/%d",
+			ErrProposalNotExecutable, proposal.Status, proposal.CurrentSignatures, proposal.RequiredSignatures)
+	}
+
+	if getExecutorSigner() == nil {
+		return nil, false, blockchain.ErrSignerNotConfigured
+	}
+
+	maxRetries := blockchain.DefaultRetryConfig.MaxRetries
+	if globalExecutionQueue != nil {
+		maxRetries = globalExecutionQueue.retry.MaxRetries
+	} else {
+		log.Printf("⚠️ [执行队列] 执行队列未启动，任务将在队列启动后执行")
+	}
+
+	job = &models.ProposalExecutionJob{
+		ProposalID:  proposalID,
+		Status:      models.ExecutionJobQueued,
+		Source:      source,
+		MaxAttempts: maxRetries + 1,
+		NextRunAt:   runAt,
+	}
+	if userID != uuid.Nil {
+		job.RequestedBy = &userID
+	}
+
+	if err := database.DB.Create(job).Error; err != nil {
+		// 并发提交时被唯一索引拦截，返回先提交的任务
+		if existing, findErr := findActiveExecutionJob(proposalID); findErr == nil && existing != nil {
+			return existing, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to enqueue execution job: %w", err)
+	}
+
+	log.Printf("📥 [执行队列] 提案 %s 的执行任务已排队: %s", proposalID, job.ID)
+	if runAt.After(time.Now()) {
+		notifyExecutionJob(job, "scheduled")
+	} else {
+		notifyExecutionJob(job, job.Status)
+	}
+	if globalExecutionQueue != nil {
+		globalExecutionQueue.notify()
+	}
+	return job, true, nil
+}
+
+func findActiveExecutionJob(proposalID uuid.UUID) (*models.ProposalExecutionJob, error) {
+	var job models.ProposalExecutionJob
+	result := database.DB.Where("proposal_id = ? AND status IN ?", proposalID,
+		[]string{models.ExecutionJobQueued, models.ExecutionJobRunning}).Limit(1).Find(&job)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query execution jobs: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &job, nil
+}
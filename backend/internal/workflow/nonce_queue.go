@@ -0,0 +1,211 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"web3-enterprise-multisig/internal/blockchain"
+	"web3-enterprise-multisig/internal/database"
+	"web3-enterprise-multisig/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// safeNonceReadTimeout 读取Safe链上nonce的超时时间
+const safeNonceReadTimeout = 15 * time.Second
+
+// ErrInvalidProposalNonce 创建提案时指定的Safe nonce不可用
+var ErrInvalidProposalNonce = errors.New("invalid proposal nonce")
+
+// QueuedProposal Safe nonce队列中的提案摘要
+type QueuedProposal struct {
+	ID                 uuid.UUID `json:"id"`
+	Title              string    `json:"title"`
+	ProposalType       string    `json:"proposal_type"`
+	Status             string    `json:"status"`
+	Nonce              *int64    `json:"nonce"`
+	CurrentSignatures  int       `json:"current_signatures"`
+	RequiredSignatures int       `json:"required_signatures"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// SafeNonceSlot 队列中同一nonce的提案，多个提案共用nonce时只有一个能执行
+type SafeNonceSlot struct {
+	Nonce     int64            `json:"nonce"`
+	Ready     bool             `json:"ready"` // nonce等于链上nonce，可立即执行
+	Stale     bool             `json:"stale"` // nonce已被链上越过，等待对账器处理
+	Conflict  bool             `json:"conflict"`
+	Proposals []QueuedProposal `json:"proposals"`
+}
+
+// SafeNonceQueue 按nonce排序的Safe待执行提案队列
+type SafeNonceQueue struct {
+	SafeID      uuid.UUID        `json:"safe_id"`
+	SafeAddress string           `json:"safe_address"`
+	ChainNonce  int64            `json:"chain_nonce"`
+	NextNonce   int64            `json:"next_nonce"`
+	Conflicts   int              `json:"conflicts"`
+	Slots       []SafeNonceSlot  `json:"slots"`
+	Unassigned  []QueuedProposal `json:"unassigned"` // 队列管理上线前创建、未分配nonce的提案
+}
+
+// CreateProposalWithNonce 为新提案分配Safe nonce，并在同一事务中调用create保存提案
+// 未指定时排在队尾；指定时必须在链上nonce与队尾之间，与已有提案同nonce视为替换（如拒绝提案）
+// 事务先锁定Safe记录再计算队尾，并发创建的提案不会分到相同的nonce
+// 链上nonce不可用时退回数据库中对账得到的nonce，两者都没有时不分配（签名时按链上nonce处理）
+func CreateProposalWithNonce(safe *models.Safe, requested *int64, create func(tx *gorm.DB, nonce *int64) error) error {
+	chainNonce, err := readChainSafeNonce(safe)
+	if err != nil {
+		if safe.Nonce == nil {
+			log.Printf("⚠️ [Nonce队列] 读取Safe %s 链上nonce失败，提案暂不分配nonce: %v", safe.Address, err)
+			return database.DB.Transaction(func(tx *gorm.DB) error {
+				return create(tx, nil)
+			})
+		}
+		log.Printf("⚠️ [Nonce队列] 读取Safe %s 链上nonce失败，使用对账记录的nonce %d: %v", safe.Address, *safe.Nonce, err)
+		chainNonce = *safe.Nonce
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := blockchain.LockSafeNonceQueue(tx, safe.ID); err != nil {
+			return err
+		}
+		nonce, err := assignProposalNonce(tx, safe.ID, chainNonce, requested)
+		if err != nil {
+			return err
+		}
+		return create(tx, nonce)
+	})
+}
+
+// assignProposalNonce 在已锁定nonce队列的事务中计算新提案的nonce
+func assignProposalNonce(tx *gorm.DB, safeID uuid.UUID, chainNonce int64, requested *int64) (*int64, error) {
+	next, err := blockchain.NextSafeNonce(tx, safeID, chainNonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if requested == nil {
+		return &next, nil
+	}
+	if *requested < chainNonce {
+		return nil, fmt.Errorf("%w: nonce %d has already been used, Safe nonce is %d", ErrInvalidProposalNonce, *requested, chainNonce)
+	}
+	if *requested > next {
+		return nil, fmt.Errorf("%w: nonce %d would leave a gap in the queue, next nonce is %d", ErrInvalidProposalNonce, *requested, next)
+	}
+	nonce := *requested
+	return &nonce, nil
+}
+
+// NonceConflicts 返回与指定提案共用Safe nonce的其他待处理提案
+func NonceConflicts(safeID uuid.UUID, nonce int64, excludeID uuid.UUID) ([]QueuedProposal, error) {
+	var proposals []models.Proposal
+	if err := database.DB.Where("safe_id = ? AND nonce = ? AND id <> ? AND status IN ?",
+		safeID, nonce, excludeID, blockchain.QueuedNonceStatuses).
+		Order("created_at ASC").Find(&proposals).Error; err != nil {
+		return nil, fmt.Errorf("failed to query nonce conflicts: %w", err)
+	}
+
+	conflicts := make([]QueuedProposal, 0, len(proposals))
+	for i := range proposals {
+		conflicts = append(conflicts, toQueuedProposal(&proposals[i]))
+	}
+	return conflicts, nil
+}
+
+// GetSafeNonceQueue 返回Safe按nonce排序的提案队列
+// 只读取队列，nonce已被链上越过的提案标记为stale，由Safe对账器确认执行结果后重新排队
+func GetSafeNonceQueue(safeID uuid.UUID) (*SafeNonceQueue, error) {
+	var safe models.Safe
+	if err := database.DB.First(&safe, safeID).Error; err != nil {
+		return nil, err
+	}
+
+	chainNonce, err := readChainSafeNonce(&safe)
+	if err != nil {
+		if safe.Nonce == nil {
+			return nil, fmt.Errorf("failed to read Safe nonce: %w", err)
+		}
+		log.Printf("⚠️ [Nonce队列] 读取Safe %s 链上nonce失败，使用对账记录的nonce %d: %v", safe.Address, *safe.Nonce, err)
+		chainNonce = *safe.Nonce
+	}
+
+	var proposals []models.Proposal
+	if err := database.DB.Where("safe_id = ? AND status IN ?", safe.ID, blockchain.QueuedNonceStatuses).
+		Order("nonce ASC, created_at ASC").Find(&proposals).Error; err != nil {
+		return nil, fmt.Errorf("failed to query queued proposals: %w", err)
+	}
+
+	next, err := blockchain.NextSafeNonce(database.DB, safe.ID, chainNonce)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := &SafeNonceQueue{
+		SafeID:      safe.ID,
+		SafeAddress: safe.Address,
+		ChainNonce:  chainNonce,
+		NextNonce:   next,
+		Slots:       []SafeNonceSlot{},
+		Unassigned:  []QueuedProposal{},
+	}
+
+	slots := make(map[int64]*SafeNonceSlot)
+	for i := range proposals {
+		proposal := &proposals[i]
+		if proposal.Nonce == nil {
+			queue.Unassigned = append(queue.Unassigned, toQueuedProposal(proposal))
+			continue
+		}
+		slot, ok := slots[*proposal.Nonce]
+		if !ok {
+			slot = &SafeNonceSlot{Nonce: *proposal.Nonce, Ready: *proposal.Nonce == chainNonce, Stale: *proposal.Nonce < chainNonce}
+			slots[*proposal.Nonce] = slot
+		}
+		slot.Proposals = append(slot.Proposals, toQueuedProposal(proposal))
+	}
+
+	for _, slot := range slots {
+		slot.Conflict = len(slot.Proposals) > 1
+		if slot.Conflict {
+			queue.Conflicts++
+		}
+		queue.Slots = append(queue.Slots, *slot)
+	}
+	sort.Slice(queue.Slots, func(i, j int) bool { return queue.Slots[i].Nonce < queue.Slots[j].Nonce })
+
+	return queue, nil
+}
+
+func toQueuedProposal(proposal *models.Proposal) QueuedProposal {
+	return QueuedProposal{
+		ID:                 proposal.ID,
+		Title:              proposal.Title,
+		ProposalType:       proposal.ProposalType,
+		Status:             proposal.Status,
+		Nonce:              proposal.Nonce,
+		CurrentSignatures:  proposal.CurrentSignatures,
+		RequiredSignatures: proposal.RequiredSignatures,
+		CreatedAt:          proposal.CreatedAt,
+	}
+}
+
+// readChainSafeNonce 通过Safe所在链的共享连接读取当前nonce
+func readChainSafeNonce(safe *models.Safe) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), safeNonceReadTimeout)
+	defer cancel()
+
+	client, err := blockchain.DefaultChainRegistry().Client(ctx, int64(safe.ChainID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to chain %d for safe %s: %v", safe.ChainID, safe.Address, err)
+	}
+
+	return blockchain.ReadSafeNonce(ctx, client, common.HexToAddress(safe.Address))
+}
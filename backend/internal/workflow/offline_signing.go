@@ -0,0 +1,198 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"web3-enterprise-multisig/internal/blockchain"
+	"web3-enterprise-multisig/internal/database"
+	"web3-enterprise-multisig/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 离线签名：导出待签名提案的EIP-712数据到离线机器，用硬件钱包签名后再导入签名文件
+const (
+	// OfflineSigningBundleFormat 导出的离线签名包格式标识
+	OfflineSigningBundleFormat = "web3-enterprise-multisig/offline-signing-bundle"
+	// OfflineSignaturesFormat 离线签名后导入的签名文件格式标识
+	OfflineSignaturesFormat = "web3-enterprise-multisig/offline-signatures"
+
+	offlineSigningBundleVersion = 1
+)
+
+var (
+	// ErrProposalNotSignable 提案不在待签名状态，不能导出离线签名
+	ErrProposalNotSignable = errors.New("proposal is not pending signatures")
+	// ErrOfflineBundleNotFound 导入时引用的签名包不存在或不是当前用户导出的
+	ErrOfflineBundleNotFound = errors.New("offline signing bundle not found")
+)
+
+// OfflineSigningProposal 离线签名包中的单个提案
+type OfflineSigningProposal struct {
+	ProposalID         uuid.UUID                   `json:"proposal_id"`
+	Title              string                      `json:"title"`
+	ProposalType       string                      `json:"proposal_type"`
+	SafeID             uuid.UUID                   `json:"safe_id"`
+	SafeAddress        string                      `json:"safe_address"`
+	ChainID            int64                       `json:"chain_id"`
+	Nonce              int64                       `json:"nonce"`
+	NonceAssigned      bool                        `json:"nonce_assigned"`
+	RequiredSignatures int                         `json:"required_signatures"`
+	CurrentSignatures  int                         `json:"current_signatures"`
+	SafeTxHash         string                      `json:"safe_tx_hash"`
+	TypedData          *blockchain.SafeTxTypedData `json:"typed_data"`
+}
+
+// OfflineSigningBundle 离线签名包
+// checksum只覆盖签名相关的部分，便于其他语言重算：每个提案取 小写proposal_id + ":" + 小写safe_tx_hash，
+// 按字典序排序后用"\n"连接，对其UTF-8字节计算keccak256（0x前缀的十六进制）
+type OfflineSigningBundle struct {
+	Format     string                   `json:"format"`
+	Version    int                      `json:"version"`
+	BundleID   uuid.UUID                `json:"bundle_id"`
+	ExportedAt time.Time                `json:"exported_at"`
+	ExportedBy uuid.UUID                `json:"exported_by"`
+	Signer     string                   `json:"signer,omitempty"` // 导出用户绑定的钱包，导入的签名必须由该钱包签署
+	Proposals  []OfflineSigningProposal `json:"proposals"`
+	Checksum   string                   `json:"checksum"`
+}
+
+// BuildOfflineSigningBundle 为用户导出待签名提案的离线签名包
+// 每个提案的EIP-712数据与GET /proposals/:id/typed-data一致；任一提案不存在或不在pending状态时整体失败
+func BuildOfflineSigningBundle(userID uuid.UUID, proposalIDs []uuid.UUID) (*OfflineSigningBundle, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	bundle := &OfflineSigningBundle{
+		Format:     OfflineSigningBundleFormat,
+		Version:    offlineSigningBundleVersion,
+		BundleID:   uuid.New(),
+		ExportedAt: time.Now().UTC().Truncate(time.Second),
+		ExportedBy: userID,
+		Proposals:  make([]OfflineSigningProposal, 0, len(proposalIDs)),
+	}
+	if user.WalletAddress != nil && common.IsHexAddress(*user.WalletAddress) {
+		bundle.Signer = common.HexToAddress(*user.WalletAddress).Hex()
+	}
+
+	// 同一条链的提案共用一个执行器连接
+	executors := make(map[int]*blockchain.SafeExecutor)
+	seen := make(map[uuid.UUID]bool, len(proposalIDs))
+	for _, proposalID := range proposalIDs {
+		if seen[proposalID] {
+			continue
+		}
+		seen[proposalID] = true
+
+		var proposal models.Proposal
+		if err := database.DB.Preload("Safe").First(&proposal, proposalID).Error; err != nil {
+			return nil, fmt.Errorf("proposal %s: %w", proposalID, err)
+		}
+		if proposal.Status != "pending" {
+			return nil, fmt.Errorf("%w: proposal %s is %s", ErrProposalNotSignable, proposalID, proposal.Status)
+		}
+
+		executor, ok := executors[proposal.Safe.ChainID]
+		if !ok {
+			var err error
+			if executor, err = dialExecutor(&proposal, nil); err != nil {
+				return nil, err
+			}
+			executors[proposal.Safe.ChainID] = executor
+		}
+
+		typedData, err := buildProposalTypedData(executor, &proposal)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build typed data for proposal %s: %w", proposalID, err)
+		}
+
+		bundle.Proposals = append(bundle.Proposals, OfflineSigningProposal{
+			ProposalID:         proposal.ID,
+			Title:              proposal.Title,
+			ProposalType:       proposal.ProposalType,
+			SafeID:             proposal.SafeID,
+			SafeAddress:        typedData.SafeAddress,
+			ChainID:            typedData.ChainID,
+			Nonce:              typedData.Nonce,
+			NonceAssigned:      typedData.NonceAssigned,
+			RequiredSignatures: proposal.RequiredSignatures,
+			CurrentSignatures:  proposal.CurrentSignatures,
+			SafeTxHash:         typedData.SafeTxHash,
+			TypedData:          typedData.TypedData,
+		})
+	}
+
+	bundle.Checksum = OfflineSigningBundleChecksum(bundle)
+
+	// 记录签名包内容，导入签名时据此拒绝不属于该签名包的签名
+	record := models.OfflineSigningBundle{
+		ID:         bundle.BundleID,
+		Checksum:   bundle.Checksum,
+		ExportedBy: userID,
+		Entries:    offlineSigningBundleEntries(bundle),
+	}
+	if bundle.Signer != "" {
+		record.Signer = &bundle.Signer
+	}
+	if err := database.DB.Create(&record).Error; err != nil {
+		return nil, fmt.Errorf("failed to save offline signing bundle: %w", err)
+	}
+	return bundle, nil
+}
+
+// OfflineSigningBundleChecksum 计算离线签名包的校验和（忽略包中已有的checksum字段）
+func OfflineSigningBundleChecksum(bundle *OfflineSigningBundle) string {
+	return offlineSigningChecksum(offlineSigningBundleEntries(bundle))
+}
+
+// OfflineSigningBundleSafeTxHashes 返回用户导出的签名包中每个提案的safeTxHash（小写）
+// bundleID不为nil时必须与checksum对应的签名包一致
+func OfflineSigningBundleSafeTxHashes(userID uuid.UUID, bundleID *uuid.UUID, checksum string) (map[uuid.UUID]string, error) {
+	query := database.DB.Where("checksum = ? AND exported_by = ?", strings.ToLower(checksum), userID)
+	if bundleID != nil {
+		query = query.Where("id = ?", *bundleID)
+	}
+
+	var record models.OfflineSigningBundle
+	if err := query.Order("created_at DESC").First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: checksum %s", ErrOfflineBundleNotFound, checksum)
+		}
+		return nil, fmt.Errorf("failed to load offline signing bundle: %w", err)
+	}
+
+	hashes := make(map[uuid.UUID]string, len(record.Entries))
+	for _, entry := range record.Entries {
+		proposalID, safeTxHash, ok := strings.Cut(entry, ":")
+		if !ok {
+			continue
+		}
+		if id, err := uuid.Parse(proposalID); err == nil {
+			hashes[id] = safeTxHash
+		}
+	}
+	return hashes, nil
+}
+
+// offlineSigningBundleEntries 签名包的校验和输入：排序后的 "proposal_id:safe_tx_hash"（小写）
+func offlineSigningBundleEntries(bundle *OfflineSigningBundle) []string {
+	entries := make([]string, 0, len(bundle.Proposals))
+	for _, proposal := range bundle.Proposals {
+		entries = append(entries, strings.ToLower(proposal.ProposalID.String()+":"+proposal.SafeTxHash))
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+func offlineSigningChecksum(entries []string) string {
+	return crypto.Keccak256Hash([]byte(strings.Join(entries, "\n"))).Hex()
+}
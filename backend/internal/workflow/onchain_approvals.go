@@ -0,0 +1,94 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"web3-enterprise-multisig/internal/database"
+	"web3-enterprise-multisig/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// OnChainApprovals 提案的链上approveHash审批同步结果
+type OnChainApprovals struct {
+	SafeTxHash         string   `json:"safe_tx_hash"`
+	Approvers          []string `json:"approvers"`
+	CurrentSignatures  int      `json:"current_signatures"`
+	RequiredSignatures int      `json:"required_signatures"`
+	Status             string   `json:"status"`
+}
+
+// SyncOnChainApprovals 查询所有者对提案当前SafeTxHash的approveHash审批，计入签名数
+// 已提交链下签名的所有者不重复计数；达到阈值时提案转为approved，并按Safe配置提交自动执行
+func SyncOnChainApprovals(proposalID uuid.UUID, userID uuid.UUID) (*OnChainApprovals, error) {
+	var proposal models.Proposal
+	if err := database.DB.Preload("Safe").First(&proposal, proposalID).Error; err != nil {
+		return nil, err
+	}
+	if proposal.Status != "pending" && proposal.Status != "approved" {
+		return nil, fmt.Errorf("%w: status %s", ErrProposalNotExecutable, proposal.Status)
+	}
+
+	executor, err := dialExecutor(&proposal, getExecutorSigner())
+	if err != nil {
+		return nil, err
+	}
+	safeTxHash, approvers, err := executor.ApprovedHashOwners(context.Background(), &proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	var signatures []models.Signature
+	if err := database.DB.Preload("Signer").Where("proposal_id = ? AND status = ?", proposalID, "valid").
+		Find(&signatures).Error; err != nil {
+		return nil, fmt.Errorf("failed to query signatures: %w", err)
+	}
+	signed := make(map[string]bool, len(signatures))
+	for _, sig := range signatures {
+		if sig.Signer.WalletAddress != nil {
+			signed[strings.ToLower(*sig.Signer.WalletAddress)] = true
+		}
+	}
+
+	result := &OnChainApprovals{
+		SafeTxHash:         safeTxHash.Hex(),
+		Approvers:          []string{},
+		RequiredSignatures: proposal.RequiredSignatures,
+	}
+	count := len(signatures)
+	for _, approver := range approvers {
+		result.Approvers = append(result.Approvers, approver.Hex())
+		if !signed[strings.ToLower(approver.Hex())] {
+			count++
+		}
+	}
+
+	updates := map[string]interface{}{"current_signatures": count}
+	reachedThreshold := proposal.Status == "pending" && count >= proposal.RequiredSignatures
+	if reachedThreshold {
+		updates["status"] = "approved"
+	}
+	if err := database.DB.Model(&proposal).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update proposal approvals: %w", err)
+	}
+	result.CurrentSignatures = count
+	result.Status = proposal.Status
+	if reachedThreshold {
+		result.Status = "approved"
+	}
+
+	if len(approvers) > 0 {
+		log.Printf("⛓️ 提案 %s 有 %d 个链上approveHash审批，签名数 %d/%d", proposalID, len(approvers), count, proposal.RequiredSignatures)
+	}
+
+	if reachedThreshold {
+		if _, err := ScheduleAutoExecution(proposalID, userID); err != nil {
+			log.Printf("⚠️ 提案 %s 自动执行提交失败: %v", proposalID, err)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"web3-enterprise-multisig/internal/database"
+	"web3-enterprise-multisig/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+)
+
+// ErrProposalNotRejectable 提案状态或类型不允许拒绝
+var ErrProposalNotRejectable = errors.New("proposal cannot be rejected")
+
+// RejectProposal 拒绝提案
+// 已分配Safe nonce的提案需要在同一nonce上执行0值自调用交易才能使已收集的签名失效，
+// 因此创建一个拒绝提案收集签名，拒绝交易上链后原提案转为rejected；
+// 未分配nonce的提案没有可失效的链上状态，直接标记为rejected（返回的拒绝提案为nil）
+// 两种情况都只允许Safe所有者操作，否则返回ErrSignerNotOwner
+func RejectProposal(proposalID uuid.UUID, userID uuid.UUID, reason string) (rejection *models.Proposal, created bool, err error) {
+	var proposal models.Proposal
+	if err := database.DB.Preload("Safe").First(&proposal, proposalID).Error; err != nil {
+		return nil, false, err
+	}
+
+	if proposal.ProposalType == "rejection" {
+		return nil, false, fmt.Errorf("%w: rejection proposals cannot be rejected", ErrProposalNotRejectable)
+	}
+	if proposal.Status != "pending" && proposal.Status != "approved" {
+		return nil, false, fmt.Errorf("%w: status %s", ErrProposalNotRejectable, proposal.Status)
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.WalletAddress == nil || !common.IsHexAddress(*user.WalletAddress) ||
+		!isSafeOwner(&proposal.Safe, common.HexToAddress(*user.WalletAddress)) {
+		return nil, false, fmt.Errorf("%w: user %s", ErrSignerNotOwner, userID)
+	}
+
+	var reasonPtr *string
+	if reason = strings.TrimSpace(reason); reason != "" {
+		reasonPtr = &reason
+	}
+
+	if proposal.Nonce == nil {
+		now := time.Now()
+		if err := database.DB.Model(&proposal).Updates(map[string]interface{}{
+			"status":           "rejected",
+			"rejection_reason": reasonPtr,
+			"rejected_by":      userID,
+			"rejected_at":      now,
+		}).Error; err != nil {
+			return nil, false, fmt.Errorf("failed to reject proposal: %w", err)
+		}
+		log.Printf("🚫 提案 %s 未分配Safe nonce，已直接拒绝 (操作人: %s)", proposalID, userID)
+		return nil, false, nil
+	}
+
+	// 同一提案只保留一个进行中的拒绝提案
+	var existing models.Proposal
+	result := database.DB.Where("rejects_proposal_id = ? AND status IN ?", proposalID, []string{"pending", "approved", "executed"}).
+		Order("created_at ASC").Limit(1).Find(&existing)
+	if result.Error != nil {
+		return nil, false, fmt.Errorf("failed to query rejection proposals: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return &existing, false, nil
+	}
+
+	requiredSignatures := proposal.Safe.Threshold
+	if requiredSignatures <= 0 {
+		requiredSignatures = proposal.RequiredSignatures
+	}
+
+	description := fmt.Sprintf("在Safe nonce %d 上执行0值自调用交易，使提案\"%s\"失效", *proposal.Nonce, proposal.Title)
+	if reasonPtr != nil {
+		description += "\n拒绝原因: " + reason
+	}
+	safeAddress := proposal.Safe.Address
+	nonce := *proposal.Nonce
+	rejection = &models.Proposal{
+		SafeID:             proposal.SafeID,
+		CreatedBy:          userID,
+		Title:              fmt.Sprintf("拒绝提案: %s", proposal.Title),
+		Description:        &description,
+		ProposalType:       "rejection",
+		ToAddress:          &safeAddress,
+		Value:              "0",
+		Status:             "pending",
+		RequiredSignatures: requiredSignatures,
+		Nonce:              &nonce,
+		RejectsProposalID:  &proposal.ID,
+		RejectionReason:    reasonPtr,
+	}
+
+	if err := database.DB.Create(rejection).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to create rejection proposal: %w", err)
+	}
+	log.Printf("🚫 已为提案 %s 创建链上拒绝提案 %s (Safe nonce: %d, 操作人: %s)", proposalID, rejection.ID, nonce, userID)
+
+	// 通知Safe所有者签名拒绝提案（异步，不阻塞响应）
+	go func() {
+		if err := InitializeProposalWorkflow(rejection.ID); err != nil {
+			log.Printf("Failed to initialize workflow for rejection proposal %s: %v", rejection.ID, err)
+		}
+	}()
+
+	return rejection, true, nil
+}
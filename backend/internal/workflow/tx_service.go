@@ -0,0 +1,547 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"web3-enterprise-multisig/internal/blockchain"
+	"web3-enterprise-multisig/internal/database"
+	"web3-enterprise-multisig/internal/models"
+	"web3-enterprise-multisig/internal/services"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Safe Transaction Service兼容接口
+// 外部Safe SDK脚本按官方交易服务的格式提交SafeTx和所有者签名，这里映射为models.Proposal和models.Signature
+
+var (
+	// ErrInvalidSafeTx 外部提交的SafeTx参数无效
+	ErrInvalidSafeTx = errors.New("invalid Safe transaction")
+	// ErrDelegateCallNotAllowed DELEGATECALL的目标不是MultiSendCallOnly或链配置的白名单合约
+	ErrDelegateCallNotAllowed = errors.New("DELEGATECALL target is not allowed")
+	// ErrSafeTxHashMismatch contractTransactionHash与按参数重建的safeTxHash不一致
+	ErrSafeTxHashMismatch = errors.New("contractTransactionHash does not match the Safe transaction")
+	// ErrSignerNotOwner 签名者不是Safe所有者
+	ErrSignerNotOwner = errors.New("signer is not an owner of the Safe")
+	// ErrSignerNotRegistered 签名者钱包未绑定系统用户，无法记录签名
+	ErrSignerNotRegistered = errors.New("signer wallet is not linked to a user")
+	// ErrAlreadyConfirmed 签名者已确认过该交易
+	ErrAlreadyConfirmed = errors.New("signer has already confirmed the transaction")
+	// ErrTransactionNotConfirmable 交易已执行或已拒绝，不再接受确认
+	ErrTransactionNotConfirmable = errors.New("transaction can no longer be confirmed")
+)
+
+// ExternalSafeTx 外部提交的Safe交易，对应交易服务POST multisig-transactions的请求体
+type ExternalSafeTx struct {
+	To                      string
+	Value                   string
+	Data                    string
+	Operation               int
+	SafeTxGas               string
+	BaseGas                 string
+	GasPrice                string
+	GasToken                string
+	RefundReceiver          string
+	Nonce                   int64
+	ContractTransactionHash string
+	Sender                  string
+	Signature               string // 可为空，只登记交易不附带确认
+	Origin                  string
+}
+
+// ProposalSafeTx 提案对应的Safe交易及其safeTxHash
+type ProposalSafeTx struct {
+	Proposal   *models.Proposal
+	SafeTx     *blockchain.SafeTransaction
+	SafeTxHash common.Hash
+}
+
+// BuildExternalProposal 将外部提交的Safe交易转换为待保存的提案，并校验contractTransactionHash
+// 带调用数据的交易按contract_call处理，否则按transfer处理；签名数按Safe阈值
+func BuildExternalProposal(safe *models.Safe, userID uuid.UUID, tx ExternalSafeTx) (*models.Proposal, error) {
+	if !common.IsHexAddress(tx.To) {
+		return nil, fmt.Errorf("%w: invalid to address %q", ErrInvalidSafeTx, tx.To)
+	}
+	if tx.Operation != models.OperationCall && tx.Operation != models.OperationDelegateCall {
+		return nil, fmt.Errorf("%w: invalid operation %d", ErrInvalidSafeTx, tx.Operation)
+	}
+	if tx.Nonce < 0 {
+		return nil, fmt.Errorf("%w: invalid nonce %d", ErrInvalidSafeTx, tx.Nonce)
+	}
+
+	var callData []byte
+	if tx.Data != "" {
+		decoded, err := hexutil.Decode(tx.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: data is not valid hex: %v", ErrInvalidSafeTx, err)
+		}
+		callData = decoded
+	}
+	// 与创建提案接口一致：DELEGATECALL必须带调用数据，且只能调用MultiSendCallOnly或白名单合约
+	if tx.Operation == models.OperationDelegateCall {
+		if len(callData) == 0 {
+			return nil, fmt.Errorf("%w: DELEGATECALL requires call data", ErrInvalidSafeTx)
+		}
+		chain, err := blockchain.DefaultChainRegistry().Get(int64(safe.ChainID))
+		if err != nil {
+			return nil, fmt.Errorf("chain %d is not configured for safe %s: %v", safe.ChainID, safe.Address, err)
+		}
+		if !chain.AllowsDelegateCall(tx.To) {
+			return nil, fmt.Errorf("%w: %w: %s", ErrInvalidSafeTx, ErrDelegateCallNotAllowed, tx.To)
+		}
+	}
+
+	value, err := externalUint("value", tx.Value)
+	if err != nil {
+		return nil, err
+	}
+	safeTxGas, err := externalUint("safeTxGas", tx.SafeTxGas)
+	if err != nil {
+		return nil, err
+	}
+	baseGas, err := externalUint("baseGas", tx.BaseGas)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := externalUint("gasPrice", tx.GasPrice)
+	if err != nil {
+		return nil, err
+	}
+	gasToken, err := externalAddress("gasToken", tx.GasToken)
+	if err != nil {
+		return nil, err
+	}
+	refundReceiver, err := externalAddress("refundReceiver", tx.RefundReceiver)
+	if err != nil {
+		return nil, err
+	}
+
+	proposalType := "transfer"
+	if len(callData) > 0 {
+		proposalType = "contract_call"
+	}
+
+	to := common.HexToAddress(tx.To).Hex()
+	data := hexutil.Encode(callData)
+	nonce := tx.Nonce
+	description := "通过Safe Transaction Service兼容接口提交"
+	if origin := strings.TrimSpace(tx.Origin); origin != "" {
+		description += "\norigin: " + origin
+	}
+
+	proposal := &models.Proposal{
+		SafeID:             safe.ID,
+		CreatedBy:          userID,
+		Title:              fmt.Sprintf("Safe交易 #%d", nonce),
+		Description:        &description,
+		ProposalType:       proposalType,
+		ToAddress:          &to,
+		Value:              value,
+		Data:               &data,
+		Operation:          tx.Operation,
+		SafeTxGas:          safeTxGas,
+		BaseGas:            baseGas,
+		GasPrice:           gasPrice,
+		GasToken:           gasToken,
+		RefundReceiver:     refundReceiver,
+		Status:             "pending",
+		RequiredSignatures: safe.Threshold,
+		Nonce:              &nonce,
+	}
+
+	safeTx, err := blockchain.SafeTxFromProposal(proposal, big.NewInt(nonce))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSafeTx, err)
+	}
+	safeTxHash := blockchain.ComputeSafeTxHash(big.NewInt(int64(safe.ChainID)), common.HexToAddress(safe.Address), safeTx).Hex()
+	if !strings.EqualFold(tx.ContractTransactionHash, safeTxHash) {
+		return nil, fmt.Errorf("%w: submitted %s, expected %s", ErrSafeTxHashMismatch, tx.ContractTransactionHash, safeTxHash)
+	}
+	proposal.SafeTxHash = &safeTxHash
+
+	return proposal, nil
+}
+
+// ProposeExternalTransaction 保存外部提交的Safe交易，附带签名时作为发送者的第一个确认
+// 同一Safe上已有相同safeTxHash的提案时不重复创建，只补充发送者的确认（与交易服务的幂等行为一致）
+// 返回值created表示是否新建了提案
+func ProposeExternalTransaction(safe *models.Safe, proposal *models.Proposal, sender, signature string) (*models.Proposal, bool, error) {
+	if !common.IsHexAddress(sender) || !isSafeOwner(safe, common.HexToAddress(sender)) {
+		return nil, false, fmt.Errorf("%w: sender %s", ErrSignerNotOwner, sender)
+	}
+
+	existing, err := FindProposalBySafeTxHash(*proposal.SafeTxHash)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+	if existing != nil && existing.SafeID == safe.ID {
+		if signature != "" && (existing.Status == "pending" || existing.Status == "approved") {
+			if _, err := ConfirmExternalTransaction(existing, signature); err != nil {
+				if !errors.Is(err, ErrAlreadyConfirmed) {
+					return nil, false, err
+				}
+			} else if existing, err = FindProposalBySafeTxHash(*proposal.SafeTxHash); err != nil {
+				return nil, false, err
+			}
+		}
+		return existing, false, nil
+	}
+
+	proposal.Safe = *safe
+	var verified *blockchain.VerifiedSignature
+	var signer *models.User
+	if signature != "" {
+		verified, signer, err = verifyExternalSignature(proposal, signature)
+		if err != nil {
+			return nil, false, err
+		}
+		if verified.Signer != common.HexToAddress(sender) {
+			return nil, false, &blockchain.SignatureVerificationError{
+				Reason: fmt.Sprintf("signature was produced by %s, not by sender %s", verified.Signer.Hex(), common.HexToAddress(sender).Hex()),
+			}
+		}
+	}
+
+	created, err := createExternalProposal(proposal, verified, signer)
+	if err != nil {
+		return nil, false, err
+	}
+	return created, true, nil
+}
+
+// ConfirmExternalTransaction 记录所有者对提案的确认，签名者从Safe格式的签名中解析
+func ConfirmExternalTransaction(proposal *models.Proposal, signature string) (*models.Signature, error) {
+	if proposal.Status != "pending" && proposal.Status != "approved" {
+		return nil, fmt.Errorf("%w: status %s", ErrTransactionNotConfirmable, proposal.Status)
+	}
+
+	verified, signer, err := verifyExternalSignature(proposal, signature)
+	if err != nil {
+		return nil, err
+	}
+	return addConfirmation(proposal, verified, signer)
+}
+
+// FindProposalBySafeTxHash 按safeTxHash查找提案，同一哈希有多个提案时优先返回已执行的
+func FindProposalBySafeTxHash(safeTxHash string) (*models.Proposal, error) {
+	var proposal models.Proposal
+	err := database.DB.Preload("Safe").Preload("Creator").
+		Preload("Signatures", "status = ?", "valid").Preload("Signatures.Signer").
+		Where("LOWER(safe_tx_hash) = ?", strings.ToLower(safeTxHash)).
+		Order("CASE WHEN status IN ('executed','confirmed','failed') THEN 0 ELSE 1 END, created_at DESC").
+		First(&proposal).Error
+	if err != nil {
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+// ResolveProposalSafeTxs 构建Safe下提案对应的Safe交易，整批只连接一次链
+// 未分配nonce的提案按首个有效签名使用的nonce构建；已分配nonce的提案哈希不再变化，写回proposals.safe_tx_hash供按哈希查询
+// 单个提案构建失败时跳过并记录日志
+func ResolveProposalSafeTxs(safe *models.Safe, proposals []models.Proposal) ([]ProposalSafeTx, error) {
+	resolved := make([]ProposalSafeTx, 0, len(proposals))
+	if len(proposals) == 0 {
+		return resolved, nil
+	}
+
+	executor, err := dialExecutor(&models.Proposal{Safe: *safe}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range proposals {
+		proposal := &proposals[i]
+		proposal.Safe = *safe
+
+		build := *proposal
+		if build.Nonce == nil {
+			build.Nonce = signedNonce(proposal)
+		}
+
+		safeTx, safeTxHash, err := executor.ProposalSafeTx(&build)
+		if err != nil {
+			log.Printf("⚠️ [交易服务] 构建提案 %s 的Safe交易失败: %v", proposal.ID, err)
+			continue
+		}
+
+		hashHex := safeTxHash.Hex()
+		if proposal.Nonce != nil && (proposal.SafeTxHash == nil || !strings.EqualFold(*proposal.SafeTxHash, hashHex)) {
+			if err := database.DB.Model(&models.Proposal{}).Where("id = ?", proposal.ID).
+				Update("safe_tx_hash", hashHex).Error; err != nil {
+				log.Printf("⚠️ [交易服务] 保存提案 %s 的safeTxHash失败: %v", proposal.ID, err)
+			}
+			proposal.SafeTxHash = &hashHex
+		}
+
+		resolved = append(resolved, ProposalSafeTx{Proposal: proposal, SafeTx: safeTx, SafeTxHash: safeTxHash})
+	}
+	return resolved, nil
+}
+
+// ReadSafeChainInfo 读取Safe链上的所有者、阈值、nonce和部署配置
+func ReadSafeChainInfo(safe *models.Safe) (*blockchain.SafeOnChainState, *blockchain.SafeSetup, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), safeNonceReadTimeout)
+	defer cancel()
+
+	client, err := blockchain.DefaultChainRegistry().Client(ctx, int64(safe.ChainID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to chain %d for safe %s: %v", safe.ChainID, safe.Address, err)
+	}
+
+	safeAddress := common.HexToAddress(safe.Address)
+	state, err := blockchain.ReadSafeState(ctx, client, safeAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	setup, err := blockchain.ReadSafeSetup(ctx, client, safeAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state, setup, nil
+}
+
+// verifyExternalSignature 从签名中解析签名者并按提案的safeTxHash验证，签名者必须是已绑定用户的Safe所有者
+func verifyExternalSignature(proposal *models.Proposal, signature string) (*blockchain.VerifiedSignature, *models.User, error) {
+	if proposal.SafeTxHash == nil {
+		return nil, nil, fmt.Errorf("proposal %s has no safeTxHash", proposal.ID)
+	}
+	signatureBytes, err := hexutil.Decode(signature)
+	if err != nil {
+		return nil, nil, &blockchain.SignatureVerificationError{Reason: fmt.Sprintf("signature is not valid hex: %v", err)}
+	}
+
+	signerAddress, signatureType, signerSignature, err := blockchain.SafeSignatureSigner(common.HexToHash(*proposal.SafeTxHash), signatureBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return verifyOwnerSignature(proposal, signerAddress, signatureType, signerSignature)
+}
+
+// verifyOwnerSignature 验证所有者针对提案safeTxHash的签名，所有者必须已绑定系统用户
+// signatureType为空时自动识别EIP-712/eth_sign签名，合约签名需传入blockchain.SafeSignatureContract
+func verifyOwnerSignature(proposal *models.Proposal, signerAddress common.Address, signatureType string, signature []byte) (*blockchain.VerifiedSignature, *models.User, error) {
+	if proposal.SafeTxHash == nil {
+		return nil, nil, fmt.Errorf("proposal %s has no safeTxHash", proposal.ID)
+	}
+	if !isSafeOwner(&proposal.Safe, signerAddress) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrSignerNotOwner, signerAddress.Hex())
+	}
+
+	var signer models.User
+	if err := database.DB.Where("LOWER(wallet_address) = ?", strings.ToLower(signerAddress.Hex())).
+		First(&signer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("%w: %s", ErrSignerNotRegistered, signerAddress.Hex())
+		}
+		return nil, nil, err
+	}
+
+	safeTxHash := common.HexToHash(*proposal.SafeTxHash)
+	verified, err := VerifyProposalSignature(proposal, signerAddress.Hex(), signatureType, hexutil.Encode(signature), proposal.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 链上状态变化（如提案未分配nonce）时服务端重建的哈希可能与提交的不同
+	if verified.SafeTxHash != safeTxHash {
+		return nil, nil, fmt.Errorf("%w: signature is for %s, proposal is %s", ErrSafeTxHashMismatch, verified.SafeTxHash.Hex(), safeTxHash.Hex())
+	}
+	return verified, &signer, nil
+}
+
+// createExternalProposal 保存已校验的外部提案，verified不为nil时同时记录第一个确认
+// 提案nonce必须在链上nonce与队尾之间，与其他提案同nonce时按替换处理；nonce校验与保存在同一事务中
+// 保存后通知所有者签名，达到阈值时提交自动执行任务
+func createExternalProposal(proposal *models.Proposal, verified *blockchain.VerifiedSignature, signer *models.User) (*models.Proposal, error) {
+	approved := false
+	// safeTxHash已按外部指定的nonce计算，这里只校验nonce可用，不改写
+	err := CreateProposalWithNonce(&proposal.Safe, proposal.Nonce, func(tx *gorm.DB, _ *int64) error {
+		if err := tx.Omit(clause.Associations).Create(proposal).Error; err != nil {
+			return fmt.Errorf("failed to create proposal: %w", err)
+		}
+		if verified == nil {
+			return nil
+		}
+		var err error
+		_, approved, err = recordConfirmation(tx, proposal, signer.ID, verified)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("📥 [交易服务] 外部Safe交易已保存为提案 %s (safeTxHash: %s, nonce: %d)", proposal.ID, *proposal.SafeTxHash, *proposal.Nonce)
+
+	// 通知Safe所有者签名（异步，不阻塞调用方）
+	proposalID := proposal.ID
+	go func() {
+		if err := InitializeProposalWorkflow(proposalID); err != nil {
+			log.Printf("Failed to initialize workflow for proposal %s: %v", proposalID, err)
+		}
+	}()
+
+	if approved {
+		scheduleAfterConfirmation(proposalID, signer.ID)
+	}
+
+	return FindProposalBySafeTxHash(*proposal.SafeTxHash)
+}
+
+// addConfirmation 记录已验证的所有者确认，同一所有者只记录一次
+// 记录前按sign阶段强制检查策略，达到阈值后提交自动执行任务
+func addConfirmation(proposal *models.Proposal, verified *blockchain.VerifiedSignature, signer *models.User) (*models.Signature, error) {
+	var count int64
+	if err := database.DB.Model(&models.Signature{}).
+		Where("proposal_id = ? AND signer_id = ? AND status = ?", proposal.ID, signer.ID, "valid").
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to query signatures: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyConfirmed, verified.Signer.Hex())
+	}
+
+	policyService := services.NewPolicyService(database.DB)
+	if _, err := policyService.EnforcePolicies(context.Background(), proposal.ID, signer.ID, services.PolicyStageSign); err != nil {
+		return nil, err
+	}
+
+	var confirmation *models.Signature
+	approved := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		confirmation, approved, err = recordConfirmation(tx, proposal, signer.ID, verified)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("✍️ [交易服务] 所有者 %s 确认了提案 %s", verified.Signer.Hex(), proposal.ID)
+
+	if approved {
+		scheduleAfterConfirmation(proposal.ID, signer.ID)
+	}
+	return confirmation, nil
+}
+
+// recordConfirmation 保存已验证的签名并更新提案签名数，返回是否因此达到阈值
+// 锁定提案行串行化同一提案的并发确认，签名数按有效签名重新统计
+func recordConfirmation(tx *gorm.DB, proposal *models.Proposal, signerID uuid.UUID, verified *blockchain.VerifiedSignature) (*models.Signature, bool, error) {
+	var locked models.Proposal
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status", "required_signatures").
+		First(&locked, "id = ?", proposal.ID).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to lock proposal: %w", err)
+	}
+
+	var existing int64
+	if err := tx.Model(&models.Signature{}).
+		Where("proposal_id = ? AND signer_id = ? AND status = ?", proposal.ID, signerID, "valid").
+		Count(&existing).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to query signatures: %w", err)
+	}
+	if existing > 0 {
+		return nil, false, fmt.Errorf("%w: %s", ErrAlreadyConfirmed, verified.Signer.Hex())
+	}
+
+	usedNonce := verified.Nonce.Int64()
+	safeTxHash := verified.SafeTxHash.Hex()
+	signature := &models.Signature{
+		ProposalID:    proposal.ID,
+		SignerID:      signerID,
+		SignatureData: hexutil.Encode(verified.Signature),
+		SignatureType: verified.SignatureType,
+		Status:        "valid",
+		UsedNonce:     &usedNonce,
+		SafeTxHash:    &safeTxHash,
+	}
+	if err := SaveProposalSignature(tx, signature); err != nil {
+		return nil, false, err
+	}
+
+	var count int64
+	if err := tx.Model(&models.Signature{}).
+		Where("proposal_id = ? AND status = ?", proposal.ID, "valid").
+		Count(&count).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to count signatures: %w", err)
+	}
+	updates := map[string]interface{}{
+		"current_signatures": int(count),
+		"safe_tx_hash":       safeTxHash,
+	}
+	approved := locked.Status == "pending" && int(count) >= locked.RequiredSignatures
+	if approved {
+		updates["status"] = "approved"
+		updates["approved_at"] = time.Now()
+	}
+	if err := tx.Model(&models.Proposal{}).Where("id = ?", proposal.ID).Updates(updates).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to update proposal: %w", err)
+	}
+
+	proposal.CurrentSignatures = int(count)
+	proposal.Status = locked.Status
+	if approved {
+		proposal.Status = "approved"
+	}
+	return signature, approved, nil
+}
+
+// scheduleAfterConfirmation 达到签名阈值后，Safe开启了自动执行时提交执行任务
+func scheduleAfterConfirmation(proposalID, userID uuid.UUID) {
+	if job, err := ScheduleAutoExecution(proposalID, userID); err != nil {
+		log.Printf("⚠️ 提案 %s 自动执行提交失败: %v", proposalID, err)
+	} else if job != nil {
+		log.Printf("Proposal %s scheduled for auto-execution at %s (job %s)", proposalID, job.NextRunAt.Format(time.RFC3339), job.ID)
+	}
+}
+
+// signedNonce 返回提案首个有效签名使用的nonce（需预加载Signatures）
+func signedNonce(proposal *models.Proposal) *int64 {
+	for _, signature := range proposal.Signatures {
+		if signature.Status == "valid" && signature.UsedNonce != nil {
+			nonce := *signature.UsedNonce
+			return &nonce
+		}
+	}
+	return nil
+}
+
+func isSafeOwner(safe *models.Safe, address common.Address) bool {
+	for _, owner := range safe.Owners {
+		if common.IsHexAddress(owner) && common.HexToAddress(owner) == address {
+			return true
+		}
+	}
+	return false
+}
+
+// externalUint 校验十进制无符号整数参数，空值按0处理
+func externalUint(name, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "0", nil
+	}
+	parsed, ok := new(big.Int).SetString(value, 10)
+	if !ok || parsed.Sign() < 0 {
+		return "", fmt.Errorf("%w: %s must be a non-negative integer", ErrInvalidSafeTx, name)
+	}
+	return parsed.String(), nil
+}
+
+// externalAddress 校验地址参数，空值按零地址处理
+func externalAddress(name, value string) (string, error) {
+	if value == "" {
+		return common.Address{}.Hex(), nil
+	}
+	if !common.IsHexAddress(value) {
+		return "", fmt.Errorf("%w: invalid %s %q", ErrInvalidSafeTx, name, value)
+	}
+	return common.HexToAddress(value).Hex(), nil
+}
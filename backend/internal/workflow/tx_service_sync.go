@@ -0,0 +1,378 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"web3-enterprise-multisig/internal/blockchain"
+	"web3-enterprise-multisig/internal/database"
+	"web3-enterprise-multisig/internal/models"
+	"web3-enterprise-multisig/internal/services"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// defaultTxServiceSyncInterval 从交易服务同步待执行交易的默认间隔
+const defaultTxServiceSyncInterval = 2 * time.Minute
+
+// TxServiceSyncResult 单个Safe的同步结果
+type TxServiceSyncResult struct {
+	Fetched       int `json:"fetched"`       // 交易服务返回的待执行交易数
+	Imported      int `json:"imported"`      // 新建的提案数
+	Confirmations int `json:"confirmations"` // 新记录的确认数
+	Skipped       int `json:"skipped"`       // 校验失败或无法记录而跳过的交易和确认数
+}
+
+// TxServiceSyncer 定时从Safe Transaction Service拉取本系统Safe的待执行多签交易和确认
+// 交易按safeTxHash去重后导入为提案，确认逐个验证签名后才计入签名数
+type TxServiceSyncer struct {
+	chain    *blockchain.ChainConfig
+	service  *blockchain.SafeService
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewTxServiceSyncer 为链创建交易服务同步器，链必须配置了交易服务地址
+func NewTxServiceSyncer(chain *blockchain.ChainConfig) (*TxServiceSyncer, error) {
+	if chain.SafeServiceURL == "" {
+		return nil, fmt.Errorf("chain %d has no Safe Transaction Service URL configured", chain.ChainID)
+	}
+
+	interval := defaultTxServiceSyncInterval
+	if v := os.Getenv("SAFE_SERVICE_SYNC_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			interval = parsed
+		} else {
+			log.Printf("⚠️ 无效的SAFE_SERVICE_SYNC_INTERVAL: %s，使用默认值 %s", v, defaultTxServiceSyncInterval)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TxServiceSyncer{
+		chain:    chain,
+		service:  blockchain.NewSafeServiceClient(chain.SafeServiceURL, os.Getenv("SAFE_SERVICE_API_KEY")),
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// Start 启动定时同步，启动后立即同步一次
+func (s *TxServiceSyncer) Start() {
+	log.Printf("🔄 启动交易服务同步器 (链: %s, 服务: %s, 间隔: %v)", s.chain.Name, s.chain.SafeServiceURL, s.interval)
+
+	go func() {
+		s.syncAll()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.syncAll()
+			case <-s.ctx.Done():
+				log.Printf("🛑 交易服务同步器收到停止信号 (链: %s)", s.chain.Name)
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止同步器
+func (s *TxServiceSyncer) Stop() {
+	s.cancel()
+}
+
+// syncAll 同步链上所有活跃Safe
+func (s *TxServiceSyncer) syncAll() {
+	var safes []models.Safe
+	if err := database.DB.WithContext(s.ctx).
+		Where("chain_id = ? AND status = ?", s.chain.ChainID, "active").
+		Find(&safes).Error; err != nil {
+		log.Printf("❌ [交易服务同步] 查询Safe列表失败: %v", err)
+		return
+	}
+
+	for i := range safes {
+		if s.ctx.Err() != nil {
+			return
+		}
+		result, err := s.SyncSafe(s.ctx, &safes[i])
+		if err != nil {
+			log.Printf("❌ [交易服务同步] Safe %s 同步失败: %v", safes[i].Address, err)
+			continue
+		}
+		if result.Imported > 0 || result.Confirmations > 0 || result.Skipped > 0 {
+			log.Printf("📥 [交易服务同步] Safe %s: 拉取 %d 笔，新建提案 %d 个，新增确认 %d 个，跳过 %d 项",
+				safes[i].Address, result.Fetched, result.Imported, result.Confirmations, result.Skipped)
+		}
+	}
+}
+
+// SyncSafe 拉取Safe在交易服务中尚未执行的交易，导入为提案并补充确认
+// 单笔交易或单个确认校验失败时跳过并记录日志，不影响其他交易
+func (s *TxServiceSyncer) SyncSafe(ctx context.Context, safe *models.Safe) (*TxServiceSyncResult, error) {
+	if int64(safe.ChainID) != s.chain.ChainID {
+		return nil, fmt.Errorf("safe %s is on chain %d, syncer is for chain %d", safe.Address, safe.ChainID, s.chain.ChainID)
+	}
+
+	// 链上nonce之前的交易已无法执行，只拉取之后的；链上nonce不可用时退回对账记录
+	nonceFrom := safe.Nonce
+	if chainNonce, err := readChainSafeNonce(safe); err == nil {
+		nonceFrom = &chainNonce
+	} else {
+		log.Printf("⚠️ [交易服务同步] 读取Safe %s 链上nonce失败: %v", safe.Address, err)
+	}
+
+	transactions, err := s.service.GetQueuedMultisigTransactions(ctx, common.HexToAddress(safe.Address), nonceFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按nonce升序导入，保证分配nonce时队列中没有空洞
+	sort.SliceStable(transactions, func(i, j int) bool {
+		ni, _ := transactions[i].Nonce.Int64()
+		nj, _ := transactions[j].Nonce.Int64()
+		return ni < nj
+	})
+
+	result := &TxServiceSyncResult{Fetched: len(transactions)}
+	for i := range transactions {
+		if ctx.Err() != nil {
+			break
+		}
+		tx := &transactions[i]
+		if tx.IsExecuted {
+			continue
+		}
+		if nonce, err := tx.Nonce.Int64(); err == nil && nonceFrom != nil && nonce < *nonceFrom {
+			continue
+		}
+		if err := s.syncTransaction(ctx, safe, tx, result); err != nil {
+			result.Skipped++
+			log.Printf("⚠️ [交易服务同步] 跳过Safe %s 的交易 %s: %v", safe.Address, tx.SafeTxHash, err)
+		}
+	}
+	return result, nil
+}
+
+// syncTransaction 按safeTxHash去重导入单笔交易，再逐个验证并记录其确认
+func (s *TxServiceSyncer) syncTransaction(ctx context.Context, safe *models.Safe, tx *blockchain.TxServiceMultisigTransaction, result *TxServiceSyncResult) error {
+	if tx.Safe != "" && (!common.IsHexAddress(tx.Safe) || common.HexToAddress(tx.Safe) != common.HexToAddress(safe.Address)) {
+		return fmt.Errorf("transaction belongs to safe %s", tx.Safe)
+	}
+
+	proposal, err := FindProposalBySafeTxHash(tx.SafeTxHash)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if proposal == nil {
+		// 本地提案重新排队后清空了safeTxHash，交易服务中仍是旧nonce的交易，不能再导入为新提案
+		requeued, err := findRequeuedProposalBySafeTxHash(safe.ID, tx.SafeTxHash)
+		if err != nil {
+			return err
+		}
+		if requeued != nil {
+			log.Printf("⏭️ [交易服务同步] 交易 %s 属于已重新排队的提案 %s，跳过", tx.SafeTxHash, requeued.ID)
+			return nil
+		}
+
+		proposal, err = importTxServiceTransaction(ctx, safe, tx)
+		if err != nil {
+			return err
+		}
+		result.Imported++
+	} else if proposal.SafeID != safe.ID {
+		return fmt.Errorf("safeTxHash already belongs to proposal %s of another safe", proposal.ID)
+	}
+
+	if proposal.Status != "pending" && proposal.Status != "approved" {
+		return nil
+	}
+
+	confirmed := make(map[common.Address]bool, len(proposal.Signatures))
+	for _, signature := range proposal.Signatures {
+		if signature.Signer.WalletAddress != nil && common.IsHexAddress(*signature.Signer.WalletAddress) {
+			confirmed[common.HexToAddress(*signature.Signer.WalletAddress)] = true
+		}
+	}
+
+	for _, confirmation := range tx.Confirmations {
+		if ctx.Err() != nil {
+			return nil
+		}
+		// approveHash确认由执行时读取链上approvedHashes计入，不需要导入
+		if confirmation.SignatureType == "APPROVED_HASH" {
+			continue
+		}
+		if !common.IsHexAddress(confirmation.Owner) {
+			result.Skipped++
+			log.Printf("⚠️ [交易服务同步] 交易 %s 的确认所有者地址无效: %q", tx.SafeTxHash, confirmation.Owner)
+			continue
+		}
+		owner := common.HexToAddress(confirmation.Owner)
+		if confirmed[owner] {
+			continue
+		}
+
+		if err := importTxServiceConfirmation(proposal, owner, confirmation); err != nil {
+			if errors.Is(err, ErrAlreadyConfirmed) {
+				confirmed[owner] = true
+				continue
+			}
+			result.Skipped++
+			log.Printf("⚠️ [交易服务同步] 跳过所有者 %s 对交易 %s 的确认: %v", owner.Hex(), tx.SafeTxHash, err)
+			continue
+		}
+		confirmed[owner] = true
+		result.Confirmations++
+	}
+	return nil
+}
+
+// findRequeuedProposalBySafeTxHash 按签名记录的safeTxHash查找已重新排队的本地提案
+// 重新排队会清空提案的safeTxHash，原哈希只保留在失效的签名记录中
+func findRequeuedProposalBySafeTxHash(safeID uuid.UUID, safeTxHash string) (*models.Proposal, error) {
+	var proposal models.Proposal
+	err := database.DB.Where("safe_id = ? AND id IN (?)", safeID,
+		database.DB.Model(&models.Signature{}).Select("proposal_id").
+			Where("LOWER(safe_tx_hash) = ? AND status <> ?", strings.ToLower(safeTxHash), "valid")).
+		First(&proposal).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query requeued proposals: %w", err)
+	}
+	return &proposal, nil
+}
+
+// importTxServiceTransaction 将交易服务中的交易保存为提案
+// 与兼容接口提交的交易相同：按参数重建safeTxHash校验、按创建阶段检查策略、按nonce队列规则分配nonce
+func importTxServiceTransaction(ctx context.Context, safe *models.Safe, tx *blockchain.TxServiceMultisigTransaction) (*models.Proposal, error) {
+	nonce, err := tx.Nonce.Int64()
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid nonce %q", ErrInvalidSafeTx, tx.Nonce)
+	}
+
+	creatorID, err := txServiceProposer(safe, tx.Proposer)
+	if err != nil {
+		return nil, err
+	}
+
+	proposal, err := BuildExternalProposal(safe, creatorID, ExternalSafeTx{
+		To:                      tx.To,
+		Value:                   tx.Value.String(),
+		Data:                    stringValue(tx.Data),
+		Operation:               tx.Operation,
+		SafeTxGas:               tx.SafeTxGas.String(),
+		BaseGas:                 tx.BaseGas.String(),
+		GasPrice:                tx.GasPrice.String(),
+		GasToken:                stringValue(tx.GasToken),
+		RefundReceiver:          stringValue(tx.RefundReceiver),
+		Nonce:                   nonce,
+		ContractTransactionHash: tx.SafeTxHash,
+		Origin:                  txServiceOrigin(tx.Origin),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	policyService := services.NewPolicyService(database.DB)
+	policyResult, err := policyService.ValidatePolicies(ctx, services.PolicyValidationRequest{
+		SafeID:       safe.ID,
+		ProposalType: proposal.ProposalType,
+		ToAddress:    proposal.ToAddress,
+		Value:        proposal.Value,
+		Data:         proposal.Data,
+		UserID:       creatorID,
+		Context: map[string]interface{}{
+			"action":    "create_proposal",
+			"operation": proposal.Operation,
+			"source":    "tx_service_sync",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate policies: %w", err)
+	}
+	if !policyResult.Passed {
+		return nil, fmt.Errorf("rejected by policies: %s", strings.Join(append(policyResult.FailedPolicies, policyResult.ValidationErrors...), "; "))
+	}
+
+	proposal.Safe = *safe
+	return createExternalProposal(proposal, nil, nil)
+}
+
+// importTxServiceConfirmation 验证交易服务返回的确认签名，通过后记录为提案签名
+func importTxServiceConfirmation(proposal *models.Proposal, owner common.Address, confirmation blockchain.TxServiceConfirmation) error {
+	signature, err := hexutil.Decode(confirmation.Signature)
+	if err != nil {
+		return &blockchain.SignatureVerificationError{Reason: fmt.Sprintf("signature is not valid hex: %v", err)}
+	}
+
+	// 交易服务返回的合约签名是传给isValidSignature的数据，EOA/eth_sign签名由验证时自动识别
+	signatureType := ""
+	if confirmation.SignatureType == "CONTRACT_SIGNATURE" {
+		signatureType = blockchain.SafeSignatureContract
+	}
+
+	verified, signer, err := verifyOwnerSignature(proposal, owner, signatureType, signature)
+	if err != nil {
+		return err
+	}
+	if verified.Signer != owner {
+		return &blockchain.SignatureVerificationError{
+			Reason: fmt.Sprintf("signature was produced by %s, not by owner %s", verified.Signer.Hex(), owner.Hex()),
+		}
+	}
+
+	_, err = addConfirmation(proposal, verified, signer)
+	return err
+}
+
+// txServiceProposer 导入提案的创建者：提议者钱包绑定了系统用户时使用该用户，否则记为Safe创建者
+func txServiceProposer(safe *models.Safe, proposer *string) (uuid.UUID, error) {
+	if proposer != nil && common.IsHexAddress(*proposer) {
+		var user models.User
+		err := database.DB.Where("LOWER(wallet_address) = ?", strings.ToLower(common.HexToAddress(*proposer).Hex())).
+			First(&user).Error
+		if err == nil {
+			return user.ID, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, err
+		}
+	}
+	return safe.CreatedBy, nil
+}
+
+// txServiceOrigin 交易服务的origin通常是JSON字符串，其他格式按原文保存
+func txServiceOrigin(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var origin string
+	if err := json.Unmarshal(raw, &origin); err == nil {
+		return origin
+	}
+	return string(raw)
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// erc20ViewABI 替身响应的ERC-20只读方法
+const erc20ViewABI = `[
+	{"inputs": [{"name": "account", "type": "address"}], "name": "balanceOf", "outputs": [{"name": "", "type": "uint256"}], "stateMutability": "view", "type": "function"},
+	{"inputs": [], "name": "decimals", "outputs": [{"name": "", "type": "uint8"}], "stateMutability": "view", "type": "function"},
+	{"inputs": [], "name": "symbol", "outputs": [{"name": "", "type": "string"}], "stateMutability": "view", "type": "function"}
+]`
+
+var erc20ABI, _ = abi.JSON(strings.NewReader(erc20ViewABI))
+
+// fakeBalanceBackend 内存中的余额RPC后端，用于测试余额读取而无需连接节点
+// 支持原生币余额以及ERC-20的decimals()、symbol()、balanceOf()
+type fakeBalanceBackend struct {
+	mu             sync.RWMutex
+	nativeBalances map[common.Address]*big.Int
+	tokens         map[common.Address]*fakeToken
+}
+
+type fakeToken struct {
+	symbol   string
+	decimals uint8
+	balances map[common.Address]*big.Int
+}
+
+// newFakeBalanceBackend 创建空的内存余额后端
+func newFakeBalanceBackend() *fakeBalanceBackend {
+	return &fakeBalanceBackend{
+		nativeBalances: make(map[common.Address]*big.Int),
+		tokens:         make(map[common.Address]*fakeToken),
+	}
+}
+
+// SetNativeBalance 设置账户原生币余额（wei）
+func (f *fakeBalanceBackend) SetNativeBalance(account common.Address, balance *big.Int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nativeBalances[account] = new(big.Int).Set(balance)
+}
+
+// AddToken 注册一个ERC-20代币
+func (f *fakeBalanceBackend) AddToken(token common.Address, symbol string, decimals uint8) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens[token] = &fakeToken{
+		symbol:   symbol,
+		decimals: decimals,
+		balances: make(map[common.Address]*big.Int),
+	}
+}
+
+// SetTokenBalance 设置账户的代币余额（最小单位），代币需先通过AddToken注册
+func (f *fakeBalanceBackend) SetTokenBalance(token, account common.Address, balance *big.Int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if t, ok := f.tokens[token]; ok {
+		t.balances[account] = new(big.Int).Set(balance)
+	}
+}
+
+// BalanceAt 实现blockchain.BalanceBackend
+func (f *fakeBalanceBackend) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if balance, ok := f.nativeBalances[account]; ok {
+		return new(big.Int).Set(balance), nil
+	}
+	return big.NewInt(0), nil
+}
+
+// CallContract 实现ethereum.ContractCaller，只响应已注册代币的ERC-20只读方法
+// 未注册的地址返回空结果，与调用非合约地址的行为一致
+func (f *fakeBalanceBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if call.To == nil || len(call.Data) < 4 {
+		return nil, fmt.Errorf("invalid call")
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	token, ok := f.tokens[*call.To]
+	if !ok {
+		return []byte{}, nil
+	}
+
+	selector := call.Data[:4]
+	switch {
+	case bytes.Equal(selector, erc20ABI.Methods["decimals"].ID):
+		return erc20ABI.Methods["decimals"].Outputs.Pack(token.decimals)
+	case bytes.Equal(selector, erc20ABI.Methods["symbol"].ID):
+		return erc20ABI.Methods["symbol"].Outputs.Pack(token.symbol)
+	case bytes.Equal(selector, erc20ABI.Methods["balanceOf"].ID):
+		args, err := erc20ABI.Methods["balanceOf"].Inputs.Unpack(call.Data[4:])
+		if err != nil || len(args) == 0 {
+			return nil, fmt.Errorf("invalid balanceOf call")
+		}
+		account, _ := args[0].(common.Address)
+		balance, ok := token.balances[account]
+		if !ok {
+			balance = big.NewInt(0)
+		}
+		return erc20ABI.Methods["balanceOf"].Outputs.Pack(balance)
+	default:
+		return nil, fmt.Errorf("execution reverted")
+	}
+}
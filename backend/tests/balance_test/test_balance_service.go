@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"web3-enterprise-multisig/internal/blockchain"
+)
+
+func main() {
+	log.Println("🧪 Testing balance fetching with fake RPC backend...")
+
+	safeAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	usdc := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	notAToken := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	// 构造内存后端：1.5 ETH 和 1234.56 USDC
+	backend := newFakeBalanceBackend()
+	backend.SetNativeBalance(safeAddress, new(big.Int).Mul(big.NewInt(15), big.NewInt(1e17)))
+	backend.AddToken(usdc, "USDC", 6)
+	backend.SetTokenBalance(usdc, safeAddress, big.NewInt(1234560000))
+
+	balances, err := blockchain.FetchBalances(context.Background(), backend, safeAddress, "ETH", []common.Address{usdc, notAToken})
+	if err != nil {
+		log.Fatal("Failed to fetch balances:", err)
+	}
+
+	expected := map[string]string{"ETH": "1.5", "USDC": "1234.56"}
+	if len(balances) != len(expected) {
+		log.Fatalf("❌ Expected %d balances (invalid token skipped), got %d", len(expected), len(balances))
+	}
+
+	for _, balance := range balances {
+		raw, _ := new(big.Int).SetString(balance.Balance, 10)
+		formatted := blockchain.FormatTokenAmount(raw, uint8(balance.Decimals))
+		log.Printf("  - %s (%s): %s", balance.Symbol, balance.TokenAddress, formatted)
+		if expected[balance.Symbol] != formatted {
+			log.Fatalf("❌ Unexpected %s balance: got %s, want %s", balance.Symbol, formatted, expected[balance.Symbol])
+		}
+	}
+
+	log.Println("🎉 Balance service test completed")
+}
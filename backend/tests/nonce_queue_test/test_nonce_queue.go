@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"web3-enterprise-multisig/internal/blockchain"
+	"web3-enterprise-multisig/internal/database"
+	"web3-enterprise-multisig/internal/handlers"
+	"web3-enterprise-multisig/internal/models"
+	"web3-enterprise-multisig/internal/workflow"
+)
+
+// unconfiguredChainID 未配置RPC的链，读取链上nonce失败后使用Safe记录的对账nonce
+const unconfiguredChainID = 999999999
+
+const concurrentCreates = 4
+
+// standInChainID 本地链节点替身的链ID，用于重新排队后重新签名的验证
+const standInChainID = 999999997
+
+// standInNode 本地链节点替身，实现签名验证用到的eth_chainId和eth_call（Safe nonce）
+type standInNode struct{}
+
+func (standInNode) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(standInChainID))
+}
+
+func (standInNode) Call(args json.RawMessage, block json.RawMessage) hexutil.Bytes {
+	return common.LeftPadBytes(big.NewInt(1).Bytes(), 32)
+}
+
+// noExecutionLogs 链上没有任何Safe执行记录的日志读取替身
+type noExecutionLogs struct{}
+
+func (noExecutionLogs) BlockNumber(ctx context.Context) (uint64, error) {
+	return 0, nil
+}
+
+func (noExecutionLogs) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, nil
+}
+
+func (noExecutionLogs) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func main() {
+	log.Println("🧪 Testing concurrent Safe nonce assignment...")
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	if err := database.ConnectDatabase(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	var user models.User
+	if err := database.DB.First(&user).Error; err != nil {
+		log.Fatal("At least one user is required to run this test:", err)
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		log.Fatal("Failed to generate key:", err)
+	}
+	chainNonce := int64(0)
+	owner := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	safe := models.Safe{
+		Name:      "nonce-queue-test",
+		Address:   owner,
+		ChainID:   unconfiguredChainID,
+		Threshold: 1,
+		Owners:    models.PostgreSQLStringArray{owner},
+		CreatedBy: user.ID,
+		Nonce:     &chainNonce,
+	}
+	if err := database.DB.Omit(clause.Associations).Create(&safe).Error; err != nil {
+		log.Fatal("Failed to create test safe:", err)
+	}
+	defer func() {
+		database.DB.Where("safe_id = ?", safe.ID).Delete(&models.Proposal{})
+		database.DB.Delete(&models.Safe{}, "id = ?", safe.ID)
+	}()
+
+	// 所有创建同时开始；保存前停顿，放大nonce计算与插入之间的竞争窗口
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	nonces := make([]int64, concurrentCreates)
+	errs := make([]error, concurrentCreates)
+	for i := 0; i < concurrentCreates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = workflow.CreateProposalWithNonce(&safe, nil, func(tx *gorm.DB, nonce *int64) error {
+				if nonce == nil {
+					return errors.New("no nonce assigned")
+				}
+				time.Sleep(200 * time.Millisecond)
+				nonces[i] = *nonce
+				return tx.Omit(clause.Associations).Create(&models.Proposal{
+					SafeID:             safe.ID,
+					CreatedBy:          user.ID,
+					Title:              fmt.Sprintf("concurrent proposal %d", i),
+					ProposalType:       "transfer",
+					Value:              "0",
+					Status:             "pending",
+					RequiredSignatures: 1,
+					Nonce:              nonce,
+				}).Error
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	seen := make(map[int64]bool, concurrentCreates)
+	for i := 0; i < concurrentCreates; i++ {
+		if errs[i] != nil {
+			log.Fatalf("❌ Concurrent create %d failed: %v", i, errs[i])
+		}
+		if seen[nonces[i]] {
+			log.Fatalf("❌ Nonce %d was assigned to more than one concurrent proposal: %v", nonces[i], nonces)
+		}
+		seen[nonces[i]] = true
+	}
+	for nonce := int64(0); nonce < concurrentCreates; nonce++ {
+		if !seen[nonce] {
+			log.Fatalf("❌ Expected nonces 0..%d without gaps, got %v", concurrentCreates-1, nonces)
+		}
+	}
+	log.Printf("✅ %d concurrent proposals got distinct nonces %v", concurrentCreates, nonces)
+
+	// 指定nonce越过队尾时拒绝，且不保存提案
+	gap := int64(concurrentCreates + 1)
+	err = workflow.CreateProposalWithNonce(&safe, &gap, func(tx *gorm.DB, nonce *int64) error {
+		return errors.New("create must not be called for an invalid nonce")
+	})
+	if !errors.Is(err, workflow.ErrInvalidProposalNonce) {
+		log.Fatalf("❌ Expected ErrInvalidProposalNonce for nonce %d, got %v", gap, err)
+	}
+	log.Println("✅ Nonce leaving a gap in the queue is rejected")
+
+	checkResignAfterRequeue()
+
+	log.Println("🎉 All nonce queue checks passed")
+}
+
+// checkResignAfterRequeue nonce被其他交易消耗后提案重新排队，之前签过名的所有者可以按新nonce重新签名
+func checkResignAfterRequeue() {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", standInNode{}); err != nil {
+		log.Fatal("Failed to register stand-in node:", err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	registry := blockchain.LoadChainRegistryFromEnv()
+	registry.Register(blockchain.ChainConfig{ChainID: standInChainID, Name: "Nonce Queue Test", RPCUrl: httpServer.URL})
+	blockchain.SetDefaultChainRegistry(registry)
+
+	ownerKey, err := crypto.GenerateKey()
+	if err != nil {
+		log.Fatal("Failed to generate key:", err)
+	}
+	owner := crypto.PubkeyToAddress(ownerKey.PublicKey).Hex()
+	suffix := uuid.New().String()[:8]
+	signer := models.User{
+		Email:         "nonce-queue-" + suffix + "@example.com",
+		Username:      "nonce-queue-" + suffix,
+		PasswordHash:  "-",
+		WalletAddress: &owner,
+		Role:          "user",
+		IsActive:      true,
+	}
+	if err := database.DB.Omit(clause.Associations).Create(&signer).Error; err != nil {
+		log.Fatal("Failed to create test user:", err)
+	}
+
+	chainNonce := int64(0)
+	safe := models.Safe{
+		Name:      "nonce-requeue-test",
+		Address:   "0x5555555555555555555555555555555555555555",
+		ChainID:   standInChainID,
+		Threshold: 2,
+		Owners:    models.PostgreSQLStringArray{owner, "0x6666666666666666666666666666666666666666"},
+		CreatedBy: signer.ID,
+		Nonce:     &chainNonce,
+	}
+	if err := database.DB.Omit(clause.Associations).Create(&safe).Error; err != nil {
+		log.Fatal("Failed to create test safe:", err)
+	}
+	recipient := "0x3333333333333333333333333333333333333333"
+	proposal := models.Proposal{
+		SafeID:             safe.ID,
+		CreatedBy:          signer.ID,
+		Title:              "requeued proposal",
+		ProposalType:       "transfer",
+		ToAddress:          &recipient,
+		Value:              "1000",
+		Status:             "pending",
+		RequiredSignatures: 2,
+		Nonce:              &chainNonce,
+	}
+	if err := database.DB.Omit(clause.Associations).Create(&proposal).Error; err != nil {
+		log.Fatal("Failed to create test proposal:", err)
+	}
+	defer func() {
+		database.DB.Where("proposal_id = ?", proposal.ID).Delete(&models.Signature{})
+		database.DB.Where("safe_id = ?", safe.ID).Delete(&models.Proposal{})
+		database.DB.Delete(&models.Safe{}, "id = ?", safe.ID)
+		database.DB.Delete(&models.User{}, "id = ?", signer.ID)
+	}()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/proposals/:id/sign", func(c *gin.Context) {
+		c.Set("userID", signer.ID)
+		handlers.SignProposal(c)
+	})
+
+	// 按nonce 0签名
+	signAt(router, &proposal, safe.Address, ownerKey, 0)
+	log.Println("✅ Owner signed the proposal at nonce 0")
+
+	// nonce 0被其他交易消耗，提案重新排队到nonce 1，原签名失效
+	requeued, err := blockchain.RequeueConsumedNonces(context.Background(), database.DB, noExecutionLogs{}, &safe, 1)
+	if err != nil {
+		log.Fatal("Failed to requeue consumed nonces:", err)
+	}
+	if len(requeued) != 1 || requeued[0].ProposalID != proposal.ID || requeued[0].NewNonce != 1 {
+		log.Fatalf("❌ Expected the proposal to be requeued to nonce 1, got %+v", requeued)
+	}
+	log.Println("✅ Proposal requeued to nonce 1")
+
+	// 之前签过名的所有者按新nonce重新签名
+	signAt(router, &proposal, safe.Address, ownerKey, 1)
+
+	var signatures []models.Signature
+	if err := database.DB.Where("proposal_id = ?", proposal.ID).Find(&signatures).Error; err != nil {
+		log.Fatal("Failed to query signatures:", err)
+	}
+	if len(signatures) != 1 || signatures[0].Status != "valid" || signatures[0].UsedNonce == nil || *signatures[0].UsedNonce != 1 {
+		log.Fatalf("❌ Expected one valid signature at nonce 1, got %+v", signatures)
+	}
+	var reloaded models.Proposal
+	if err := database.DB.First(&reloaded, "id = ?", proposal.ID).Error; err != nil {
+		log.Fatal("Failed to reload proposal:", err)
+	}
+	if reloaded.CurrentSignatures != 1 {
+		log.Fatalf("❌ Expected 1 signature after re-signing, got %d", reloaded.CurrentSignatures)
+	}
+	log.Println("✅ Prior signer re-signed the requeued proposal")
+}
+
+// signAt 按指定nonce对提案生成EIP-712签名并通过签名接口提交，失败时终止
+func signAt(router *gin.Engine, proposal *models.Proposal, safeAddress string, key *ecdsa.PrivateKey, nonce int64) {
+	safeTx, err := blockchain.SafeTxFromProposal(proposal, big.NewInt(nonce))
+	if err != nil {
+		log.Fatal("Failed to build Safe transaction:", err)
+	}
+	safeTxHash := blockchain.ComputeSafeTxHash(big.NewInt(standInChainID), common.HexToAddress(safeAddress), safeTx)
+	signature, err := crypto.Sign(safeTxHash.Bytes(), key)
+	if err != nil {
+		log.Fatal("Failed to sign safeTxHash:", err)
+	}
+	signature[64] += 27
+
+	body, err := json.Marshal(gin.H{
+		"signature_data": hexutil.Encode(signature),
+		"signature_type": "eth_signTypedData",
+		"used_nonce":     nonce,
+		"safe_tx_hash":   safeTxHash.Hex(),
+	})
+	if err != nil {
+		log.Fatal("Failed to marshal sign request:", err)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/proposals/"+proposal.ID.String()+"/sign", bytes.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		log.Fatalf("❌ Signing at nonce %d returned %d: %s", nonce, recorder.Code, recorder.Body.String())
+	}
+}
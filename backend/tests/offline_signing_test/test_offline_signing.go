@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm/clause"
+
+	"web3-enterprise-multisig/internal/blockchain"
+	"web3-enterprise-multisig/internal/database"
+	"web3-enterprise-multisig/internal/handlers"
+	"web3-enterprise-multisig/internal/models"
+	"web3-enterprise-multisig/internal/workflow"
+)
+
+// standInChainID 本地链节点替身的链ID，只在测试中注册
+const standInChainID = 999999998
+
+// standInSafeNonce 替身节点上Safe合约nonce()的返回值
+const standInSafeNonce = 5
+
+// standInNode 本地链节点替身，实现签名验证用到的eth_chainId和eth_call（Safe nonce）
+type standInNode struct{}
+
+func (standInNode) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(standInChainID))
+}
+
+func (standInNode) Call(args json.RawMessage, block json.RawMessage) hexutil.Bytes {
+	return common.LeftPadBytes(big.NewInt(standInSafeNonce).Bytes(), 32)
+}
+
+type importResult struct {
+	ProposalID string `json:"proposal_id"`
+	Status     string `json:"status"`
+	Code       string `json:"code"`
+}
+
+func main() {
+	log.Println("🧪 Testing offline signing bundles...")
+
+	checkBundleChecksum()
+	checkSignatureImport()
+
+	log.Println("🎉 All offline signing checks passed")
+}
+
+// checkBundleChecksum 校验和只取决于提案和safeTxHash，与顺序、大小写和JSON往返无关
+func checkBundleChecksum() {
+	bundle := &workflow.OfflineSigningBundle{
+		Format:   workflow.OfflineSigningBundleFormat,
+		Version:  1,
+		BundleID: uuid.New(),
+	}
+	for i := 0; i < 3; i++ {
+		proposalID := uuid.New()
+		bundle.Proposals = append(bundle.Proposals, workflow.OfflineSigningProposal{
+			ProposalID: proposalID,
+			Title:      "checksum proposal",
+			SafeTxHash: crypto.Keccak256Hash(proposalID[:]).Hex(),
+		})
+	}
+	bundle.Checksum = workflow.OfflineSigningBundleChecksum(bundle)
+
+	// JSON往返
+	raw, err := json.Marshal(bundle)
+	if err != nil {
+		log.Fatal("Failed to marshal bundle:", err)
+	}
+	var decoded workflow.OfflineSigningBundle
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		log.Fatal("Failed to unmarshal bundle:", err)
+	}
+	if checksum := workflow.OfflineSigningBundleChecksum(&decoded); checksum != bundle.Checksum {
+		log.Fatalf("❌ Checksum changed after JSON round-trip: %s != %s", checksum, bundle.Checksum)
+	}
+	log.Println("✅ Checksum is stable across a JSON round-trip")
+
+	// 提案顺序、大小写和其他字段不影响校验和
+	reordered := decoded
+	reordered.Proposals = nil
+	for i := len(decoded.Proposals) - 1; i >= 0; i-- {
+		proposal := decoded.Proposals[i]
+		proposal.SafeTxHash = "0x" + strings.ToUpper(proposal.SafeTxHash[2:])
+		proposal.Title = "edited title"
+		reordered.Proposals = append(reordered.Proposals, proposal)
+	}
+	reordered.Checksum = ""
+	if checksum := workflow.OfflineSigningBundleChecksum(&reordered); checksum != bundle.Checksum {
+		log.Fatalf("❌ Checksum depends on proposal order or hash case: %s != %s", checksum, bundle.Checksum)
+	}
+	log.Println("✅ Checksum ignores proposal order, hash case and display fields")
+
+	// 按文档独立重算
+	lines := make([]string, 0, len(bundle.Proposals))
+	for _, proposal := range bundle.Proposals {
+		lines = append(lines, strings.ToLower(proposal.ProposalID.String()+":"+proposal.SafeTxHash))
+	}
+	sort.Strings(lines)
+	if expected := crypto.Keccak256Hash([]byte(strings.Join(lines, "\n"))).Hex(); expected != bundle.Checksum {
+		log.Fatalf("❌ Checksum %s does not match the documented algorithm %s", bundle.Checksum, expected)
+	}
+	log.Println("✅ Checksum matches the documented algorithm")
+
+	// 任一safeTxHash变化时校验和改变
+	tampered := decoded
+	tampered.Proposals = append([]workflow.OfflineSigningProposal(nil), decoded.Proposals...)
+	tampered.Proposals[0].SafeTxHash = crypto.Keccak256Hash([]byte("tampered")).Hex()
+	if checksum := workflow.OfflineSigningBundleChecksum(&tampered); checksum == bundle.Checksum {
+		log.Fatal("❌ Checksum did not change when a safeTxHash changed")
+	}
+	log.Println("✅ Checksum changes when a safeTxHash changes")
+}
+
+// checkSignatureImport 一次导入中正确签名被保存，错误签名和声明了其他签名者的条目逐个失败
+func checkSignatureImport() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	if err := database.ConnectDatabase(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	// 签名验证通过替身节点读取链ID和Safe nonce
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", standInNode{}); err != nil {
+		log.Fatal("Failed to register stand-in node:", err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	registry := blockchain.LoadChainRegistryFromEnv()
+	registry.Register(blockchain.ChainConfig{ChainID: standInChainID, Name: "Offline Signing Test", RPCUrl: httpServer.URL})
+	blockchain.SetDefaultChainRegistry(registry)
+
+	ownerKey, err := crypto.GenerateKey()
+	if err != nil {
+		log.Fatal("Failed to generate key:", err)
+	}
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		log.Fatal("Failed to generate key:", err)
+	}
+	owner := crypto.PubkeyToAddress(ownerKey.PublicKey).Hex()
+	other := crypto.PubkeyToAddress(otherKey.PublicKey).Hex()
+
+	suffix := uuid.New().String()[:8]
+	user := models.User{
+		Email:         "offline-signing-" + suffix + "@example.com",
+		Username:      "offline-signing-" + suffix,
+		PasswordHash:  "-",
+		WalletAddress: &owner,
+		Role:          "user",
+		IsActive:      true,
+	}
+	if err := database.DB.Omit(clause.Associations).Create(&user).Error; err != nil {
+		log.Fatal("Failed to create test user:", err)
+	}
+	safe := models.Safe{
+		Name:      "offline-signing-test",
+		Address:   "0x4444444444444444444444444444444444444444",
+		ChainID:   standInChainID,
+		Threshold: 2,
+		Owners:    models.PostgreSQLStringArray{owner, other},
+		CreatedBy: user.ID,
+	}
+	if err := database.DB.Omit(clause.Associations).Create(&safe).Error; err != nil {
+		log.Fatal("Failed to create test safe:", err)
+	}
+
+	recipient := "0x3333333333333333333333333333333333333333"
+	proposals := make([]models.Proposal, 2)
+	for i := range proposals {
+		nonce := int64(standInSafeNonce + i)
+		proposals[i] = models.Proposal{
+			SafeID:             safe.ID,
+			CreatedBy:          user.ID,
+			Title:              "offline signing proposal",
+			ProposalType:       "transfer",
+			ToAddress:          &recipient,
+			Value:              "1000",
+			Status:             "pending",
+			RequiredSignatures: 2,
+			Nonce:              &nonce,
+		}
+		if err := database.DB.Omit(clause.Associations).Create(&proposals[i]).Error; err != nil {
+			log.Fatal("Failed to create test proposal:", err)
+		}
+	}
+	defer func() {
+		database.DB.Where("signer_id = ?", user.ID).Delete(&models.Signature{})
+		database.DB.Where("safe_id = ?", safe.ID).Delete(&models.Proposal{})
+		database.DB.Delete(&models.Safe{}, "id = ?", safe.ID)
+		database.DB.Delete(&models.User{}, "id = ?", user.ID)
+	}()
+
+	good, goodHash := signProposal(&proposals[0], safe.Address, ownerKey)
+	bad, badHash := signProposal(&proposals[1], safe.Address, otherKey)
+	request := gin.H{
+		"format": workflow.OfflineSignaturesFormat,
+		"signatures": []gin.H{
+			{"proposal_id": proposals[0].ID, "safe_tx_hash": goodHash, "signer": owner, "signature": good, "nonce": *proposals[0].Nonce},
+			{"proposal_id": proposals[1].ID, "safe_tx_hash": badHash, "signer": owner, "signature": bad, "nonce": *proposals[1].Nonce},
+			{"proposal_id": proposals[1].ID, "safe_tx_hash": badHash, "signer": other, "signature": bad, "nonce": *proposals[1].Nonce},
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/proposals/offline-signatures/import", func(c *gin.Context) {
+		c.Set("userID", user.ID)
+		handlers.ImportOfflineSignatures(c)
+	})
+	body, err := json.Marshal(request)
+	if err != nil {
+		log.Fatal("Failed to marshal import request:", err)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/proposals/offline-signatures/import", bytes.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		log.Fatalf("❌ Import returned %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var response struct {
+		Signed  int            `json:"signed"`
+		Failed  int            `json:"failed"`
+		Results []importResult `json:"results"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		log.Fatal("Failed to decode import response:", err)
+	}
+	if response.Signed != 1 || response.Failed != 2 || len(response.Results) != 3 {
+		log.Fatalf("❌ Expected 1 signed and 2 failed, got %s", recorder.Body.String())
+	}
+	expectResult(response.Results[0], proposals[0].ID, "signed", "")
+	expectResult(response.Results[1], proposals[1].ID, "failed", "INVALID_SIGNATURE")
+	expectResult(response.Results[2], proposals[1].ID, "failed", "SIGNER_MISMATCH")
+	log.Println("✅ Good signature saved, bad signature rejected in the same import")
+	log.Println("✅ Entry declaring another signer is rejected with SIGNER_MISMATCH")
+
+	var count int64
+	database.DB.Model(&models.Signature{}).Where("signer_id = ?", user.ID).Count(&count)
+	if count != 1 {
+		log.Fatalf("❌ Expected exactly one saved signature, found %d", count)
+	}
+	var signedProposal models.Proposal
+	if err := database.DB.First(&signedProposal, "id = ?", proposals[0].ID).Error; err != nil {
+		log.Fatal("Failed to reload proposal:", err)
+	}
+	if signedProposal.CurrentSignatures != 1 || signedProposal.SafeTxHash == nil || !strings.EqualFold(*signedProposal.SafeTxHash, goodHash) {
+		log.Fatalf("❌ Signed proposal not updated: signatures=%d safe_tx_hash=%v", signedProposal.CurrentSignatures, signedProposal.SafeTxHash)
+	}
+	log.Println("✅ Only the good signature was counted")
+}
+
+// signProposal 按提案分配的nonce计算safeTxHash并生成EIP-712签名（v=27/28）
+func signProposal(proposal *models.Proposal, safeAddress string, key *ecdsa.PrivateKey) (string, string) {
+	safeTx, err := blockchain.SafeTxFromProposal(proposal, big.NewInt(*proposal.Nonce))
+	if err != nil {
+		log.Fatal("Failed to build Safe transaction:", err)
+	}
+	safeTxHash := blockchain.ComputeSafeTxHash(big.NewInt(standInChainID), common.HexToAddress(safeAddress), safeTx)
+
+	signature, err := crypto.Sign(safeTxHash.Bytes(), key)
+	if err != nil {
+		log.Fatal("Failed to sign safeTxHash:", err)
+	}
+	signature[64] += 27
+	return hexutil.Encode(signature), safeTxHash.Hex()
+}
+
+func expectResult(result importResult, proposalID uuid.UUID, status, code string) {
+	if result.ProposalID != proposalID.String() || result.Status != status || result.Code != code {
+		log.Fatalf("❌ Expected %s %s %s, got %+v", proposalID, status, code, result)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"log"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/google/uuid"
+
+	"web3-enterprise-multisig/internal/blockchain"
+)
+
+// standInSigner 本地远程签名服务替身，实现eth_accounts和eth_signTransaction（Clef返回格式）
+type standInSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+type signTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+}
+
+func (s *standInSigner) Accounts() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *standInSigner) SignTransaction(args signTxArgs) (map[string]interface{}, error) {
+	if args.From != crypto.PubkeyToAddress(s.key.PublicKey) {
+		return nil, errors.New("unknown account")
+	}
+
+	var tx *types.Transaction
+	if args.MaxFeePerGas != nil {
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   args.ChainID.ToInt(),
+			Nonce:     uint64(args.Nonce),
+			GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap: args.MaxFeePerGas.ToInt(),
+			Gas:       uint64(args.Gas),
+			To:        args.To,
+			Value:     args.Value.ToInt(),
+			Data:      args.Data,
+		})
+	} else {
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(args.Nonce),
+			GasPrice: args.GasPrice.ToInt(),
+			Gas:      uint64(args.Gas),
+			To:       args.To,
+			Value:    args.Value.ToInt(),
+			Data:     args.Data,
+		})
+	}
+
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(args.ChainID.ToInt()), s.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"raw": hexutil.Encode(raw), "tx": signed}, nil
+}
+
+func main() {
+	log.Println("🧪 Testing executor transaction signers...")
+	ctx := context.Background()
+	chainID := big.NewInt(11155111)
+	safeAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		log.Fatal("Failed to generate key:", err)
+	}
+	expected := crypto.PubkeyToAddress(key.PublicKey)
+
+	unsignedTxs := []*types.Transaction{
+		types.NewTransaction(7, safeAddress, big.NewInt(0), 500000, big.NewInt(2e9), []byte{0x6a, 0x76, 0x12, 0x02}),
+		types.NewTx(&types.DynamicFeeTx{
+			ChainID: chainID, Nonce: 8, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(3e9),
+			Gas: 500000, To: &safeAddress, Value: big.NewInt(0), Data: []byte{0x6a, 0x76, 0x12, 0x02},
+		}),
+	}
+
+	// 1. 加密keystore
+	dir, err := os.MkdirTemp("", "executor-keystore")
+	if err != nil {
+		log.Fatal("Failed to create temp dir:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{Id: uuid.New(), Address: expected, PrivateKey: key}, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		log.Fatal("Failed to encrypt key:", err)
+	}
+	keystorePath := filepath.Join(dir, "executor.json")
+	if err := os.WriteFile(keystorePath, keyJSON, 0600); err != nil {
+		log.Fatal("Failed to write keystore:", err)
+	}
+
+	if _, err := blockchain.NewKeystoreSigner(keystorePath, "wrong"); err == nil {
+		log.Fatal("❌ Keystore signer accepted a wrong password")
+	}
+	keystoreSigner, err := blockchain.NewKeystoreSigner(keystorePath, "secret")
+	if err != nil {
+		log.Fatal("Failed to load keystore signer:", err)
+	}
+	checkSigner(ctx, "keystore", keystoreSigner, expected, chainID, unsignedTxs)
+
+	// 2. 远程JSON-RPC签名服务（本地替身）
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &standInSigner{key: key}); err != nil {
+		log.Fatal("Failed to register stand-in signer:", err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	remoteSigner, err := blockchain.NewRemoteSigner(ctx, httpServer.URL, "")
+	if err != nil {
+		log.Fatal("Failed to connect remote signer:", err)
+	}
+	defer remoteSigner.Close()
+	checkSigner(ctx, "remote", remoteSigner, expected, chainID, unsignedTxs)
+
+	// 签名服务不管理的账户应被拒绝
+	otherSigner, err := blockchain.NewRemoteSigner(ctx, httpServer.URL, "0x2222222222222222222222222222222222222222")
+	if err != nil {
+		log.Fatal("Failed to connect remote signer:", err)
+	}
+	defer otherSigner.Close()
+	if _, err := otherSigner.SignTx(ctx, unsignedTxs[0], chainID); err == nil {
+		log.Fatal("❌ Remote signer signed for an unmanaged account")
+	}
+	log.Println("✅ Remote signer rejects unmanaged accounts")
+
+	// 3. 未配置签名器
+	os.Unsetenv("EXECUTOR_SIGNER")
+	os.Unsetenv("PRIVATE_KEY")
+	if _, err := blockchain.LoadTxSignerFromEnv(ctx); !errors.Is(err, blockchain.ErrSignerNotConfigured) {
+		log.Fatalf("❌ Expected ErrSignerNotConfigured, got %v", err)
+	}
+	log.Println("✅ Missing signer configuration is reported")
+
+	log.Println("🎉 All signer checks passed")
+}
+
+func checkSigner(ctx context.Context, name string, signer blockchain.TxSigner, expected common.Address, chainID *big.Int, txs []*types.Transaction) {
+	if signer.Address() != expected {
+		log.Fatalf("❌ %s signer address %s, expected %s", name, signer.Address().Hex(), expected.Hex())
+	}
+	for _, tx := range txs {
+		signed, err := signer.SignTx(ctx, tx, chainID)
+		if err != nil {
+			log.Fatalf("❌ %s signer failed to sign type %d tx: %v", name, tx.Type(), err)
+		}
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		if err != nil || sender != expected {
+			log.Fatalf("❌ %s signer produced invalid signature: sender=%s err=%v", name, sender.Hex(), err)
+		}
+		log.Printf("✅ %s signer signed type %d tx %s", name, tx.Type(), signed.Hash().Hex())
+	}
+}
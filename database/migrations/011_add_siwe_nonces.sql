@@ -0,0 +1,31 @@
+-- 011_add_siwe_nonces.sql
+-- Sign-In With Ethereum (EIP-4361) 挑战nonce表
+-- 服务端签发一次性nonce，钱包登录/注册时校验并消费，防止签名重放
+
+CREATE TABLE IF NOT EXISTS siwe_nonces (
+    -- 主键
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- 挑战信息
+    nonce VARCHAR(64) NOT NULL UNIQUE, -- EIP-4361 nonce（字母数字，至少8位）
+    wallet_address VARCHAR(42) NOT NULL, -- 请求挑战的钱包地址
+    domain VARCHAR(255) NOT NULL, -- 签发时的domain
+    uri VARCHAR(500) NOT NULL, -- 签发时的URI
+    chain_id BIGINT NOT NULL, -- 签发时的链ID
+
+    -- 时间管理
+    issued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP, -- 消费时间，为空表示未使用
+
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- 添加注释说明字段用途
+COMMENT ON TABLE siwe_nonces IS 'SIWE登录挑战nonce，单次使用且带过期时间';
+COMMENT ON COLUMN siwe_nonces.nonce IS '服务端生成的随机nonce，必须出现在签名消息中';
+COMMENT ON COLUMN siwe_nonces.used_at IS 'nonce被消费的时间，非空后不可再次使用';
+
+-- 创建索引优化查询性能
+CREATE INDEX IF NOT EXISTS idx_siwe_nonces_wallet_address ON siwe_nonces(LOWER(wallet_address));
+CREATE INDEX IF NOT EXISTS idx_siwe_nonces_expires_at ON siwe_nonces(expires_at);
@@ -0,0 +1,26 @@
+-- 012_add_safe_tx_params_to_proposals.sql
+-- 为提案表添加完整的SafeTx参数，支持DELEGATECALL（如MultiSend批量交易）和中继退款
+
+ALTER TABLE proposals
+ADD COLUMN IF NOT EXISTS operation SMALLINT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS safe_tx_gas DECIMAL(78, 0) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS base_gas DECIMAL(78, 0) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS gas_price DECIMAL(78, 0) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS gas_token VARCHAR(42) NOT NULL DEFAULT '0x0000000000000000000000000000000000000000',
+ADD COLUMN IF NOT EXISTS refund_receiver VARCHAR(42) NOT NULL DEFAULT '0x0000000000000000000000000000000000000000';
+
+-- operation只能是CALL(0)或DELEGATECALL(1)
+ALTER TABLE proposals
+DROP CONSTRAINT IF EXISTS proposals_operation_check;
+
+ALTER TABLE proposals
+ADD CONSTRAINT proposals_operation_check
+CHECK (operation IN (0, 1));
+
+-- 添加注释说明字段用途
+COMMENT ON COLUMN proposals.operation IS 'Safe操作类型: 0=CALL, 1=DELEGATECALL';
+COMMENT ON COLUMN proposals.safe_tx_gas IS 'Safe内部交易gas上限，0表示使用全部可用gas';
+COMMENT ON COLUMN proposals.base_gas IS '与执行无关的gas开销（签名校验、退款等），用于计算退款';
+COMMENT ON COLUMN proposals.gas_price IS '退款使用的gas价格，0表示不退款';
+COMMENT ON COLUMN proposals.gas_token IS '退款代币地址，0地址表示ETH';
+COMMENT ON COLUMN proposals.refund_receiver IS '退款接收地址，0地址表示tx.origin';
@@ -0,0 +1,33 @@
+-- 013_add_batch_proposals.sql
+-- 批量交易提案：多笔子交易通过MultiSendCallOnly编码为一笔SafeTx，一次签名、一次执行
+
+-- 提案类型增加batch
+ALTER TABLE proposals
+DROP CONSTRAINT IF EXISTS check_proposal_type;
+
+ALTER TABLE proposals
+ADD CONSTRAINT check_proposal_type
+CHECK (proposal_type IN ('transfer', 'contract_call', 'add_owner', 'remove_owner', 'change_threshold', 'batch'));
+
+COMMENT ON COLUMN proposals.proposal_type IS '提案类型：transfer(转账), contract_call(合约调用), add_owner(添加所有者), remove_owner(移除所有者), change_threshold(修改阈值), batch(批量交易)';
+
+-- 批量提案子交易表
+CREATE TABLE IF NOT EXISTS proposal_batch_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    proposal_id UUID NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+    item_index INTEGER NOT NULL,                  -- 子交易在MultiSend中的顺序
+    to_address VARCHAR(42) NOT NULL,
+    value DECIMAL(78, 0) NOT NULL DEFAULT 0,      -- 以wei为单位
+    data TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT proposal_batch_items_unique_index UNIQUE (proposal_id, item_index),
+    CONSTRAINT proposal_batch_items_status_check CHECK (status IN ('pending', 'executed', 'confirmed', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_proposal_batch_items_proposal_id ON proposal_batch_items(proposal_id);
+
+COMMENT ON TABLE proposal_batch_items IS '批量提案的子交易明细，按item_index顺序编码进MultiSendCallOnly调用';
+COMMENT ON COLUMN proposal_batch_items.status IS '子交易状态：pending(待执行), executed(已提交), confirmed(已确认), failed(失败)；MultiSend原子执行，与父提案同步';
@@ -0,0 +1,40 @@
+-- 014_add_token_transfer_proposals.sql
+-- ERC-20代币转账提案：SafeTx调用代币合约transfer(to, amount)，金额按代币decimals换算
+
+-- 提案类型增加token_transfer
+ALTER TABLE proposals
+DROP CONSTRAINT IF EXISTS check_proposal_type;
+
+ALTER TABLE proposals
+ADD CONSTRAINT check_proposal_type
+CHECK (proposal_type IN ('transfer', 'contract_call', 'add_owner', 'remove_owner', 'change_threshold', 'batch', 'token_transfer'));
+
+COMMENT ON COLUMN proposals.proposal_type IS '提案类型：transfer(转账), contract_call(合约调用), add_owner(添加所有者), remove_owner(移除所有者), change_threshold(修改阈值), batch(批量交易), token_transfer(代币转账)';
+
+-- 代币转账字段（to_address为代币合约地址，value为0）
+ALTER TABLE proposals
+ADD COLUMN IF NOT EXISTS token_address VARCHAR(42),
+ADD COLUMN IF NOT EXISTS token_symbol VARCHAR(32),
+ADD COLUMN IF NOT EXISTS token_decimals SMALLINT,
+ADD COLUMN IF NOT EXISTS token_recipient VARCHAR(42),
+ADD COLUMN IF NOT EXISTS token_amount DECIMAL(78, 0);
+
+-- 代币转账提案必须完整记录代币信息
+ALTER TABLE proposals
+DROP CONSTRAINT IF EXISTS proposals_token_transfer_check;
+
+ALTER TABLE proposals
+ADD CONSTRAINT proposals_token_transfer_check
+CHECK (proposal_type <> 'token_transfer' OR (
+    token_address IS NOT NULL AND token_decimals IS NOT NULL AND
+    token_recipient IS NOT NULL AND token_amount IS NOT NULL
+));
+
+-- 按代币统计支出（支出限额策略、仪表盘）
+CREATE INDEX IF NOT EXISTS idx_proposals_token_address ON proposals(safe_id, LOWER(token_address)) WHERE token_address IS NOT NULL;
+
+COMMENT ON COLUMN proposals.token_address IS 'ERC-20代币合约地址';
+COMMENT ON COLUMN proposals.token_symbol IS '创建提案时通过RPC读取的代币symbol()';
+COMMENT ON COLUMN proposals.token_decimals IS '创建提案时通过RPC读取的代币decimals()';
+COMMENT ON COLUMN proposals.token_recipient IS '代币接收地址';
+COMMENT ON COLUMN proposals.token_amount IS '代币转账数量，按代币最小单位存储（已乘以10^decimals）';
@@ -0,0 +1,24 @@
+-- 015_add_safe_balance_snapshots.sql
+-- Safe资产余额快照：缓存原生币和已配置ERC-20代币的余额，按刷新间隔从链上更新
+
+CREATE TABLE IF NOT EXISTS safe_balance_snapshots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    safe_id UUID NOT NULL REFERENCES safes(id) ON DELETE CASCADE,
+    chain_id BIGINT NOT NULL,
+    token_address VARCHAR(42) NOT NULL,            -- 原生币使用0地址
+    symbol VARCHAR(32) NOT NULL,
+    decimals SMALLINT NOT NULL DEFAULT 18,
+    balance DECIMAL(78, 0) NOT NULL DEFAULT 0,     -- 以最小单位存储
+    fetched_at TIMESTAMP WITH TIME ZONE NOT NULL,  -- 从链上读取的时间
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT safe_balance_snapshots_unique_token UNIQUE (safe_id, token_address)
+);
+
+CREATE INDEX IF NOT EXISTS idx_safe_balance_snapshots_safe_id ON safe_balance_snapshots(safe_id);
+CREATE INDEX IF NOT EXISTS idx_safe_balance_snapshots_fetched_at ON safe_balance_snapshots(fetched_at);
+
+COMMENT ON TABLE safe_balance_snapshots IS 'Safe资产余额快照，每个Safe每种资产一行，超过刷新间隔后重新读取链上余额';
+COMMENT ON COLUMN safe_balance_snapshots.token_address IS 'ERC-20代币地址，原生币为0x0000000000000000000000000000000000000000';
+COMMENT ON COLUMN safe_balance_snapshots.balance IS '余额，按代币最小单位存储（原生币为wei）';
@@ -0,0 +1,41 @@
+-- 016_add_safe_history.sql
+-- Safe交易历史索引：记录链上与Safe相关的事件（包括在其他客户端执行的交易和入账）
+-- 并按Safe保存索引进度，服务重启后从上次的区块继续
+
+CREATE TABLE IF NOT EXISTS safe_history_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    safe_id UUID NOT NULL REFERENCES safes(id) ON DELETE CASCADE,
+    chain_id BIGINT NOT NULL,
+    event_type VARCHAR(30) NOT NULL,
+    tx_hash VARCHAR(66) NOT NULL,
+    log_index INTEGER NOT NULL,
+    block_number BIGINT NOT NULL,
+    block_timestamp TIMESTAMP WITH TIME ZONE,
+    safe_tx_hash VARCHAR(66),                       -- ExecutionSuccess/ExecutionFailure对应的safeTxHash
+    proposal_id UUID REFERENCES proposals(id) ON DELETE SET NULL, -- 由本系统创建的提案
+    token_address VARCHAR(42),                      -- ERC-20 Transfer的代币地址
+    from_address VARCHAR(42),
+    to_address VARCHAR(42),
+    value DECIMAL(78, 0) NOT NULL DEFAULT 0,        -- 入账/转账金额（最小单位）
+    payment DECIMAL(78, 0),                         -- Safe执行时支付的退款
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT safe_history_events_unique_log UNIQUE (safe_id, chain_id, tx_hash, log_index),
+    CONSTRAINT safe_history_events_type_check CHECK (event_type IN (
+        'execution_success', 'execution_failure', 'safe_received', 'erc20_transfer_in', 'erc20_transfer_out'
+    ))
+);
+
+CREATE INDEX IF NOT EXISTS idx_safe_history_events_safe_block ON safe_history_events(safe_id, block_number DESC, log_index DESC);
+CREATE INDEX IF NOT EXISTS idx_safe_history_events_safe_tx_hash ON safe_history_events(safe_tx_hash);
+
+CREATE TABLE IF NOT EXISTS safe_index_checkpoints (
+    safe_id UUID PRIMARY KEY REFERENCES safes(id) ON DELETE CASCADE,
+    chain_id BIGINT NOT NULL,
+    last_indexed_block BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+COMMENT ON TABLE safe_history_events IS 'Safe链上交易历史：执行成功/失败、ETH入账和ERC-20转入转出';
+COMMENT ON COLUMN safe_history_events.event_type IS '事件类型：execution_success, execution_failure, safe_received, erc20_transfer_in, erc20_transfer_out';
+COMMENT ON TABLE safe_index_checkpoints IS '历史索引进度，记录每个Safe已索引到的区块';
@@ -0,0 +1,33 @@
+-- 017_add_safe_state_reconciliation.sql
+-- Safe链上状态对账：记录链上nonce及最近一次同步时间，
+-- 并在数据库中的owners/threshold与链上不一致时写入审计记录
+
+ALTER TABLE safes ADD COLUMN IF NOT EXISTS nonce BIGINT;
+ALTER TABLE safes ADD COLUMN IF NOT EXISTS last_synced_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN safes.nonce IS '最近一次对账读取的链上Safe nonce';
+COMMENT ON COLUMN safes.last_synced_at IS '最近一次与链上状态对账的时间';
+
+CREATE TABLE IF NOT EXISTS safe_state_drifts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    safe_id UUID NOT NULL REFERENCES safes(id) ON DELETE CASCADE,
+    chain_id BIGINT NOT NULL,
+    source VARCHAR(20) NOT NULL,                    -- 触发来源：execution, scheduled
+    proposal_id UUID REFERENCES proposals(id) ON DELETE SET NULL, -- 执行后对账时关联的提案
+
+    old_owners TEXT[] NOT NULL,
+    new_owners TEXT[] NOT NULL,
+    old_threshold INTEGER NOT NULL,
+    new_threshold INTEGER NOT NULL,
+    old_nonce BIGINT,
+    new_nonce BIGINT NOT NULL,
+
+    detected_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT safe_state_drifts_source_check CHECK (source IN ('execution', 'scheduled'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_safe_state_drifts_safe_id ON safe_state_drifts(safe_id, detected_at DESC);
+
+COMMENT ON TABLE safe_state_drifts IS 'Safe owners/threshold漂移审计记录：数据库与链上状态不一致时记录变更前后的值';
+COMMENT ON COLUMN safe_state_drifts.source IS '触发来源：execution（提案执行确认后）、scheduled（定时对账）';
@@ -0,0 +1,20 @@
+-- 018_add_execution_fee_params.sql
+-- 记录执行账户提交execTransaction时使用的以太坊交易参数（gas上限和EIP-1559费用）
+-- 与SafeTx的safe_tx_gas/gas_price（退款参数）不同，这里是外层交易的参数
+
+ALTER TABLE proposals
+ADD COLUMN IF NOT EXISTS tx_type SMALLINT,
+ADD COLUMN IF NOT EXISTS tx_gas_estimate BIGINT,
+ADD COLUMN IF NOT EXISTS tx_gas_limit BIGINT,
+ADD COLUMN IF NOT EXISTS tx_max_fee_per_gas DECIMAL(78, 0),
+ADD COLUMN IF NOT EXISTS tx_max_priority_fee_per_gas DECIMAL(78, 0),
+ADD COLUMN IF NOT EXISTS tx_gas_price DECIMAL(78, 0),
+ADD COLUMN IF NOT EXISTS tx_base_fee DECIMAL(78, 0);
+
+COMMENT ON COLUMN proposals.tx_type IS '执行交易类型: 0=legacy, 2=EIP-1559';
+COMMENT ON COLUMN proposals.tx_gas_estimate IS 'eth_estimateGas估算的gas';
+COMMENT ON COLUMN proposals.tx_gas_limit IS '估算值加缓冲后的gas上限';
+COMMENT ON COLUMN proposals.tx_max_fee_per_gas IS 'EIP-1559 maxFeePerGas（wei）';
+COMMENT ON COLUMN proposals.tx_max_priority_fee_per_gas IS 'EIP-1559 maxPriorityFeePerGas（wei）';
+COMMENT ON COLUMN proposals.tx_gas_price IS 'legacy交易的gasPrice（wei），不支持EIP-1559的链使用';
+COMMENT ON COLUMN proposals.tx_base_fee IS '提交时最新区块的baseFee（wei）';
@@ -0,0 +1,37 @@
+-- 019_add_proposal_execution_txs.sql
+-- 提案执行交易跟踪：记录执行账户为同一提案广播的所有交易（原始交易、加速、取消），
+-- 同一执行账户nonce下只会有一笔被打包，监控器跟踪所有哈希并以实际上链的交易为准
+
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS tx_nonce BIGINT;
+COMMENT ON COLUMN proposals.tx_nonce IS '执行交易使用的执行账户nonce，加速/取消时复用';
+
+CREATE TABLE IF NOT EXISTS proposal_execution_txs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    proposal_id UUID NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+    chain_id BIGINT NOT NULL,
+    tx_hash VARCHAR(66) NOT NULL UNIQUE,
+    from_address VARCHAR(42) NOT NULL,
+    nonce BIGINT NOT NULL,
+    action VARCHAR(20) NOT NULL,                    -- execute, speed_up, cancel
+    replaces_tx_hash VARCHAR(66),                   -- 被替换的交易哈希
+
+    tx_type SMALLINT NOT NULL,
+    gas_limit BIGINT NOT NULL,
+    max_fee_per_gas DECIMAL(78, 0),
+    max_priority_fee_per_gas DECIMAL(78, 0),
+    gas_price DECIMAL(78, 0),
+
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, mined, replaced
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    mined_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT proposal_execution_txs_action_check CHECK (action IN ('execute', 'speed_up', 'cancel')),
+    CONSTRAINT proposal_execution_txs_status_check CHECK (status IN ('pending', 'mined', 'replaced'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_proposal_execution_txs_proposal_id ON proposal_execution_txs(proposal_id, created_at);
+
+COMMENT ON TABLE proposal_execution_txs IS '提案执行交易及其替换交易（同nonce加速/取消）';
+COMMENT ON COLUMN proposal_execution_txs.action IS 'execute=原始执行交易, speed_up=提高费用重发, cancel=0值自转账取消';
+COMMENT ON COLUMN proposal_execution_txs.status IS 'pending=等待打包, mined=已上链, replaced=同nonce的其他交易已上链';
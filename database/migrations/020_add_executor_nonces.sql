@@ -0,0 +1,23 @@
+-- 020_add_executor_nonces.sql
+-- 执行账户nonce分配记录：并发执行提案时在锁内分配nonce并持久化，
+-- 重启后据此恢复，并复用未上链（发送失败或被节点丢弃）交易留下的nonce空洞
+
+CREATE TABLE IF NOT EXISTS executor_nonces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chain_id BIGINT NOT NULL,
+    address VARCHAR(42) NOT NULL,                   -- 执行账户地址（checksum格式）
+    nonce BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'reserved', -- reserved, broadcast, confirmed, released
+    proposal_id UUID REFERENCES proposals(id) ON DELETE SET NULL,
+    tx_hash VARCHAR(66),                            -- 最近一次使用该nonce广播的交易（含加速/取消）
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT executor_nonces_unique UNIQUE (chain_id, address, nonce),
+    CONSTRAINT executor_nonces_status_check CHECK (status IN ('reserved', 'broadcast', 'confirmed', 'released'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_executor_nonces_active ON executor_nonces(chain_id, address, status);
+
+COMMENT ON TABLE executor_nonces IS '执行账户nonce分配记录，保证并发执行时nonce不冲突';
+COMMENT ON COLUMN executor_nonces.status IS 'reserved=已分配待广播, broadcast=已广播, confirmed=链上nonce已超过, released=未使用可重新分配';
@@ -0,0 +1,33 @@
+-- 021_add_proposal_execution_jobs.sql
+-- 提案执行任务队列：执行请求先落库再由后台worker异步执行，
+-- HTTP请求中断不影响执行；每个提案同一时间只有一个排队/执行中的任务
+
+CREATE TABLE IF NOT EXISTS proposal_execution_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    proposal_id UUID NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',   -- queued, running, succeeded, failed
+    attempts INTEGER NOT NULL DEFAULT 0,             -- 已调用执行的次数（含重试）
+    max_attempts INTEGER NOT NULL DEFAULT 4,
+    tx_hash VARCHAR(66),
+    error_code VARCHAR(50),                          -- POLICY_VIOLATION, SIMULATION_FAILED, EXECUTOR_NOT_CONFIGURED, EXECUTION_ERROR
+    last_error TEXT,
+    error_details JSONB,                             -- 策略违规原因或模拟结果
+    next_run_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    locked_at TIMESTAMP WITH TIME ZONE,              -- worker领取时间，超时未完成视为进程中断
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT proposal_execution_jobs_status_check CHECK (status IN ('queued', 'running', 'succeeded', 'failed'))
+);
+
+-- 幂等：每个提案最多一个未完成的任务
+CREATE UNIQUE INDEX IF NOT EXISTS idx_proposal_execution_jobs_active
+    ON proposal_execution_jobs(proposal_id) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_proposal_execution_jobs_queue ON proposal_execution_jobs(status, next_run_at);
+CREATE INDEX IF NOT EXISTS idx_proposal_execution_jobs_proposal ON proposal_execution_jobs(proposal_id, created_at DESC);
+
+COMMENT ON TABLE proposal_execution_jobs IS '提案异步执行任务，支持幂等提交、瞬时RPC错误重试和进程重启后恢复';
+COMMENT ON COLUMN proposal_execution_jobs.locked_at IS 'worker领取任务的时间，running状态超时后任务被重新排队';
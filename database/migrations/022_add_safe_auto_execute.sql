@@ -0,0 +1,13 @@
+-- 022_add_safe_auto_execute.sql
+-- Safe自动执行设置：开启后提案签名达到阈值即自动提交执行任务，
+-- 存在时间锁定策略时任务安排在最早允许执行的时间
+
+ALTER TABLE safes ADD COLUMN IF NOT EXISTS auto_execute BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- 执行任务来源：manual=用户手动提交, auto=签名达到阈值后自动提交
+ALTER TABLE proposal_execution_jobs ADD COLUMN IF NOT EXISTS source VARCHAR(20) NOT NULL DEFAULT 'manual';
+ALTER TABLE proposal_execution_jobs DROP CONSTRAINT IF EXISTS proposal_execution_jobs_source_check;
+ALTER TABLE proposal_execution_jobs ADD CONSTRAINT proposal_execution_jobs_source_check CHECK (source IN ('manual', 'auto'));
+
+COMMENT ON COLUMN safes.auto_execute IS '签名达到阈值后自动执行提案（默认关闭）';
+COMMENT ON COLUMN proposal_execution_jobs.source IS '任务来源：manual=手动提交, auto=达到签名阈值自动提交';
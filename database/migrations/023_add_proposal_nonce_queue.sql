@@ -0,0 +1,7 @@
+-- 023_add_proposal_nonce_queue.sql
+-- Safe nonce队列：提案创建时分配Safe nonce，按nonce排队执行，
+-- 同一nonce上的多个提案视为冲突，链上nonce被越过的提案重新排队
+
+CREATE INDEX IF NOT EXISTS idx_proposals_safe_nonce ON proposals(safe_id, nonce) WHERE nonce IS NOT NULL;
+
+COMMENT ON COLUMN proposals.nonce IS '提案创建时分配的Safe nonce，签名和执行都针对该nonce；被链上越过时重新分配';
@@ -0,0 +1,25 @@
+-- 024_add_proposal_rejections.sql
+-- 链上拒绝提案：在原提案的Safe nonce上执行0值自调用交易使原提案失效，
+-- 拒绝交易上链后原提案转为rejected，并记录拒绝原因、操作人和时间
+
+-- 提案类型增加rejection
+ALTER TABLE proposals
+DROP CONSTRAINT IF EXISTS check_proposal_type;
+
+ALTER TABLE proposals
+ADD CONSTRAINT check_proposal_type
+CHECK (proposal_type IN ('transfer', 'contract_call', 'add_owner', 'remove_owner', 'change_threshold', 'batch', 'token_transfer', 'rejection'));
+
+COMMENT ON COLUMN proposals.proposal_type IS '提案类型：transfer(转账), contract_call(合约调用), add_owner(添加所有者), remove_owner(移除所有者), change_threshold(修改阈值), batch(批量交易), token_transfer(代币转账), rejection(链上拒绝)';
+
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS rejects_proposal_id UUID REFERENCES proposals(id) ON DELETE SET NULL;
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS rejection_reason TEXT;
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS rejected_by UUID REFERENCES users(id);
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS rejected_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_proposals_rejects_proposal_id ON proposals(rejects_proposal_id) WHERE rejects_proposal_id IS NOT NULL;
+
+COMMENT ON COLUMN proposals.rejects_proposal_id IS '拒绝提案指向的原提案（与原提案共用Safe nonce）';
+COMMENT ON COLUMN proposals.rejection_reason IS '拒绝原因';
+COMMENT ON COLUMN proposals.rejected_by IS '发起拒绝的用户';
+COMMENT ON COLUMN proposals.rejected_at IS '提案被拒绝的时间（链上拒绝交易确认时间）';
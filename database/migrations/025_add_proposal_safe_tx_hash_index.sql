@@ -0,0 +1,7 @@
+-- 025_add_proposal_safe_tx_hash_index.sql
+-- Safe Transaction Service兼容接口：按safeTxHash查询提案和确认，
+-- 提案签名或被提交时记录safeTxHash，nonce被越过重新排队时清空
+
+CREATE INDEX IF NOT EXISTS idx_proposals_safe_tx_hash ON proposals(LOWER(safe_tx_hash)) WHERE safe_tx_hash IS NOT NULL;
+
+COMMENT ON COLUMN proposals.safe_tx_hash IS '提案在分配的Safe nonce上的safeTxHash，用于交易服务兼容接口和链上历史关联';
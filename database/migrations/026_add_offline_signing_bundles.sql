@@ -0,0 +1,17 @@
+-- 026_add_offline_signing_bundles.sql
+-- 离线签名包导出记录：导入签名文件时按bundle_checksum找到导出时的提案和safeTxHash，
+-- 拒绝不属于该签名包的签名
+
+CREATE TABLE IF NOT EXISTS offline_signing_bundles (
+    id UUID PRIMARY KEY,                             -- 与签名包中的bundle_id一致
+    checksum VARCHAR(66) NOT NULL,
+    exported_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    signer VARCHAR(42),                              -- 导出用户绑定的钱包
+    entries TEXT[] NOT NULL,                         -- 排序后的 "proposal_id:safe_tx_hash"（小写），checksum的计算输入
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_offline_signing_bundles_checksum ON offline_signing_bundles(checksum);
+
+COMMENT ON TABLE offline_signing_bundles IS '导出的离线签名包，导入签名时校验签名属于该签名包';
+COMMENT ON COLUMN offline_signing_bundles.checksum IS 'keccak256(entries按换行连接的UTF-8字节)';
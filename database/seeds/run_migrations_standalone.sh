@@ -172,6 +172,22 @@ run_migrations() {
         "008_create_safe_role_templates.sql"
         "009_create_safe_custom_roles.sql"
         "010_permission_data.sql"
+        "011_add_siwe_nonces.sql"
+        "012_add_safe_tx_params_to_proposals.sql"
+        "013_add_batch_proposals.sql"
+        "014_add_token_transfer_proposals.sql"
+        "015_add_safe_balance_snapshots.sql"
+        "016_add_safe_history.sql"
+        "017_add_safe_state_reconciliation.sql"
+        "018_add_execution_fee_params.sql"
+        "019_add_proposal_execution_txs.sql"
+        "020_add_executor_nonces.sql"
+        "021_add_proposal_execution_jobs.sql"
+        "022_add_safe_auto_execute.sql"
+        "023_add_proposal_nonce_queue.sql"
+        "024_add_proposal_rejections.sql"
+        "025_add_proposal_safe_tx_hash_index.sql"
+        "026_add_offline_signing_bundles.sql"
     )
     
     for migration in "${migrations[@]}"; do
@@ -9,7 +9,7 @@ interface AssetOverviewCardProps {
 
 /**
  * 资产概况卡片组件
- * 显示用户所有Safe的ETH余额汇总和Safe数量统计
+ * 显示用户所有Safe的ETH余额汇总、各资产明细和Safe数量统计
  */
 export const AssetOverviewCard: React.FC<AssetOverviewCardProps> = ({ data, loading }) => {
   // 加载状态显示
@@ -87,6 +87,25 @@ export const AssetOverviewCard: React.FC<AssetOverviewCardProps> = ({ data, load
           </div>
         </div>
 
+        {/* 各资产汇总 */}
+        {data.assets && data.assets.length > 0 && (
+          <div className="space-y-2">
+            <span className="text-sm font-medium text-gray-600">资产明细</span>
+            {data.assets.map((asset) => (
+              <div
+                key={`${asset.chain_id}-${asset.token_address}`}
+                className="flex items-center justify-between text-sm"
+              >
+                <span className="text-gray-700">
+                  {asset.symbol}
+                  <span className="ml-1 text-xs text-gray-400">#{asset.chain_id}</span>
+                </span>
+                <span className="font-medium text-gray-900">{asset.formatted}</span>
+              </div>
+            ))}
+          </div>
+        )}
+
         {/* Safe数量统计 */}
         <div className="grid grid-cols-2 gap-4">
           <div className="bg-purple-50 rounded-lg p-4">
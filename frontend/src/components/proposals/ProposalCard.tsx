@@ -4,6 +4,7 @@ import { Button } from '../ui/Button';
 import { Clock, Users, CheckCircle, XCircle, Eye, ExternalLink } from 'lucide-react';
 import { useWalletStore } from '../../stores/walletStore';
 import type { Proposal } from '../../stores/proposalStore';
+import { ethers } from 'ethers';
 import { weiToEth, formatEthAmount } from '../../utils/ethUtils';
 
 interface ProposalCardProps {
@@ -86,6 +87,8 @@ export const ProposalCard: React.FC<ProposalCardProps> = ({
         return 'Remove Owner';
       case 'change_threshold':
         return 'Change Threshold';
+      case 'rejection':
+        return 'Rejection';
       default:
         return 'Unknown';
     }
@@ -93,7 +96,8 @@ export const ProposalCard: React.FC<ProposalCardProps> = ({
 
   // 获取提案类型，优先使用映射后的字段
   const proposalType = proposal.type || proposal.proposal_type;
-  const toAddress = proposal.to || proposal.to_address;
+  const isTokenTransfer = proposal.proposal_type === 'token_transfer' && proposal.token_amount;
+  const toAddress = isTokenTransfer ? proposal.token_recipient : (proposal.to || proposal.to_address);
   const requiredSigs = proposal.requiredSignatures || proposal.required_signatures || 0;
   const currentSigs = proposal.currentSignatures || proposal.current_signatures || 0;
   const createdDate = proposal.createdAt || proposal.created_at;
@@ -137,7 +141,10 @@ export const ProposalCard: React.FC<ProposalCardProps> = ({
                 <span className="font-medium">To:</span> {formatAddress(toAddress)}
               </div>
               <div>
-                <span className="font-medium">Value:</span> {formatEthAmount(weiToEth(proposal.value || '0'))} ETH
+                <span className="font-medium">Value:</span>{' '}
+                {isTokenTransfer
+                  ? `${ethers.formatUnits(proposal.token_amount!, proposal.token_decimals ?? 18)} ${proposal.token_symbol || ''}`
+                  : `${formatEthAmount(weiToEth(proposal.value || '0'))} ETH`}
               </div>
               <div className="flex items-center">
                 <Users className="h-4 w-4 mr-1" />
@@ -1,7 +1,7 @@
-import React, { useEffect, useState } from 'react';
+import React, { useEffect, useRef, useState } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import { ethers } from 'ethers';
-import { useProposalStore } from '../../stores/proposalStore';
+import { useProposalStore, type ProposalTypedData } from '../../stores/proposalStore';
 import { useWalletStore } from '../../stores/walletStore';
 import { useAuthStore } from '../../stores/authStore';
 import { useToast } from '../../hooks/useToast';
@@ -22,7 +22,7 @@ import {
 export const ProposalDetailPage: React.FC = () => {
   const { id } = useParams<{ id: string }>();
   const navigate = useNavigate();
-  const { currentProposal, fetchProposal, signProposal, executeProposal, rejectProposal, isLoading } = useProposalStore();
+  const { currentProposal, fetchProposal, fetchTypedData, signProposal, executeProposal, fetchExecutionJobs, executionJobs, rejectProposal, isLoading } = useProposalStore();
   const { address, signer, isConnected } = useWalletStore();
   const { user } = useAuthStore();
   const { toasts, removeToast, success, error: showError } = useToast();
@@ -32,8 +32,29 @@ export const ProposalDetailPage: React.FC = () => {
   useEffect(() => {
     if (id) {
       fetchProposal(id);
+      fetchExecutionJobs(id);
     }
-  }, [id, fetchProposal]);
+  }, [id, fetchProposal, fetchExecutionJobs]);
+
+  // 执行任务进度通过WebSocket推送；任务未结束时轮询作为兜底，结束后重新加载提案
+  const executionJob = id ? executionJobs[id] : undefined;
+  const jobStatus = executionJob?.status;
+  const previousJobStatus = useRef(jobStatus);
+  useEffect(() => {
+    if (!id || !jobStatus) {
+      return;
+    }
+    const wasActive = previousJobStatus.current === 'queued' || previousJobStatus.current === 'running';
+    previousJobStatus.current = jobStatus;
+
+    if (jobStatus === 'queued' || jobStatus === 'running') {
+      const timer = setInterval(() => fetchExecutionJobs(id), 5000);
+      return () => clearInterval(timer);
+    }
+    if (wasActive) {
+      fetchProposal(id);
+    }
+  }, [id, jobStatus, fetchExecutionJobs, fetchProposal]);
 
   if (isLoading || !currentProposal) {
     return (
@@ -63,7 +84,8 @@ export const ProposalDetailPage: React.FC = () => {
     return signerAddress && signerAddress.toLowerCase() === address.toLowerCase();
   });
   const canSign = isConnected && !hasUserSigned && proposal.status === 'pending';
-  const canExecute = proposal.status === 'approved' && currentSigs >= requiredSigs;
+  const jobActive = executionJob?.status === 'queued' || executionJob?.status === 'running';
+  const canExecute = proposal.status === 'approved' && currentSigs >= requiredSigs && !jobActive;
   const canReject = proposal.status === 'pending' && user?.role === 'admin';
 
   const getStatusColor = (status: string) => {
@@ -140,136 +162,31 @@ export const ProposalDetailPage: React.FC = () => {
         return;
       }
       
-      const chainId = 11155111; // Sepolia chain ID
-      
-      // 企业级nonce管理：动态获取当前Safe nonce
-      let currentNonce: string;
+      // 签名数据由后端按签名验证和执行相同的规则生成（含各提案类型的调用数据和分配的nonce）
+      let payload: ProposalTypedData;
       try {
-        const authStorage = localStorage.getItem('auth-storage');
-        const authData = authStorage ? JSON.parse(authStorage) : null;
-        const token = authData?.token || authData?.state?.token;
-        
-        if (!token) {
-          showError('Authentication Error', 'Please login again');
-          return;
-        }
-        
-        // 从Safe合约获取最新nonce
-        const response = await fetch(`${import.meta.env.VITE_API_BASE_URL}/api/v1/safes/${proposal.safe_id}/nonce`, {
-          headers: {
-            'Authorization': `Bearer ${token}`,
-            ...getAuthHeaders()
-          }
-        });
-        
-        if (!response.ok) {
-          // 如果获取失败，抛出错误而不是使用fallback
-          const errorText = await response.text();
-          throw new Error(`Failed to fetch current nonce: ${response.status} ${errorText}`);
-        } else {
-          const nonceData = await response.json();
-          currentNonce = nonceData.nonce.toString();
-          console.log('Successfully fetched current Safe nonce:', currentNonce);
-        }
-        
-        console.log('Using current Safe nonce for signing:', currentNonce);
+        payload = await fetchTypedData(proposal.id);
       } catch (error) {
-        console.error('Critical error fetching nonce:', error);
+        console.error('Failed to fetch typed data:', error);
         const errorMessage = error instanceof Error ? error.message : String(error);
-        showError('Nonce获取失败', `无法获取Safe当前nonce，请稍后重试: ${errorMessage}`);
-        return; // 停止签名流程，不使用错误的nonce
+        showError('签名数据获取失败', `无法获取提案的EIP-712签名数据，请稍后重试: ${errorMessage}`);
+        return; // 停止签名流程，不使用本地构建的数据
       }
-      
-      // 使用实际提案数据构建Safe交易参数
-      const safeTxData = {
-        to: toAddress || '0x0000000000000000000000000000000000000000',
-        value: proposal.value || '0',
-        data: proposal.data || '0x',
-        operation: '0', // 默认为CALL操作
-        safeTxGas: '0',
-        baseGas: '0', 
-        gasPrice: '0',
-        gasToken: '0x0000000000000000000000000000000000000000',
-        refundReceiver: '0x0000000000000000000000000000000000000000',
-        nonce: currentNonce // 使用从区块链获取的真实nonce
-      };
 
-      // EIP-712 Domain
-      const domain = {
-        chainId: chainId,
-        verifyingContract: proposalSafeAddress
-      };
+      if (payload.safe_address.toLowerCase() !== proposalSafeAddress.toLowerCase()) {
+        showError('Safe Address Error', 'Typed data does not match the Safe of this proposal');
+        return;
+      }
 
-      // EIP-712 Types
-      const types = {
-        SafeTx: [
-          { name: 'to', type: 'address' },
-          { name: 'value', type: 'uint256' },
-          { name: 'data', type: 'bytes' },
-          { name: 'operation', type: 'uint8' },
-          { name: 'safeTxGas', type: 'uint256' },
-          { name: 'baseGas', type: 'uint256' },
-          { name: 'gasPrice', type: 'uint256' },
-          { name: 'gasToken', type: 'address' },
-          { name: 'refundReceiver', type: 'address' },
-          { name: 'nonce', type: 'uint256' }
-        ]
-      };
+      console.log('Signing EIP-712 data:', payload.typed_data, 'safeTxHash:', payload.safe_tx_hash);
 
-      console.log('Signing EIP-712 data:', { domain, types, safeTxData });
-      
       // 使用MetaMask直接签名 - 避免ENS解析问题
       let signature: string;
-      let typedData: any; // 声明在外层作用域
-      
       if (window.ethereum && window.ethereum.request) {
         try {
-          // 构建EIP-712 payload - 严格按照Safe标准格式
-          typedData = {
-            types: {
-              EIP712Domain: [
-                { name: 'chainId', type: 'uint256' },
-                { name: 'verifyingContract', type: 'address' }
-              ],
-              SafeTx: [
-                { name: 'to', type: 'address' },
-                { name: 'value', type: 'uint256' },
-                { name: 'data', type: 'bytes' },
-                { name: 'operation', type: 'uint8' },
-                { name: 'safeTxGas', type: 'uint256' },
-                { name: 'baseGas', type: 'uint256' },
-                { name: 'gasPrice', type: 'uint256' },
-                { name: 'gasToken', type: 'address' },
-                { name: 'refundReceiver', type: 'address' },
-                { name: 'nonce', type: 'uint256' }
-              ]
-            },
-            primaryType: 'SafeTx',
-            domain: {
-              chainId: chainId,
-              verifyingContract: proposalSafeAddress
-            },
-            message: {
-              to: safeTxData.to,
-              value: safeTxData.value,
-              data: safeTxData.data,
-              operation: safeTxData.operation,
-              safeTxGas: safeTxData.safeTxGas,
-              baseGas: safeTxData.baseGas,
-              gasPrice: safeTxData.gasPrice,
-              gasToken: safeTxData.gasToken,
-              refundReceiver: safeTxData.refundReceiver,
-              nonce: safeTxData.nonce
-            }
-          };
-          
-          console.log('Calling MetaMask with typedData:', typedData);
-          console.log('User address:', address);
-          
-          // 直接使用MetaMask的eth_signTypedData_v4
           signature = await window.ethereum.request({
             method: 'eth_signTypedData_v4',
-            params: [address?.toLowerCase(), JSON.stringify(typedData)]
+            params: [address?.toLowerCase(), JSON.stringify(payload.typed_data)]
           });
         } catch (metaMaskError: any) {
           console.error('MetaMask signing error:', metaMaskError);
@@ -278,20 +195,19 @@ export const ProposalDetailPage: React.FC = () => {
       } else {
         throw new Error('MetaMask not available or not properly initialized');
       }
-      
-      console.log('Generated signature:', signature);
-      
-      // 计算Safe交易哈希用于记录 (使用ethers v6语法)
+
+      // 本地复核后端返回的safeTxHash，与签名数据不一致时不提交
       const safeTxHash = ethers.TypedDataEncoder.hash(
-        typedData.domain,
-        { SafeTx: typedData.types.SafeTx },
-        typedData.message
+        payload.typed_data.domain,
+        { SafeTx: payload.typed_data.types.SafeTx },
+        payload.typed_data.message
       );
-      
-      console.log('Safe transaction hash:', safeTxHash);
-      
+      if (safeTxHash.toLowerCase() !== payload.safe_tx_hash.toLowerCase()) {
+        throw new Error(`safeTxHash mismatch: typed data hashes to ${safeTxHash}, server returned ${payload.safe_tx_hash}`);
+      }
+
       // 提交签名到后端，包含nonce和交易哈希信息
-      await signProposal(proposal.id, signature, parseInt(currentNonce), safeTxHash);
+      await signProposal(proposal.id, signature, payload.nonce, payload.safe_tx_hash);
       
       // Show success message
       success('Signature Successful', 'Your EIP-712 signature has been added to the proposal');
@@ -312,8 +228,10 @@ export const ProposalDetailPage: React.FC = () => {
     try {
       setIsExecutingLoading(true);
       await executeProposal(proposal.id);
+      success('Execution Queued', 'The proposal has been submitted for execution');
     } catch (error) {
       console.error('Failed to execute proposal:', error);
+      showError('Execution Failed', error instanceof Error ? error.message : 'Failed to execute proposal');
     } finally {
       setIsExecutingLoading(false);
     }
@@ -445,6 +363,39 @@ export const ProposalDetailPage: React.FC = () => {
                     </div>
                   )}
                   
+                  {proposal.batch_items && proposal.batch_items.length > 0 && (
+                    <div>
+                      <label className="text-sm font-medium text-gray-500">Batch Items ({proposal.batch_items.length})</label>
+                      <div className="mt-2 space-y-2">
+                        {proposal.batch_items.map((item) => (
+                          <div key={item.id} className="flex items-center justify-between p-2 bg-gray-50 rounded">
+                            <div className="min-w-0">
+                              <p className="text-sm text-gray-900 font-mono break-all">
+                                #{item.item_index + 1} {item.to_address}
+                              </p>
+                              <p className="text-xs text-gray-500">{item.value} wei</p>
+                            </div>
+                            <span className={`ml-2 px-2 py-0.5 text-xs rounded border ${getStatusColor(item.status)}`}>
+                              {getStatusLabel(item.status)}
+                            </span>
+                          </div>
+                        ))}
+                      </div>
+                    </div>
+                  )}
+
+                  {executionJob && (
+                    <div>
+                      <label className="text-sm font-medium text-gray-500">Execution Job</label>
+                      <p className="text-sm text-gray-900">
+                        {executionJob.status} (attempt {executionJob.attempts}/{executionJob.max_attempts})
+                      </p>
+                      {executionJob.status === 'failed' && executionJob.last_error && (
+                        <p className="text-xs text-red-600 break-all">{executionJob.last_error}</p>
+                      )}
+                    </div>
+                  )}
+
                   {txHash && (
                     <div>
                       <label className="text-sm font-medium text-gray-500">Transaction Hash</label>
@@ -12,8 +12,19 @@ export interface ProposalCenterCard {
   approvalRate: string;         // 提案通过率（百分比字符串）
 }
 
+export interface AssetTotal {
+  chain_id: number;
+  token_address: string;        // 原生币为0地址
+  symbol: string;
+  decimals: number;
+  balance: string;              // 最小单位
+  formatted: string;            // 按decimals格式化后的数量
+  safe_count: number;
+}
+
 export interface AssetOverviewCard {
   totalETH: string;             // 所有Safe的ETH余额汇总（ETH单位）
+  assets?: AssetTotal[];        // 按链和资产汇总的余额
   safeCount: number;            // 用户管理的Safe数量
 }
 
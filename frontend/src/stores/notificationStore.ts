@@ -1,6 +1,7 @@
 import { create } from 'zustand';
 import { buildApiUrl, API_ENDPOINTS, getAuthHeaders } from '../config/api';
 import { persist } from 'zustand/middleware';
+import { useProposalStore } from './proposalStore';
 
 // 通知类型定义
 export interface Notification {
@@ -246,6 +247,11 @@ export const useNotificationStore = create<NotificationStore>()(
                 console.log('⚠️ 提案执行失败通知已添加到通知列表');
               }
               
+              // 处理执行任务进度（只推送给提交任务的用户），同步到提案store
+              else if (message.type === 'proposal_execution_job') {
+                useProposalStore.getState().applyExecutionJobEvent(message.data);
+              }
+
               // 处理Safe创建通知
               else if (message.type === 'safe_created') {
                 const safeData = message.data;
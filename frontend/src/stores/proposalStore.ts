@@ -6,7 +6,7 @@ export interface Proposal {
   id: string;
   title: string;
   description: string;
-  proposal_type: 'transfer' | 'contract_call' | 'add_owner' | 'remove_owner' | 'change_threshold';
+  proposal_type: 'transfer' | 'contract_call' | 'add_owner' | 'remove_owner' | 'change_threshold' | 'batch' | 'token_transfer' | 'rejection';
   status: 'pending' | 'approved' | 'executed' | 'rejected';
   safe_id: string;
   to_address: string;
@@ -25,6 +25,28 @@ export interface Proposal {
   executed_at?: string;
   tx_hash?: string;
   nonce?: number;  // Safe交易nonce
+  // SafeTx参数（参与safeTxHash计算）
+  operation?: number;       // 0=CALL, 1=DELEGATECALL
+  safe_tx_gas?: string;
+  base_gas?: string;
+  gas_price?: string;
+  gas_token?: string;
+  refund_receiver?: string;
+  // ERC-20代币转账（to_address为代币合约，token_recipient为接收方）
+  token_address?: string;
+  token_symbol?: string;
+  token_decimals?: number;
+  token_recipient?: string;
+  token_amount?: string;   // 代币最小单位
+  // 批量提案子交易（MultiSend）
+  batch_items?: Array<{
+    id: string;
+    item_index: number;
+    to_address: string;
+    value: string;
+    data?: string;
+    status: 'pending' | 'executed' | 'confirmed' | 'failed';
+  }>;
   // Safe关联对象
   Safe?: {
     id: string;
@@ -48,6 +70,53 @@ export interface Proposal {
   transactionHash?: string;
 }
 
+// 提案签名使用的EIP-712数据（由后端按签名验证和执行相同的规则生成）
+export interface ProposalTypedData {
+  proposal_id: string;
+  safe_address: string;
+  chain_id: number;
+  nonce: number;
+  nonce_assigned: boolean; // false表示提案未分配nonce，按链上当前nonce生成
+  safe_tx_hash: string;
+  typed_data: {
+    types: Record<string, Array<{ name: string; type: string }>>;
+    primaryType: 'SafeTx';
+    domain: { chainId: number; verifyingContract: string };
+    message: Record<string, string | number>;
+  };
+}
+
+// 提案异步执行任务（执行请求提交到后台队列，由worker执行）
+export interface ProposalExecutionJob {
+  id: string;
+  proposal_id: string;
+  status: 'queued' | 'running' | 'succeeded' | 'failed';
+  source: 'manual' | 'auto';
+  attempts: number;
+  max_attempts: number;
+  tx_hash?: string | null;
+  error_code?: string | null;
+  last_error?: string | null;
+  next_run_at: string;
+  created_at?: string;
+  updated_at?: string;
+}
+
+// WebSocket proposal_execution_job 事件数据（stage: scheduled/running/retrying/succeeded/failed）
+export interface ProposalExecutionJobEvent {
+  job_id: string;
+  proposal_id: string;
+  stage: string;
+  status: ProposalExecutionJob['status'];
+  attempts: number;
+  max_attempts: number;
+  source: ProposalExecutionJob['source'];
+  next_run_at: string;
+  tx_hash?: string | null;
+  error_code?: string | null;
+  error?: string | null;
+}
+
 // 创建提案时的数据接口
 export interface CreateProposalData {
   safeId: string;
@@ -65,6 +134,7 @@ interface ProposalState {
   currentProposal: Proposal | null;
   isLoading: boolean;
   error: string | null;
+  executionJobs: Record<string, ProposalExecutionJob>; // 按提案ID记录最近的执行任务
   pagination: {
     page: number;
     limit: number;
@@ -77,9 +147,12 @@ interface ProposalActions {
   fetchProposals: (page?: number, limit?: number) => Promise<void>;
   fetchProposal: (id: string) => Promise<void>;
   createProposal: (proposalData: CreateProposalData) => Promise<any>;
+  fetchTypedData: (id: string) => Promise<ProposalTypedData>;
   signProposal: (id: string, signature: string, usedNonce?: number, safeTxHash?: string) => Promise<void>;
-  executeProposal: (id: string) => Promise<void>;
-  rejectProposal: (id: string) => Promise<void>;
+  executeProposal: (id: string) => Promise<ProposalExecutionJob>;
+  fetchExecutionJobs: (id: string) => Promise<void>;
+  applyExecutionJobEvent: (event: ProposalExecutionJobEvent) => void;
+  rejectProposal: (id: string, reason?: string) => Promise<void>;
   clearError: () => void;
   setCurrentProposal: (proposal: Proposal | null) => void;
 }
@@ -90,6 +163,7 @@ export const useProposalStore = create<ProposalState & ProposalActions>((set) =>
   currentProposal: null,
   isLoading: false,
   error: null,
+  executionJobs: {},
   pagination: {
     page: 1,
     limit: 10,
@@ -308,6 +382,26 @@ export const useProposalStore = create<ProposalState & ProposalActions>((set) =>
     }
   },
 
+  fetchTypedData: async (id: string) => {
+    const authStorage = localStorage.getItem('auth-storage');
+    const authData = authStorage ? JSON.parse(authStorage) : null;
+    const token = authData?.token || authData?.state?.token;
+
+    const response = await fetch(buildApiUrl(`/api/v1/proposals/${id}/typed-data`), {
+      headers: {
+        ...getAuthHeaders(),
+        'Authorization': `Bearer ${token}`,
+      },
+    });
+
+    if (!response.ok) {
+      const errorData = await response.json().catch(() => ({}));
+      throw new Error(errorData.details || errorData.error || `Failed to fetch typed data: ${response.status}`);
+    }
+
+    return response.json();
+  },
+
   signProposal: async (id: string, signature: string, usedNonce?: number, safeTxHash?: string) => {
     set({ isLoading: true, error: null });
 
@@ -388,17 +482,19 @@ export const useProposalStore = create<ProposalState & ProposalActions>((set) =>
 
       if (!response.ok) {
         const errorData = await response.json();
-        throw new Error(errorData.message || 'Failed to execute proposal');
+        throw new Error(errorData.error || errorData.message || 'Failed to execute proposal');
       }
 
-      const updatedProposal = await response.json();
-      
+      // 执行任务已提交到后台队列，进度通过WebSocket proposal_execution_job事件推送
+      const data = await response.json();
+      const job: ProposalExecutionJob = data.job;
+
       set((state) => ({
-        proposals: state.proposals.map(p => p.id === id ? updatedProposal : p),
-        currentProposal: state.currentProposal?.id === id ? updatedProposal : state.currentProposal,
+        executionJobs: { ...state.executionJobs, [id]: job },
         isLoading: false,
         error: null,
       }));
+      return job;
     } catch (error) {
       set({
         error: error instanceof Error ? error.message : 'Failed to execute proposal',
@@ -408,7 +504,66 @@ export const useProposalStore = create<ProposalState & ProposalActions>((set) =>
     }
   },
 
-  rejectProposal: async (id: string) => {
+  fetchExecutionJobs: async (id: string) => {
+    try {
+      const authStorage = localStorage.getItem('auth-storage');
+      const authData = authStorage ? JSON.parse(authStorage) : null;
+      const token = authData?.token || authData?.state?.token;
+
+      const response = await fetch(buildApiUrl(`/api/v1/proposals/${id}/execution-jobs`), {
+        headers: {
+          ...getAuthHeaders(),
+          'Authorization': `Bearer ${token}`,
+        },
+      });
+      if (!response.ok) {
+        throw new Error(`HTTP error! status: ${response.status}`);
+      }
+
+      const data = await response.json();
+      const latest: ProposalExecutionJob | undefined = data.jobs?.[0];
+      if (latest) {
+        set((state) => ({
+          executionJobs: { ...state.executionJobs, [id]: latest },
+        }));
+      }
+    } catch (error) {
+      console.error('Failed to fetch execution jobs:', error);
+    }
+  },
+
+  applyExecutionJobEvent: (event: ProposalExecutionJobEvent) => {
+    set((state) => {
+      const previous = state.executionJobs[event.proposal_id];
+      const job: ProposalExecutionJob = {
+        ...(previous?.id === event.job_id ? previous : {}),
+        id: event.job_id,
+        proposal_id: event.proposal_id,
+        status: event.status,
+        source: event.source,
+        attempts: event.attempts,
+        max_attempts: event.max_attempts,
+        tx_hash: event.tx_hash,
+        error_code: event.error_code,
+        last_error: event.error,
+        next_run_at: event.next_run_at,
+      };
+
+      // 任务成功表示执行交易已广播，提案进入executed状态等待链上确认
+      const markExecuted = (p: Proposal): Proposal =>
+        p.id === event.proposal_id && job.status === 'succeeded' && job.tx_hash
+          ? { ...p, status: 'executed', tx_hash: job.tx_hash, transactionHash: job.tx_hash }
+          : p;
+
+      return {
+        executionJobs: { ...state.executionJobs, [event.proposal_id]: job },
+        proposals: state.proposals.map(markExecuted),
+        currentProposal: state.currentProposal ? markExecuted(state.currentProposal) : state.currentProposal,
+      };
+    });
+  },
+
+  rejectProposal: async (id: string, reason?: string) => {
     set({ isLoading: true, error: null });
 
     try {
@@ -424,23 +579,39 @@ export const useProposalStore = create<ProposalState & ProposalActions>((set) =>
       const response = await fetch(buildApiUrl(`/api/v1/proposals/${id}/reject`), {
         method: 'POST',
         headers: {
+          'Content-Type': 'application/json',
           'Authorization': `Bearer ${token}`,
         },
+        body: JSON.stringify({ reason: reason || '' }),
       });
 
       if (!response.ok) {
         const errorData = await response.json();
-        throw new Error(errorData.message || 'Failed to reject proposal');
+        throw new Error(errorData.error || errorData.message || 'Failed to reject proposal');
       }
 
-      const updatedProposal = await response.json();
-      
-      set((state) => ({
-        proposals: state.proposals.map(p => p.id === id ? updatedProposal : p),
-        currentProposal: state.currentProposal?.id === id ? updatedProposal : state.currentProposal,
-        isLoading: false,
-        error: null,
-      }));
+      const data = await response.json();
+      const rejectionProposal: Proposal | undefined = data.rejection_proposal;
+
+      if (rejectionProposal) {
+        // 已分配nonce的提案需要签名并执行链上拒绝提案，原提案状态暂不变化
+        set((state) => ({
+          proposals: state.proposals.some(p => p.id === rejectionProposal.id)
+            ? state.proposals
+            : [rejectionProposal, ...state.proposals],
+          isLoading: false,
+          error: null,
+        }));
+      } else {
+        set((state) => ({
+          proposals: state.proposals.map(p => p.id === id ? { ...p, status: 'rejected' as const } : p),
+          currentProposal: state.currentProposal?.id === id
+            ? { ...state.currentProposal, status: 'rejected' as const }
+            : state.currentProposal,
+          isLoading: false,
+          error: null,
+        }));
+      }
     } catch (error) {
       set({
         error: error instanceof Error ? error.message : 'Failed to reject proposal',